@@ -0,0 +1,269 @@
+package maze
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+)
+
+// DeadEnds returns an iterator over every cell with exactly one opening, in
+// CellId order.
+func (g *Grid) DeadEnds() iter.Seq[Cell] {
+	return func(yield func(Cell) bool) {
+		for id, cell := range g.data {
+			if bits.OnesCount8(cell) == 1 && !yield(g.CellOf(id)) {
+				return
+			}
+		}
+	}
+}
+
+// SolutionStats describes the solution between a maze's endpoints: how it
+// twists and how many chances it gives a solver to go wrong.
+type SolutionStats struct {
+	// Steps is how many moves the solution takes.
+	Steps int
+	// Turns is how many times it changes direction.
+	Turns int
+	// Decisions is how many junctions it passes through, cells where
+	// there's more than one way on, not counting the way back.
+	Decisions int
+	// DeadEndLength is how many cells there are in all the branches
+	// leading off it: corridor a solver can get lost in.
+	DeadEndLength int
+}
+
+// AnalyzeSolution returns SolutionStats for the maze's solution between its
+// endpoints, and false if there's none.
+func (g *Grid) AnalyzeSolution() (SolutionStats, bool) {
+	stats, _, ok := g.analyzeSolution()
+	return stats, ok
+}
+
+// Difficulty scores how hard the maze is to solve between its endpoints: the
+// length of the solution plus, for every branch leading off it, the length of
+// the longest walk down that branch before it dead ends, plus one for each
+// turn and each decision along it.  Long false leads waste more of a
+// solver's time than short ones, and every junction is another chance to
+// take one.  It's 0 if there's no solution.
+func (g *Grid) Difficulty() int {
+	stats, branches, ok := g.analyzeSolution()
+	if !ok {
+		return 0
+	}
+	return stats.Steps + 1 + branches + stats.Turns + stats.Decisions
+}
+
+// analyzeSolution is AnalyzeSolution, also returning the sum over the
+// branches leading off the solution of the longest walk down each.
+func (g *Grid) analyzeSolution() (stats SolutionStats, branches int, ok bool) {
+	start, end := g.endpoints()
+	path := g.solveNearest([]int{start}, []int{end})
+	if path == nil {
+		return stats, 0, false
+	}
+	stats.Steps = len(path) - 1
+	for i := 2; i < len(path); i++ {
+		if path[i]-path[i-1] != path[i-1]-path[i-2] {
+			stats.Turns++
+		}
+	}
+	visited := make([]bool, len(g.data))
+	for _, id := range path {
+		visited[id] = true
+	}
+	var queue, depth []int
+	for i, id := range path {
+		ways := 0
+		for _, branch := range g.linkedNeighbors(id) {
+			if i == 0 || branch != path[i-1] {
+				ways++
+			}
+			if visited[branch] {
+				continue
+			}
+			visited[branch] = true
+			queue, depth = append(queue[:0], branch), append(depth[:0], 1)
+			longest := 0
+			for j := 0; j < len(queue); j++ {
+				if depth[j] > longest {
+					longest = depth[j]
+				}
+				for _, next := range g.linkedNeighbors(queue[j]) {
+					if !visited[next] {
+						visited[next] = true
+						queue = append(queue, next)
+						depth = append(depth, depth[j]+1)
+					}
+				}
+			}
+			branches += longest
+			stats.DeadEndLength += len(queue)
+		}
+		if ways > 1 && i < len(path)-1 {
+			stats.Decisions++
+		}
+	}
+	return stats, branches, true
+}
+
+// LongestPath returns the longest of the shortest paths between any two
+// cells, the maze's diameter: the spine the rest of the maze branches off.  It walks out from a cell to the furthest cell from it, and
+// from there to the furthest cell from that, which is exact for a perfect
+// maze.  In a maze with loops it's a long path but may not be the longest,
+// and like the solvers it only looks at the part of the maze connected to
+// the first cell.
+func (g *Grid) LongestPath() []Cell {
+	return g.PathCells(g.longestPath())
+}
+
+// longestPath is LongestPath with the path as CellIds.
+func (g *Grid) longestPath() []int {
+	if len(g.data) == 0 {
+		return nil
+	}
+	furthest := func(dist []int) int {
+		best := 0
+		for id, d := range dist {
+			if d > dist[best] {
+				best = id
+			}
+		}
+		return best
+	}
+	dist, _ := g.bfsFrom([]int{0})
+	start := furthest(dist)
+	dist, parent := g.bfsFrom([]int{start})
+	var path []int
+	for id := furthest(dist); id != start; id = parent[id] {
+		path = append(path, id)
+	}
+	path = append(path, start)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// CycleCount returns the number of independent loops in the maze: how many
+// passages could be walled up, one at a time, before it became a perfect
+// maze (or a forest of them, if parts of it are cut off).  It's 0 for a
+// perfect maze.
+func (g *Grid) CycleCount() int {
+	sets := NewDisjointSet(len(g.data))
+	cycles := 0
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			for _, d := range []Direction{E, S} {
+				if g.openings(row, col)&d == 0 || !g.inside(row+rowOffset[d], col+colOffset[d]) {
+					continue
+				}
+				if !sets.Union(g.CellId(row, col), g.CellId(row+rowOffset[d], col+colOffset[d])) {
+					cycles++
+				}
+			}
+		}
+	}
+	return cycles
+}
+
+// SimplePaths returns up to limit different paths from cell start to cell
+// end that don't visit any cell twice.  A perfect maze has
+// exactly one; each loop can multiply the number, so limit bounds how many
+// are kept, though not how long finding them takes in a maze with many
+// loops.
+func (g *Grid) SimplePaths(start, end Cell, limit int) [][]Cell {
+	if limit < 1 {
+		return nil
+	}
+	var paths [][]Cell
+	g.walkSimplePaths(g.CellIdOf(start), g.CellIdOf(end), func(path []int) bool {
+		paths = append(paths, g.PathCells(path))
+		return len(paths) < limit
+	})
+	return paths
+}
+
+// CountPaths returns how many different paths there are from cell start to
+// cell end that don't visit any cell twice, counting no further than
+// limit: 0 means the maze can't be solved and more than 1 that the
+// solution is ambiguous.
+func (g *Grid) CountPaths(start, end Cell, limit int) int {
+	if limit < 1 {
+		return 0
+	}
+	n := 0
+	g.walkSimplePaths(g.CellIdOf(start), g.CellIdOf(end), func([]int) bool {
+		n++
+		return n < limit
+	})
+	return n
+}
+
+// walkSimplePaths calls found with each path from start to end that doesn't
+// visit a cell twice, until it returns false.  The path is only valid
+// during the call.  It keeps its own stack rather than recursing, since a
+// path can be as long as the maze has cells.
+func (g *Grid) walkSimplePaths(start, end int, found func(path []int) bool) {
+	if start < 0 || start >= len(g.data) || end < 0 || end >= len(g.data) {
+		return
+	}
+	if start == end {
+		found([]int{start})
+		return
+	}
+	visited := make([]bool, len(g.data))
+	visited[start] = true
+	path := []int{start}
+	// untried[i] are the neighbours of path[i] still to go on to.
+	untried := [][]int{g.linkedNeighbors(start)}
+	for len(path) > 0 {
+		top := len(path) - 1
+		if len(untried[top]) == 0 {
+			visited[path[top]] = false
+			path, untried = path[:top], untried[:top]
+			continue
+		}
+		next := untried[top][0]
+		untried[top] = untried[top][1:]
+		if visited[next] {
+			continue
+		}
+		path = append(path, next)
+		if next == end {
+			if !found(path) {
+				return
+			}
+			path = path[:len(path)-1]
+			continue
+		}
+		visited[next] = true
+		untried = append(untried, g.linkedNeighbors(next))
+	}
+}
+
+// Warnings describes anything that stops g from being a proper maze.
+func (g *Grid) Warnings() []string {
+	var warnings []string
+	starts := g.CellIds(g.Entrances)
+	if len(starts) == 0 {
+		warnings = append(warnings, "no start")
+		starts = []int{0}
+	}
+	if len(g.Exits) == 0 {
+		warnings = append(warnings, "no finish")
+	} else if g.solveNearest(starts, g.CellIds(g.Exits)) == nil {
+		warnings = append(warnings, "the finish can't be reached from the start")
+	}
+	dist, _ := g.bfsFrom(starts)
+	unreached := 0
+	for _, d := range dist {
+		if d < 0 {
+			unreached++
+		}
+	}
+	if unreached > 0 {
+		warnings = append(warnings, fmt.Sprintf("disconnected: %d cells can't be reached from the start", unreached))
+	}
+	return warnings
+}
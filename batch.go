@@ -0,0 +1,266 @@
+package maze
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// BatchOptions are the settings for WriteBatch.
+type BatchOptions struct {
+	Rows, Cols, Count, Workers int
+	// Prefix names the files: Prefix-001.txt, Prefix-002.txt, etc.
+	Prefix string
+	// Archive, if not "", is a ZIP file to write the mazes to instead, along
+	// with their solutions and a manifest.json of seeds and stats.
+	Archive string
+	// Dedupe skips mazes with the same Fingerprint as one already written.
+	// Small grids have few enough mazes that it happens a lot.
+	Dedupe bool
+	// Unique dedupes and keeps generating until there are Count different
+	// mazes, giving up after a thousand times as many as that.
+	Unique bool
+	// Generator carves the mazes, with Bias, and Algorithm is its name for
+	// the manifest.
+	Generator Generator
+	Algorithm string
+	Bias      float64
+	// GeneratorVersion is the Grid.GeneratorVersion to carve with.
+	GeneratorVersion int
+}
+
+// WriteBatch generates opts.Count mazes using a pool of workers and writes
+// each one to its own numbered file, or to an archive.
+//
+// Every maze gets its own rand.Rand split from rng up front, so the set of
+// mazes produced doesn't depend on how the work is scheduled.
+func WriteBatch(rng *rand.Rand, opts BatchOptions) error {
+	if err := CheckGridSize(opts.Rows, opts.Cols); err != nil {
+		return err
+	}
+	var out batchWriter = fileWriter{}
+	if opts.Archive != "" {
+		zw, err := newZipWriter(opts.Archive)
+		if err != nil {
+			return err
+		}
+		out = zw
+	}
+	width := len(strconv.Itoa(opts.Count))
+	seen := map[string]bool{}
+	var manifest []*batchMaze
+	var err error
+	for attempts := 0; err == nil && len(manifest) < opts.Count; {
+		if attempts > 0 && (!opts.Unique || attempts >= maxAttempts*opts.Count) {
+			break
+		}
+		n := opts.Count - len(manifest)
+		rngs := make([]*rand.Rand, n)
+		seeds := make([]int64, n)
+		for i := range rngs {
+			rngs[i], seeds[i] = splitRand(rng)
+		}
+		attempts += n
+		err = forEachOrdered(n, opts.Workers, func(i int) *batchMaze {
+			m := newBatchMaze(rngs[i], opts)
+			m.Seed = seeds[i]
+			return m
+		}, func(m *batchMaze) error {
+			if m.err != nil {
+				return m.err
+			}
+			if opts.Dedupe || opts.Unique {
+				if seen[m.fingerprint] {
+					return nil
+				}
+				seen[m.fingerprint] = true
+			}
+			m.Name = fmt.Sprintf("%s-%0*d", opts.Prefix, width, len(manifest)+1)
+			manifest = append(manifest, m)
+			return out.add(m)
+		})
+	}
+	if cerr := out.close(manifest); err == nil {
+		err = cerr
+	}
+	if err == nil && opts.Unique && len(manifest) < opts.Count {
+		err = fmt.Errorf("only found %d different %dx%d mazes", len(manifest), opts.Rows, opts.Cols)
+	}
+	return err
+}
+
+// forEachOrdered calls fn(i) for every i from 0 to count-1 using a pool of
+// workers, and passes the results to use in order of i, stopping at the
+// first error use returns.
+func forEachOrdered(count, workers int, fn func(i int) *batchMaze, use func(m *batchMaze) error) error {
+	// Mazes can finish out of order; hold on to them until it's their turn
+	// so the output is the same however the work was scheduled.
+	type done struct {
+		i int
+		m *batchMaze
+	}
+	results := make(chan done)
+	go func() {
+		forEach(count, workers, func(i int) { results <- done{i, fn(i)} })
+		close(results)
+	}()
+	pending := map[int]*batchMaze{}
+	next := 0
+	var err error
+	for r := range results {
+		pending[r.i] = r.m
+		for m, ok := pending[next]; ok; m, ok = pending[next] {
+			delete(pending, next)
+			next++
+			if err == nil {
+				err = use(m)
+			}
+		}
+	}
+	return err
+}
+
+// forEach calls fn(i) for every i from 0 to count-1 using a pool of workers.
+func forEach(count, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// batchMaze is a maze rendered for WriteBatch, with its manifest entry.
+type batchMaze struct {
+	Name             string  `json:"name"`
+	Seed             int64   `json:"seed,omitempty"`
+	Rows             int     `json:"rows"`
+	Cols             int     `json:"cols"`
+	SolutionLength   int     `json:"solutionLength"`
+	DeadEnds         int     `json:"deadEnds"`
+	Turns            int     `json:"turns"`
+	Decisions        int     `json:"decisions"`
+	DeadEndLength    int     `json:"deadEndLength"`
+	Difficulty       int     `json:"difficulty"`
+	Algorithm        string  `json:"algorithm"`
+	Bias             float64 `json:"bias"`
+	GeneratorVersion int     `json:"generatorVersion"`
+	maze, solution   []byte
+	fingerprint      string
+	// err is why the maze couldn't be generated, if it couldn't.
+	err error
+}
+
+// newBatchMaze generates a maze as opts says and renders it with and
+// without its solution.
+func newBatchMaze(rng *rand.Rand, opts BatchOptions) *batchMaze {
+	rows, cols := opts.Rows, opts.Cols
+	grid := newGrid(rows, cols)
+	grid.GeneratorVersion = opts.GeneratorVersion
+	if err := Generate(context.Background(), opts.Generator, &grid, rng, opts.Bias); err != nil {
+		return &batchMaze{err: err}
+	}
+	path := grid.solvePath(Cell{0, 0}, Cell{rows - 1, cols - 1})
+	// Counted in steps, as maze stats does.
+	m := &batchMaze{Rows: rows, Cols: cols, SolutionLength: len(path) - 1,
+		Algorithm: opts.Algorithm, Bias: opts.Bias, GeneratorVersion: grid.GeneratorVersion}
+	for range grid.DeadEnds() {
+		m.DeadEnds++
+	}
+	stats, _ := grid.AnalyzeSolution()
+	m.Turns, m.Decisions, m.DeadEndLength = stats.Turns, stats.Decisions, stats.DeadEndLength
+	m.Difficulty = grid.Difficulty()
+	m.fingerprint = grid.Fingerprint()
+	m.maze = grid.appendText(nil, nil)
+	m.solution = grid.appendText(nil, path)
+	return m
+}
+
+// batchWriter is where WriteBatch writes its mazes.
+type batchWriter interface {
+	add(m *batchMaze) error
+	// close finishes up after the last maze, given all the mazes written.
+	close(manifest []*batchMaze) error
+}
+
+// fileWriter writes each maze to its own text file.
+type fileWriter struct{}
+
+func (fileWriter) add(m *batchMaze) error {
+	err := os.WriteFile(m.Name+".txt", m.maze, 0666)
+	m.maze, m.solution = nil, nil
+	return err
+}
+
+func (fileWriter) close([]*batchMaze) error { return nil }
+
+// zipWriter writes mazes and their solutions to a ZIP file, followed by
+// manifest.json.
+type zipWriter struct {
+	f  *os.File
+	zw *zip.Writer
+}
+
+func newZipWriter(name string) (*zipWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &zipWriter{f, zip.NewWriter(f)}, nil
+}
+
+func (z *zipWriter) write(name string, b []byte) error {
+	w, err := z.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (z *zipWriter) add(m *batchMaze) error {
+	err := z.write(m.Name+".txt", m.maze)
+	if err == nil {
+		err = z.write(m.Name+"-solution.txt", m.solution)
+	}
+	m.maze, m.solution = nil, nil
+	return err
+}
+
+func (z *zipWriter) close(manifest []*batchMaze) error {
+	return z.finish("manifest.json", manifest)
+}
+
+// finish writes v as indented JSON to the named file at the end of the
+// archive and closes it.
+func (z *zipWriter) finish(name string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err == nil {
+		err = z.write(name, append(b, '\n'))
+	}
+	if err == nil {
+		err = z.zw.Close()
+	}
+	if cerr := z.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// A bias is a number from 0 to 1 describing which way a generator prefers to
+// carve: 0 carves north-south wherever it can, producing long vertical
+// "rivers", 1 does the same east-west, and NoBias doesn't prefer either.
+const NoBias = 0.5
+
+// CheckBias returns an error if bias isn't from 0 to 1, or if it asks for a
+// preference that gen, the algorithm called name, would ignore.
+func CheckBias(name string, gen Generator, bias float64) error {
+	if !(bias >= 0 && bias <= 1) {
+		return fmt.Errorf("bad bias %g, want 0 to 1", bias)
+	}
+	if _, ok := gen.(BiasedGenerator); bias != NoBias && (!ok || ignoresBias[name]) {
+		return fmt.Errorf("the %s algorithm doesn't take a bias", name)
+	}
+	return nil
+}
+
+// shuffleBiased puts dirs in a random order where each east-west direction is
+// picked ahead of the others with weight bias and each north-south one with
+// weight 1-bias.
+func shuffleBiased(rng *rand.Rand, dirs []Direction, bias float64) {
+	weight := func(d Direction) float64 {
+		if d == E || d == W {
+			return bias
+		}
+		return 1 - bias
+	}
+	for i := range dirs {
+		total := 0.0
+		for _, d := range dirs[i:] {
+			total += weight(d)
+		}
+		// Once only zero-weight directions are left, take them in order.
+		pick := i
+		r := rng.Float64() * total
+		for j, d := range dirs[i:] {
+			if r < weight(d) {
+				pick = i + j
+				break
+			}
+			r -= weight(d)
+		}
+		dirs[i], dirs[pick] = dirs[pick], dirs[i]
+	}
+}
+
+// BiasWeight returns random edge weights for weighted Kruskal that make it
+// carve east-west edges sooner the closer bias is to 1, and north-south ones
+// sooner the closer it is to 0.
+func BiasWeight(rng *rand.Rand, bias float64) EdgeWeight {
+	return func(row, col int, d Direction) float64 {
+		if d == E || d == W {
+			return rng.Float64() * (1 - bias)
+		}
+		return rng.Float64() * bias
+	}
+}
+
+// MazifyRecBiased is MazifyRec with a preference for carving in the
+// direction given by bias.
+func (g *Grid) MazifyRecBiased(rng *rand.Rand, start Cell, bias float64) {
+	runSteps(context.Background(), NewBiasedRecStepper(g, rng, start, bias))
+}
+
+// MazifyKruskalBiased is MazifyKruskal with a preference for carving in the
+// direction given by bias.
+func (g *Grid) MazifyKruskalBiased(rng *rand.Rand, bias float64) {
+	g.MazifyWeightedKruskal(rng, BiasWeight(rng, bias))
+}
+
+// MazifyEllerBiased is MazifyEller with a preference for carving in the
+// direction given by bias.
+func (g *Grid) MazifyEllerBiased(rng *rand.Rand, bias float64) {
+	g.mazifyEller(context.Background(), rng, bias)
+}
+
+// spiralStrength is how much SpiralWeight favours the passages it prefers: a
+// passage going the wrong way costs up to this much more than the random
+// weight every edge gets.  Below about 1 the spiral is hard to make out.
+const spiralStrength = 3
+
+// SpiralPitch is the pitch the spiral algorithm uses without --pitch.  Pitches near 45 degrees line up with the grid and come out as a
+// pinwheel instead.
+const SpiralPitch = 20
+
+// SpiralGenerator returns the spiral algorithm winding with the given pitch,
+// in degrees, for --pitch.  It ignores bias.
+func SpiralGenerator(pitch float64) Generator {
+	return mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return g.MazifyWeightedKruskalContext(ctx, rng, SpiralWeight(rng, g.RowCount, g.ColCount, pitch))
+	})
+}
+
+// SpiralWeight returns random edge weights for weighted Kruskal that make it
+// carve passages winding around the middle of a rows x cols grid first, so
+// the maze swirls like a vortex.  pitch is how many degrees the passages it
+// prefers turn in from going straight around the middle: 0 prefers rings,
+// 90 spokes, and anything in between spirals, winding tighter the closer it
+// is to 0.
+func SpiralWeight(rng *rand.Rand, rows, cols int, pitch float64) EdgeWeight {
+	cx, cy := float64(cols)/2, float64(rows)/2
+	sin, cos := math.Sincos(pitch * math.Pi / 180)
+	return func(row, col int, d Direction) float64 {
+		// Where the passage is, from the middle, and the unit vector out.
+		x := float64(col) + 0.5 + float64(colOffset[d])/2 - cx
+		y := float64(row) + 0.5 + float64(rowOffset[d])/2 - cy
+		r := math.Hypot(x, y)
+		if r == 0 {
+			return rng.Float64()
+		}
+		outX, outY := x/r, y/r
+		// The way round, turned pitch in towards the middle.
+		wayX, wayY := -outY*cos-outX*sin, outX*cos-outY*sin
+		along := math.Abs(wayX*float64(colOffset[d]) + wayY*float64(rowOffset[d]))
+		return rng.Float64() + spiralStrength*(1-along)
+	}
+}
+
+// MazifySpiral carves a maze swirling around the middle, with passages
+// that turn pitch degrees in from going round it (see SpiralWeight).
+func (g *Grid) MazifySpiral(rng *rand.Rand, pitch float64) {
+	g.MazifyWeightedKruskal(rng, SpiralWeight(rng, g.RowCount, g.ColCount, pitch))
+}
@@ -0,0 +1,172 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+)
+
+// BlobbyStepper is "blobby" recursive division as a Stepper.  Instead of
+// splitting a region in two with a straight wall, it picks two of its cells
+// and floods out from both at once in random order, so the region splits
+// along the ragged frontier where the two floods meet.  A door is carved
+// through the frontier and each half is split the same way, down to single
+// cells, giving a maze of organic, cavern-like shapes.
+//
+// With a room probability above 0, each region of no more than roomSize
+// squared cells is, with that probability, left open as a cave instead of
+// being split further.  Like DivisionStepper's rooms, caves make the maze
+// imperfect.
+type BlobbyStepper struct {
+	g   *Grid
+	rng *rand.Rand
+	// cells holds every cell id, rearranged so that every region still to
+	// be split is a contiguous run of it; regions are those runs as [lo, hi)
+	// index pairs.
+	cells   []int
+	regions [][2]int
+	// mark says which region or half each cell is in during a split: cells
+	// with mark stamp are in the region being split but not in a half yet,
+	// stamp+1 and stamp+2 are in the two halves.  Bumping stamp forgets the
+	// last split without clearing mark.
+	mark     []int
+	stamp    int
+	frontier []int
+	doors    []edge
+	rooms    float64
+	carves   []edge // decided on but not carved yet
+	last     edge
+}
+
+// NewBlobbyStepper returns a Stepper that carves g with blobby recursive
+// division, leaving small regions open as caves with probability rooms.
+func NewBlobbyStepper(g *Grid, rng *rand.Rand, rooms float64) *BlobbyStepper {
+	n := g.RowCount * g.ColCount
+	s := &BlobbyStepper{
+		g:       g,
+		rng:     rng,
+		cells:   make([]int, n),
+		regions: [][2]int{{0, n}},
+		mark:    make([]int, n),
+		rooms:   rooms,
+	}
+	for i := range s.cells {
+		s.cells[i] = i
+	}
+	return s
+}
+
+func (s *BlobbyStepper) Step() bool {
+	for len(s.carves) == 0 {
+		if len(s.regions) == 0 {
+			return false
+		}
+		r := s.regions[len(s.regions)-1]
+		s.regions = s.regions[:len(s.regions)-1]
+		switch size := r[1] - r[0]; {
+		case size < 2:
+		case s.rooms > 0 && size <= roomSize*roomSize && s.rng.Float64() < s.rooms:
+			s.open(r[0], r[1])
+		default:
+			mid := s.split(r[0], r[1])
+			s.regions = append(s.regions, [2]int{r[0], mid}, [2]int{mid, r[1]})
+		}
+	}
+	e := s.carves[len(s.carves)-1]
+	s.carves = s.carves[:len(s.carves)-1]
+	s.g.carve(e.row, e.col, e.d)
+	s.last = e
+	return true
+}
+
+// open queues every wall between two cells of the region cells[lo:hi] to be
+// carved, leaving it as a cave.
+func (s *BlobbyStepper) open(lo, hi int) {
+	g := s.g
+	s.stamp += 3
+	for _, id := range s.cells[lo:hi] {
+		s.mark[id] = s.stamp
+	}
+	for _, id := range s.cells[lo:hi] {
+		row, col := id/g.ColCount, id%g.ColCount
+		if col < g.ColCount-1 && s.mark[id+1] == s.stamp {
+			s.carves = append(s.carves, edge{row, col, E})
+		}
+		if row < g.RowCount-1 && s.mark[id+g.ColCount] == s.stamp {
+			s.carves = append(s.carves, edge{row, col, S})
+		}
+	}
+}
+
+// split divides the region cells[lo:hi] into two blobs, queues a door
+// between them to be carved and rearranges the region so the first blob comes first,
+// returning where the second starts.
+func (s *BlobbyStepper) split(lo, hi int) int {
+	g, region := s.g, s.cells[lo:hi]
+	s.stamp += 3
+	unclaimed, first, second := s.stamp, s.stamp+1, s.stamp+2
+	for _, id := range region {
+		s.mark[id] = unclaimed
+	}
+	i := s.rng.Intn(len(region))
+	j := s.rng.Intn(len(region) - 1)
+	if j >= i {
+		j++
+	}
+	s.mark[region[i]], s.mark[region[j]] = first, second
+	s.frontier = append(s.frontier[:0], region[i], region[j])
+	for len(s.frontier) > 0 {
+		k := s.rng.Intn(len(s.frontier))
+		id := s.frontier[k]
+		s.frontier[k] = s.frontier[len(s.frontier)-1]
+		s.frontier = s.frontier[:len(s.frontier)-1]
+		row, col := id/g.ColCount, id%g.ColCount
+		for _, n := range neighbourSteps {
+			nextRow, nextCol := row+n.row, col+n.col
+			if !g.inside(nextRow, nextCol) {
+				continue
+			}
+			if next := g.CellId(nextRow, nextCol); s.mark[next] == unclaimed {
+				s.mark[next] = s.mark[id]
+				s.frontier = append(s.frontier, next)
+			}
+		}
+	}
+
+	mid := 0
+	s.doors = s.doors[:0]
+	for k, id := range region {
+		if s.mark[id] != first {
+			continue
+		}
+		region[mid], region[k] = region[k], region[mid]
+		mid++
+		row, col := id/g.ColCount, id%g.ColCount
+		for _, n := range neighbourSteps {
+			nextRow, nextCol := row+n.row, col+n.col
+			if g.inside(nextRow, nextCol) && s.mark[g.CellId(nextRow, nextCol)] == second {
+				s.doors = append(s.doors, edge{row, col, n.d})
+			}
+		}
+	}
+	s.carves = append(s.carves, s.doors[s.rng.Intn(len(s.doors))])
+	return lo + mid
+}
+
+func (s *BlobbyStepper) Last() (c Cell, d Direction) {
+	return Cell{s.last.row, s.last.col}, s.last.d
+}
+
+// MazifyBlobby turns the grid into a maze using blobby recursive division,
+// leaving small regions open as caves with probability rooms.
+func (g *Grid) MazifyBlobby(rng *rand.Rand, rooms float64) {
+	runSteps(context.Background(), NewBlobbyStepper(g, rng, rooms))
+}
+
+// BlobbyGenerator returns the blobby algorithm with the given room
+// probability as a Generator.
+func BlobbyGenerator(rooms float64) Generator {
+	// Blobby ignores bias.
+	return mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return runSteps(ctx, NewBlobbyStepper(g, rng, rooms))
+	})
+}
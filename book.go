@@ -0,0 +1,42 @@
+package maze
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteBook writes grids to w as a PDF puzzle book, one to a page in style,
+// followed by an answer key with the solutions four to a page, and info in
+// the document information.
+func WriteBook(w io.Writer, grids []Grid, style Style, info map[string]string) error {
+	const margin = 54
+	doc := &pdfDoc{info: info}
+	for i := range grids {
+		g := &grids[i]
+		page := doc.newPage()
+		pdfText(page, pdfPageWidth/2, pdfPageHeight-margin, 18, fmt.Sprintf("Puzzle %d", i+1))
+		pdfText(page, pdfPageWidth/2, pdfPageHeight-margin-20, 10, fmt.Sprintf("%d x %d", g.RowCount, g.ColCount))
+		pdfMaze(page, g, margin, margin, pdfPageWidth-2*margin, pdfPageHeight-3*margin, RenderOptions{Style: &style})
+	}
+
+	// The answers go in a 2x2 grid on each page.
+	cellWidth := float64(pdfPageWidth-2*margin) / 2
+	cellHeight := float64(pdfPageHeight-3*margin) / 2
+	var page = doc.newPage()
+	for i := range grids {
+		if i > 0 && i%4 == 0 {
+			page = doc.newPage()
+		}
+		if i%4 == 0 {
+			pdfText(page, pdfPageWidth/2, pdfPageHeight-margin, 18, "Answers")
+		}
+		g := &grids[i]
+		x := margin + float64(i%2)*cellWidth
+		y := margin + float64(1-i%4/2)*cellHeight
+		pdfText(page, x+cellWidth/2, y+cellHeight-14, 10, fmt.Sprintf("Puzzle %d", i+1))
+		path := g.solvePath(Cell{0, 0}, Cell{g.RowCount - 1, g.ColCount - 1})
+		pdfMaze(page, g, x+8, y+8, cellWidth-16, cellHeight-32, RenderOptions{Style: &style, Path: g.PathCells(path)})
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
@@ -0,0 +1,24 @@
+package maze
+
+import (
+	"errors"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// renderOrder draws the maze as an SVG with each cell numbered and shaded
+// by opts.Order, the order the generator carved into them, to see how an
+// algorithm works its way across the grid.  Cells are made big enough for
+// the numbers to be read.
+func renderOrder(g *Grid, w io.Writer, opts RenderOptions) error {
+	if opts.Order == nil {
+		return errors.New("the order format needs the order the maze was carved in, so only works as the maze is generated")
+	}
+	style := opts.style()
+	style.CellSize = max(style.CellSize, 6*len(strconv.Itoa(len(g.data))))
+	// The start and finish would hide their shading.
+	style.Start, style.Finish = color.RGBA{}, color.RGBA{}
+	opts.Style = &style
+	return renderSVG(g, w, opts)
+}
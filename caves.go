@@ -0,0 +1,82 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+)
+
+// CaveFill is the share of cells the caves algorithm starts off as cave
+// unless --fill says otherwise, and caveSmoothing how many rounds of the
+// cellular automaton it runs on them.
+const (
+	CaveFill      = 0.45
+	caveSmoothing = 4
+)
+
+// CavesGenerator returns the caves algorithm starting off the given share
+// of cells as cave, for --fill.  It ignores bias.
+func CavesGenerator(fill float64) Generator {
+	return mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return g.MazifyCaves(ctx, rng, fill)
+	})
+}
+
+// caveCells returns which cells, by CellId, are cave: at first each is with
+// probability fill, then each round of smoothing a cell becomes cave if at
+// least five of the eight cells around it are, stays cave if four are, and
+// otherwise becomes rock, with the cells off the edge of the grid counting
+// as rock.  The noise clumps into rounded caverns, as in a cave level of a
+// roguelike.
+func caveCells(g *Grid, rng *rand.Rand, fill float64) []bool {
+	cave := make([]bool, len(g.data))
+	for id := range cave {
+		cave[id] = rng.Float64() < fill
+	}
+	next := make([]bool, len(cave))
+	for round := 0; round < caveSmoothing; round++ {
+		for id := range cave {
+			row, col := id/g.ColCount, id%g.ColCount
+			around := 0
+			for r := row - 1; r <= row+1; r++ {
+				for c := col - 1; c <= col+1; c++ {
+					if (r != row || c != col) && g.inside(r, c) && cave[g.CellId(r, c)] {
+						around++
+					}
+				}
+			}
+			next[id] = around >= 5 || cave[id] && around == 4
+		}
+		cave, next = next, cave
+	}
+	return cave
+}
+
+// MazifyCaves carves a maze of caves joined by passages: it grows caves
+// with caveCells, fill the share of cells it starts from, opens up every
+// wall between two cave cells so each cave is one open space, then carves
+// the rest of the grid with Kruskal's algorithm, treating each cave as a
+// single cell already joined up.  So the rock between the caves is filled
+// with winding corridors, and every cave and corridor can be reached from
+// every other with no loops but those inside caves.  It returns ctx.Err()
+// if ctx is done before it finishes.
+func (g *Grid) MazifyCaves(ctx context.Context, rng *rand.Rand, fill float64) error {
+	cave := caveCells(g, rng, fill)
+	s, err := newKruskalStepper(ctx, g, rng, g.GeneratorVersion == 1)
+	if err != nil {
+		return err
+	}
+	for id, open := range cave {
+		if !open {
+			continue
+		}
+		row, col := id/g.ColCount, id%g.ColCount
+		for _, d := range []Direction{E, S} {
+			r, c := row+rowOffset[d], col+colOffset[d]
+			if g.inside(r, c) && cave[g.CellId(r, c)] {
+				g.carve(row, col, d)
+				s.sets.Union(id, g.CellId(r, c))
+			}
+		}
+	}
+	return runSteps(ctx, s)
+}
@@ -0,0 +1,83 @@
+package maze
+
+import "fmt"
+
+// Cell is a cell's position in a grid.  Everything exported that takes or
+// returns cells uses Cells, not pairs of ints that are easy to get out of
+// order: starts and finishes, entrances and exits, paths, the corners of a
+// Subgrid and the cells an Observer is told about.  The exceptions are
+// Grid's Graph methods, whose nodes are CellIds, slices with a value for
+// every cell, such as Distances returns, which are indexed by CellId, and
+// TraceStep, whose row and col are JSON.  Inside, the grid and the renderers
+// index cells by CellId, half the size for big mazes; CellOf, CellIdOf and
+// PathCells convert between the two.
+type Cell struct {
+	Row, Col int
+}
+
+// String returns the cell as "row,col", the way the command line takes it.
+func (c Cell) String() string {
+	return fmt.Sprintf("%d,%d", c.Row, c.Col)
+}
+
+// Step returns the cell next to c in direction d, which may be outside the
+// grid.
+func (c Cell) Step(d Direction) Cell {
+	return Cell{c.Row + rowOffset[d], c.Col + colOffset[d]}
+}
+
+// CellOf returns the Cell with CellId id.
+func (g *Grid) CellOf(id int) Cell {
+	return Cell{id / g.ColCount, id % g.ColCount}
+}
+
+// CellIdOf returns the CellId of c.
+func (g *Grid) CellIdOf(c Cell) int {
+	return g.CellId(c.Row, c.Col)
+}
+
+// Contains reports whether c is in the grid.
+func (g *Grid) Contains(c Cell) bool {
+	return g.inside(c.Row, c.Col)
+}
+
+// PathCells returns path, given as CellIds, as Cells.  It's nil if path is.
+func (g *Grid) PathCells(path []int) []Cell {
+	if path == nil {
+		return nil
+	}
+	cells := make([]Cell, len(path))
+	for i, id := range path {
+		cells[i] = g.CellOf(id)
+	}
+	return cells
+}
+
+// CellIds returns the CellIds of cells, or nil if there are none.
+func (g *Grid) CellIds(cells []Cell) []int {
+	if len(cells) == 0 {
+		return nil
+	}
+	ids := make([]int, len(cells))
+	for i, c := range cells {
+		ids[i] = g.CellIdOf(c)
+	}
+	return ids
+}
+
+// cells is PathCells, but nil if there are none, for the grid's entrances
+// and exits.
+func (g *Grid) cells(ids []int) []Cell {
+	if len(ids) == 0 {
+		return nil
+	}
+	return g.PathCells(ids)
+}
+
+// Endpoints returns where the maze starts and finishes: its first entrance
+// and exit, or the top left and bottom right corners for any it doesn't
+// have.
+func (g *Grid) Endpoints() (start, finish Cell) {
+	s, f := g.endpoints()
+	return g.CellOf(s), g.CellOf(f)
+}
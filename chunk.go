@@ -0,0 +1,81 @@
+package maze
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+)
+
+// ChunkSize is how many rows and columns of cells there are in a chunk of
+// the infinite maze.
+const ChunkSize = 16
+
+// chunkHash hashes seed, a tag saying what it's for and a chunk's
+// coordinates, so every chunk and edge between chunks gets its own,
+// repeatable random numbers.
+func chunkHash(seed int64, tag byte, cx, cy int) int64 {
+	var b [25]byte
+	binary.BigEndian.PutUint64(b[0:], uint64(seed))
+	b[8] = tag
+	binary.BigEndian.PutUint64(b[9:], uint64(cx))
+	binary.BigEndian.PutUint64(b[17:], uint64(cy))
+	h := fnv.New64a()
+	h.Write(b[:])
+	return int64(h.Sum64())
+}
+
+// Chunk returns the chunk at c of the infinite maze for seed, where c.Row
+// counts chunks south and c.Col chunks east; either can be negative.  The
+// same arguments always give the same chunk, so the maze can be explored by
+// generating chunks as they're needed.
+//
+// Each chunk is a perfect maze with one door through each of its edges into
+// the next chunk over; doors[d] is the row (on the E and W edges) or column
+// (on the N and S edges) of the cell on edge d with a door.  Neighbouring
+// chunks agree on the doors between them, so the whole maze is connected.
+func Chunk(seed int64, c Cell) (g Grid, doors map[Direction]int) {
+	cx, cy := c.Col, c.Row
+	g = newGrid(ChunkSize, ChunkSize)
+	// Chunks are carved as they were before version 2 of the generators, so
+	// they never change.
+	g.GeneratorVersion = 1
+	g.MazifyKruskal(rand.New(rand.NewSource(chunkHash(seed, 'c', cx, cy))))
+	// An edge's door is decided by the chunk to its west or north.
+	door := func(tag byte, cx, cy int) int {
+		return int(uint64(chunkHash(seed, tag, cx, cy)) % ChunkSize)
+	}
+	doors = map[Direction]int{
+		N: door('s', cx, cy-1),
+		E: door('e', cx, cy),
+		S: door('s', cx, cy),
+		W: door('e', cx-1, cy),
+	}
+	return g, doors
+}
+
+// ChunkRegion returns the part of the infinite maze for seed made of rows x
+// cols chunks with the chunk at start, as Chunk takes it, at the top left, as
+// a single maze with the doors between the chunks opened up.  It returns an
+// error if that's not a size NewGrid can make.
+func ChunkRegion(seed int64, start Cell, rows, cols int) (Grid, error) {
+	out, err := NewGrid(rows*ChunkSize, cols*ChunkSize)
+	if err != nil {
+		return Grid{}, err
+	}
+	for j := 0; j < rows; j++ {
+		for i := 0; i < cols; i++ {
+			g, doors := Chunk(seed, Cell{start.Row + j, start.Col + i})
+			top, left := j*ChunkSize, i*ChunkSize
+			for row := 0; row < ChunkSize; row++ {
+				copy(out.data[out.CellId(top+row, left):], g.data[g.CellId(row, 0):g.CellId(row+1, 0)])
+			}
+			if i < cols-1 {
+				out.carve(top+doors[E], left+ChunkSize-1, E)
+			}
+			if j < rows-1 {
+				out.carve(top+ChunkSize-1, left+doors[S], S)
+			}
+		}
+	}
+	return out, nil
+}
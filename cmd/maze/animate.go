@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// animateCarving returns the Carve callback for --animate: it draws g with
+// all its walls on w, a terminal, then redraws the rows each carve changes
+// in place, pausing delay after each so it can be watched.
+func animateCarving(w io.Writer, g *maze.Grid, delay time.Duration) func(c maze.Cell, d maze.Direction) {
+	buf := append([]byte(ansiClear), g.AppendText(nil, nil)...)
+	w.Write(buf)
+	redraw := func(row int) {
+		// The top wall is on line 1, so row is on line row+2.
+		buf = fmt.Appendf(buf[:0], "\x1b[%d;1H", row+2)
+		buf = g.AppendTextRow(buf, row, nil, nil)
+		w.Write(buf)
+	}
+	return func(c maze.Cell, d maze.Direction) {
+		redraw(c.Row)
+		if d == maze.N {
+			// The wall between them is drawn as the row above's floor.
+			redraw(c.Row - 1)
+		}
+		time.Sleep(delay)
+	}
+}
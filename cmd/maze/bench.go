@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+var benchSizes = []int{10, 100, 500}
+
+// benchTime is how long runBench spends on each algorithm and size, at the
+// least, as `go test -bench` does by default.
+const benchTime = time.Second
+
+// benchResult is what measureMazify found: how many mazes it made, how long
+// they took in all, and the bytes and allocations they took.
+type benchResult struct {
+	n              int
+	t              time.Duration
+	bytes, mallocs uint64
+}
+
+// measureMazify generates size x size mazes with gen, more each round, until
+// they've taken benchTime, for the same numbers the benchmarks in
+// bench_test.go report without linking the testing package into the binary.
+func measureMazify(gen maze.Generator, size int) benchResult {
+	rng := rand.New(rand.NewSource(1))
+	var r benchResult
+	for n := 1; ; n *= 2 {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			grid, _ := maze.NewGrid(size, size)
+			maze.Generate(context.Background(), gen, &grid, rng, maze.NoBias)
+		}
+		r.t = time.Since(start)
+		runtime.ReadMemStats(&after)
+		r.n, r.bytes, r.mallocs = n, after.TotalAlloc-before.TotalAlloc, after.Mallocs-before.Mallocs
+		if r.t >= benchTime {
+			return r
+		}
+	}
+}
+
+// runBench benchmarks every algorithm, or those --algorithm names, at each
+// size and prints a table.  Sizes can be given as arguments, e.g. `maze
+// bench 50 1000`.  `go test -bench .` runs the same benchmarks.
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	names := fs.String("algorithm", strings.Join(maze.GeneratorNames(), ","), "algorithms to benchmark, separated by commas")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze bench [flags] [size...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	var chosen []string
+	for _, name := range strings.Split(*names, ",") {
+		if _, ok := maze.LookupGenerator(name); !ok {
+			return fmt.Errorf("unknown algorithm %q; have %s", name, strings.Join(maze.GeneratorNames(), ", "))
+		}
+		chosen = append(chosen, name)
+	}
+	sizes := benchSizes
+	if fs.NArg() > 0 {
+		sizes = nil
+		for _, arg := range fs.Args() {
+			size, err := strconv.Atoi(arg)
+			if err != nil {
+				return err
+			}
+			sizes = append(sizes, size)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "algorithm\tsize\tns/op\tB/op\tallocs/op\tcells/s\t")
+	for _, name := range chosen {
+		gen, _ := maze.LookupGenerator(name)
+		for _, size := range sizes {
+			r := measureMazify(gen, size)
+			n := uint64(r.n)
+			cellsPerSec := float64(size*size*r.n) / r.t.Seconds()
+			fmt.Fprintf(w, "%s\t%dx%d\t%d\t%d\t%d\t%.0f\t\n", name, size, size,
+				r.t.Nanoseconds()/int64(r.n), r.bytes/n, r.mallocs/n, cellsPerSec)
+		}
+	}
+	return w.Flush()
+}
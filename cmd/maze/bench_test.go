@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// kruskalSize is the size of BenchmarkMazifyKruskalLarge's maze.  Kruskal's
+// packs its walls to make 10000x10000 mazes, which take a minute and a few
+// gigabytes, so the default is scaled down:
+//
+//	go test -run XXX -bench KruskalLarge -kruskal-size 10000
+var kruskalSize = flag.Int("kruskal-size", 2000, "size of BenchmarkMazifyKruskalLarge's maze")
+
+// benchmarkMazify runs a sub-benchmark for each of benchSizes that
+// generates a size x size maze with the algorithm name b.N times.
+func benchmarkMazify(b *testing.B, name string) {
+	gen, ok := maze.LookupGenerator(name)
+	if !ok {
+		b.Fatalf("unknown algorithm %q", name)
+	}
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				grid, _ := maze.NewGrid(size, size)
+				maze.Generate(context.Background(), gen, &grid, rng, maze.NoBias)
+			}
+		})
+	}
+}
+
+func BenchmarkMazifyRec(b *testing.B)         { benchmarkMazify(b, "rec") }
+func BenchmarkMazifyKruskal(b *testing.B)     { benchmarkMazify(b, "kruskal") }
+func BenchmarkMazifyParallel(b *testing.B)    { benchmarkMazify(b, "parallel") }
+func BenchmarkMazifyEller(b *testing.B)       { benchmarkMazify(b, "eller") }
+func BenchmarkMazifySpiral(b *testing.B)      { benchmarkMazify(b, "spiral") }
+func BenchmarkMazifyGrowingTree(b *testing.B) { benchmarkMazify(b, "growingtree") }
+func BenchmarkMazifyDivision(b *testing.B)    { benchmarkMazify(b, "division") }
+func BenchmarkMazifyBlobby(b *testing.B)      { benchmarkMazify(b, "blobby") }
+func BenchmarkMazifyPrim(b *testing.B)        { benchmarkMazify(b, "prim") }
+func BenchmarkMazifyWilson(b *testing.B)      { benchmarkMazify(b, "wilson") }
+func BenchmarkMazifyFractal(b *testing.B)     { benchmarkMazify(b, "fractal") }
+func BenchmarkMazifyOriginShift(b *testing.B) { benchmarkMazify(b, "originshift") }
+func BenchmarkMazifyCaves(b *testing.B)       { benchmarkMazify(b, "caves") }
+
+// BenchmarkMazifyKruskalLarge generates a -kruskal-size maze with Kruskal's
+// algorithm, with each version of the generators.
+func BenchmarkMazifyKruskalLarge(b *testing.B) {
+	size := *kruskalSize
+	for version := 1; version <= maze.LatestGeneratorVersion; version++ {
+		b.Run(fmt.Sprintf("%dx%d/v%d", size, size, version), func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				grid, _ := maze.NewGrid(size, size)
+				grid.GeneratorVersion = version
+				grid.MazifyKruskal(rng)
+			}
+		})
+	}
+}
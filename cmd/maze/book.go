@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runBook is the book command: it writes a PDF puzzle book of mazes that
+// get bigger as it goes, one to a page, followed by an answer key with the
+// solutions four to a page.
+func runBook(args []string) error {
+	fs := flag.NewFlagSet("book", flag.ExitOnError)
+	count := fs.Int("count", 10, "number of puzzles")
+	start := fs.Int("start", 8, "rows and columns in the first puzzle")
+	step := fs.Int("step", 4, "how many rows and columns each puzzle adds")
+	algorithm := fs.String("algorithm", "kruskal", "generation algorithm: "+strings.Join(maze.GeneratorNames(), ", "))
+	seed := fs.Int64("seed", 0, "random seed, to repeat a book (0 picks one from the clock)")
+	version := fs.Int("generator-version", maze.LatestGeneratorVersion, generatorVersionUsage)
+	theme := fs.String("theme", "print", "drawing style: "+strings.Join(maze.ThemeNames(), ", "))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze book [flags] out.pdf")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *count < 1 || *start < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	gen, ok := maze.LookupGenerator(*algorithm)
+	if !ok {
+		return fmt.Errorf("unknown algorithm %q", *algorithm)
+	}
+	style, ok := maze.LookupTheme(*theme)
+	if !ok {
+		return fmt.Errorf("unknown theme %q", *theme)
+	}
+	if err := checkGeneratorVersion(*version); err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	info := map[string]string{
+		"command":           "book",
+		"seed":              strconv.FormatInt(*seed, 10),
+		"algorithm":         *algorithm,
+		"generator-version": strconv.Itoa(*version),
+		"count":             strconv.Itoa(*count),
+		"start":             strconv.Itoa(*start),
+		"step":              strconv.Itoa(*step),
+	}
+	grids := make([]maze.Grid, *count)
+	for i := range grids {
+		size := *start + i**step
+		var err error
+		if grids[i], err = maze.NewGrid(size, size); err != nil {
+			return err
+		}
+		grids[i].GeneratorVersion = *version
+		if err := maze.Generate(context.Background(), gen, &grids[i], rng, maze.NoBias); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := maze.WriteBook(f, grids, style, info); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
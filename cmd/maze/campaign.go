@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// campaignLevel is a level's entry in a level pack's pack.json.
+type campaignLevel struct {
+	Level          int    `json:"level"`
+	File           string `json:"file"`
+	Seed           int64  `json:"seed"`
+	Rows           int    `json:"rows"`
+	Cols           int    `json:"cols"`
+	Difficulty     int    `json:"difficulty"`
+	SolutionLength int    `json:"solutionLength"`
+	// Coins are the CellIds of the level's coins, with --coins, which are
+	// also marked in its file with CoinKey, and CollectLength how long,
+	// in cells, the shortest walk collecting them all and finishing is.
+	Coins         []int `json:"coins,omitempty"`
+	CollectLength int   `json:"collectLength,omitempty"`
+}
+
+// campaignPack is the pack.json of a level pack.
+type campaignPack struct {
+	Seed             int64           `json:"seed"`
+	Algorithm        string          `json:"algorithm"`
+	GeneratorVersion int             `json:"generatorVersion"`
+	Levels           []campaignLevel `json:"levels"`
+}
+
+// maxAttempts is how many mazes campaign tries for a level, past --tries,
+// before giving up on one harder than the level before.
+const maxAttempts = 1000
+
+// runCampaign is the campaign command: it writes a ZIP level pack of mazes
+// for a game, each at least as big as the one before and strictly harder by
+// Difficulty.  Each level is a saved maze, starting at the top left and
+// finishing at the bottom right, and pack.json lists them in order.
+func runCampaign(args []string) error {
+	fs := flag.NewFlagSet("campaign", flag.ExitOnError)
+	levels := fs.Int("levels", 10, "number of levels")
+	start := fs.Int("start", 5, "rows and columns in the first level")
+	step := fs.Int("step", 2, "how many rows and columns each level adds")
+	tries := fs.Int("tries", 10, "mazes to try for each level, keeping the hardest")
+	coins := fs.Int("coins", 0, fmt.Sprintf("coins to collect in each level before its finish opens, up to %d", maze.MaxCoins))
+	algorithm := fs.String("algorithm", "kruskal", "generation algorithm: "+strings.Join(maze.GeneratorNames(), ", "))
+	seed := fs.Int64("seed", 0, "random seed, to repeat a pack (0 picks one from the clock)")
+	version := fs.Int("generator-version", maze.LatestGeneratorVersion, generatorVersionUsage)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze campaign [flags] pack.zip")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *levels < 1 || *start < 1 || *step < 0 || *tries < 1 || *coins < 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	gen, ok := maze.LookupGenerator(*algorithm)
+	if !ok {
+		return fmt.Errorf("unknown algorithm %q", *algorithm)
+	}
+	if err := checkGeneratorVersion(*version); err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	pack := campaignPack{Seed: *seed, Algorithm: *algorithm, GeneratorVersion: *version}
+	grids := make([]maze.Grid, *levels)
+	previous := 0
+	for i := range grids {
+		size := *start + i**step
+		level := campaignLevel{Level: i + 1, File: fmt.Sprintf("level-%0*d.json", len(fmt.Sprint(*levels)), i+1),
+			Rows: size, Cols: size, Difficulty: -1}
+		// Keep the hardest of the tries, and carry on past them if that
+		// still isn't harder than the level before.
+		for attempt := 0; attempt < *tries || level.Difficulty <= previous; attempt++ {
+			if attempt == maxAttempts {
+				return fmt.Errorf("no %dx%d maze harder than level %d in %d attempts", size, size, i, maxAttempts)
+			}
+			s := rng.Int63()
+			g, err := maze.NewGrid(size, size)
+			if err != nil {
+				return err
+			}
+			g.GeneratorVersion = *version
+			if err := maze.Generate(context.Background(), gen, &g, rand.New(rand.NewSource(s)), maze.NoBias); err != nil {
+				return err
+			}
+			if d := g.Difficulty(); d > level.Difficulty {
+				level.Seed, level.Difficulty = s, d
+				grids[i] = g
+			}
+		}
+		g := &grids[i]
+		g.Entrances, g.Exits = []maze.Cell{{Row: 0, Col: 0}}, []maze.Cell{{Row: g.RowCount - 1, Col: g.ColCount - 1}}
+		level.SolutionLength = len(g.SolveExits())
+		if *coins > 0 {
+			var err error
+			coins, err := g.PlaceCoins(rng, *coins)
+			if err != nil {
+				return err
+			}
+			for _, c := range coins {
+				level.Coins = append(level.Coins, g.CellIdOf(c))
+			}
+			route, err := g.CollectRoute(g.Entrances[0], g.Exits[0], coins)
+			if err != nil {
+				return err
+			}
+			level.CollectLength = len(route)
+		}
+		previous = level.Difficulty
+		pack.Levels = append(pack.Levels, level)
+	}
+
+	f, err := os.Create(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	for i, level := range pack.Levels {
+		b, err := json.Marshal(grids[i])
+		if err == nil {
+			err = writeZipFile(zw, level.File, append(b, '\n'))
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(pack, "", "  ")
+	if err == nil {
+		err = writeZipFile(zw, "pack.json", append(b, '\n'))
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeZipFile adds a file called name holding b to zw.
+func writeZipFile(zw *zip.Writer, name string, b []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import maze "github.com/overthink/maze-go"
+
+// trackCarveOrder sets g's Observer, keeping any callbacks it already has,
+// to number the cells in the order the generator first carves into them,
+// and returns the numbers, by CellId, as they fill in.  Cells the generator
+// never carves into stay -1, and the numbering starts over if the maze is
+// cleared for another attempt.
+func trackCarveOrder(g *maze.Grid) []int {
+	order := make([]int, g.RowCount*g.ColCount)
+	for i := range order {
+		order[i] = -1
+	}
+	next := 0
+	number := func(row, col int) {
+		if id := g.CellId(row, col); order[id] < 0 {
+			order[id] = next
+			next++
+		}
+	}
+	if g.Observer == nil {
+		g.Observer = &maze.Observer{}
+	}
+	carve, event := g.Observer.Carve, g.Observer.Event
+	g.Observer.Event = func(name string) {
+		if name == "clear" {
+			for i := range order {
+				order[i] = -1
+			}
+			next = 0
+		}
+		if event != nil {
+			event(name)
+		}
+	}
+	g.Observer.Carve = func(c maze.Cell, d maze.Direction) {
+		next := c.Step(d)
+		number(c.Row, c.Col)
+		number(next.Row, next.Col)
+		if carve != nil {
+			carve(c, d)
+		}
+	}
+	return order
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// parseCell parses a cell given as "row,col".
+func parseCell(s string) (maze.Cell, error) {
+	var c maze.Cell
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return c, fmt.Errorf("bad cell %q, want row,col", s)
+	}
+	var err error
+	if c.Row, err = strconv.Atoi(strings.TrimSpace(parts[0])); err != nil {
+		return c, err
+	}
+	if c.Col, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+		return c, err
+	}
+	return c, nil
+}
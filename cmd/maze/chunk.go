@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runChunks is the chunks command: it draws part of the infinite maze.
+func runChunks(args []string) error {
+	fs := flag.NewFlagSet("chunks", flag.ExitOnError)
+	seed := fs.Int64("seed", 0, "random seed of the infinite maze (0 picks one from the clock)")
+	cols := fs.Int("cols", 2, "chunks across")
+	rows := fs.Int("rows", 2, "chunks down")
+	format := fs.String("format", "text", "output format: "+strings.Join(maze.RendererNames(), ", "))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze chunks [flags] [--] [cx cy]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if (fs.NArg() != 0 && fs.NArg() != 2) || *cols < 1 || *rows < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var cx, cy int
+	if fs.NArg() == 2 {
+		var err error
+		if cx, err = strconv.Atoi(fs.Arg(0)); err != nil {
+			return err
+		}
+		if cy, err = strconv.Atoi(fs.Arg(1)); err != nil {
+			return err
+		}
+	}
+	renderer, ok := maze.LookupRenderer(*format)
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+		fmt.Fprintf(os.Stderr, "seed %d\n", *seed)
+	}
+	g, err := maze.ChunkRegion(*seed, maze.Cell{Row: cy, Col: cx}, *rows, *cols)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(&g, os.Stdout, maze.RenderOptions{})
+}
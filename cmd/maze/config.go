@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// configEntry is a setting from a config file: a key and its values, more
+// than one for a list, and the line it's on.
+type configEntry struct {
+	key    string
+	values []string
+	line   int
+}
+
+// configLine matches a key and its value in a config file, key: value as
+// YAML has it or key = value as TOML does.
+var configLine = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*([:=])\s*(.*)$`)
+
+// parseConfig reads the settings of a config file, in the plain subset of
+// YAML or TOML a list of settings needs: a key and a value to a line, each
+// value a string, quoted or not, a number or a boolean, or a list of them,
+// written [a, b] or, in YAML, as "- a" lines under the key.  # starts a
+// comment.  Keys can be written with _ for -.
+func parseConfig(b []byte) ([]configEntry, error) {
+	var entries []configEntry
+	// list is whether the last key had no value, so list items can follow.
+	list := false
+	for i, text := range strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n") {
+		line := i + 1
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			return nil, fmt.Errorf("line %d: tables aren't supported, only settings at the top level", line)
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+			// An item of the YAML list under the last key.
+			if !list {
+				return nil, fmt.Errorf("line %d: a list item with no key above it", line)
+			}
+			value, err := configValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			last := &entries[len(entries)-1]
+			last.values = append(last.values, value)
+			continue
+		}
+		m := configLine.FindStringSubmatch(trimmed)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: %q isn't key: value or key = value", line, trimmed)
+		}
+		entry := configEntry{key: strings.ReplaceAll(m[1], "_", "-"), line: line}
+		rest := strings.TrimSpace(m[3])
+		list = false
+		switch {
+		case strings.HasPrefix(rest, "["):
+			values, err := configList(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			entry.values = values
+		case rest == "" || strings.HasPrefix(rest, "#"):
+			// The list items follow, if any.
+			list = true
+		default:
+			value, err := configValue(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			entry.values = []string{value}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// configValue returns the value s, which is quoted or else runs to a
+// comment or the end of the line.
+func configValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("no value")
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		value, rest, err := configQuoted(s)
+		if err != nil {
+			return "", err
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("%q after the closing quote", rest)
+		}
+		return value, nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// configQuoted splits s, which starts with a quote, into the string it
+// quotes and the rest.  Double quotes can have backslash escapes in; single
+// quotes can't.
+func configQuoted(s string) (value, rest string, err error) {
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("no closing quote in %s", s)
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+	quoted, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", "", fmt.Errorf("bad quoted string %s", s)
+	}
+	value, err = strconv.Unquote(quoted)
+	return value, s[len(quoted):], err
+}
+
+// configList returns the values of the list s, [a, b, ...].
+func configList(s string) ([]string, error) {
+	var values []string
+	s = strings.TrimSpace(s[1:])
+	for {
+		if rest, ok := strings.CutPrefix(s, "]"); ok {
+			if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("%q after the end of the list", rest)
+			}
+			return values, nil
+		}
+		var value string
+		if s != "" && (s[0] == '"' || s[0] == '\'') {
+			var err error
+			if value, s, err = configQuoted(s); err != nil {
+				return nil, err
+			}
+		} else {
+			end := strings.IndexAny(s, ",]")
+			if end < 0 {
+				return nil, fmt.Errorf("no ] at the end of the list")
+			}
+			value, s = strings.TrimSpace(s[:end]), s[end:]
+		}
+		values = append(values, value)
+		s = strings.TrimSpace(s)
+		if rest, ok := strings.CutPrefix(s, ","); ok {
+			s = strings.TrimSpace(rest)
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, fmt.Errorf("no ] at the end of the list")
+		}
+	}
+}
+
+// applyConfig sets the flags of fs from the config file at path, each key
+// the name of a flag, except for the ones set already, so the command line
+// wins.  A list sets a flag once for each value, for flags that can be
+// given more than once.  The keys rows and cols, or size as RxC, give the
+// size, returned as the arguments to use if none were given, and outputs
+// the files to write the maze to, returned too.
+func applyConfig(fs *flag.FlagSet, path string) (args, outputs []string, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := parseConfig(b)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var rows, cols string
+	for _, e := range entries {
+		if len(e.values) == 0 {
+			return nil, nil, fmt.Errorf("%s: line %d: no value for %s", path, e.line, e.key)
+		}
+		switch e.key {
+		case "rows":
+			rows = e.values[0]
+		case "cols":
+			cols = e.values[0]
+		case "size":
+			var ok bool
+			if rows, cols, ok = strings.Cut(e.values[0], "x"); !ok {
+				return nil, nil, fmt.Errorf("%s: line %d: bad size %q, want RxC", path, e.line, e.values[0])
+			}
+		case "outputs":
+			outputs = append(outputs, e.values...)
+		default:
+			if fs.Lookup(e.key) == nil {
+				return nil, nil, fmt.Errorf("%s: line %d: no such setting %q", path, e.line, e.key)
+			}
+			if set[e.key] {
+				continue
+			}
+			for _, v := range e.values {
+				if err := fs.Set(e.key, v); err != nil {
+					return nil, nil, fmt.Errorf("%s: line %d: %s: %v", path, e.line, e.key, err)
+				}
+			}
+		}
+	}
+	switch {
+	case rows != "" && cols != "":
+		args = []string{rows, cols}
+	case rows != "":
+		args = []string{rows}
+	case cols != "":
+		args = []string{"10", cols}
+	}
+	return args, outputs, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+)
+
+// dailySeed returns the seed of the maze of the day for t: the same all day
+// (in UTC) for everyone using the same namespace, so puzzles can be shared
+// by date.
+func dailySeed(t time.Time, namespace string) int64 {
+	sum := sha256.Sum256([]byte(t.UTC().Format("2006-01-02") + "\x00" + namespace))
+	seed := int64(binary.BigEndian.Uint64(sum[:]) >> 1)
+	if seed == 0 {
+		// 0 means pick a seed from the clock.
+		seed = 1
+	}
+	return seed
+}
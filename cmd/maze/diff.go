@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runDiff is the diff command: it compares two mazes, each a file or a seed,
+// lists the walls in one but not the other, and draws the second with the
+// cells beside them marked: + where it has a wall the first doesn't, - where
+// it's missing one the first has, and * for both.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	rows := fs.Int("rows", 10, "rows in mazes given by seed")
+	cols := fs.Int("cols", 10, "columns in mazes given by seed")
+	algorithm := fs.String("algorithm", "kruskal", "generation algorithm for mazes given by seed: "+strings.Join(maze.GeneratorNames(), ", "))
+	version := fs.Int("generator-version", maze.LatestGeneratorVersion, "for mazes given by seed, "+generatorVersionUsage)
+	format := fs.String("format", "text", "output format: "+strings.Join(maze.RendererNames(), ", "))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze diff [flags] file|seed file|seed")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	renderer, ok := maze.LookupRenderer(*format)
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err := checkGeneratorVersion(*version); err != nil {
+		return err
+	}
+	var mazes [2]*maze.Grid
+	for i := range mazes {
+		g, err := loadDiffMaze(fs.Arg(i), *rows, *cols, *algorithm, *version)
+		if err != nil {
+			return err
+		}
+		mazes[i] = g
+	}
+	onlyA, onlyB, err := maze.DiffWalls(mazes[0], mazes[1])
+	if err != nil {
+		return err
+	}
+
+	g := mazes[1].Clone()
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			g.DeleteMeta(maze.Cell{Row: row, Col: col}, maze.LabelKey)
+		}
+	}
+	mark := func(row, col int, label string) {
+		if old, ok := g.Meta(maze.Cell{Row: row, Col: col}, maze.LabelKey); ok && old != label {
+			label = "*"
+		}
+		g.SetMeta(maze.Cell{Row: row, Col: col}, maze.LabelKey, label)
+	}
+	for _, walls := range []struct {
+		walls []maze.Wall
+		label string
+	}{{onlyA, "-"}, {onlyB, "+"}} {
+		for _, w := range walls.walls {
+			mark(w.Row, w.Col, walls.label)
+			next := w.Step(w.Side)
+			mark(next.Row, next.Col, walls.label)
+		}
+	}
+	if err := renderer.Render(&g, os.Stdout, maze.RenderOptions{}); err != nil {
+		return err
+	}
+	for _, w := range onlyA {
+		fmt.Fprintf(os.Stderr, "- %v\n", w)
+	}
+	for _, w := range onlyB {
+		fmt.Fprintf(os.Stderr, "+ %v\n", w)
+	}
+	fmt.Fprintf(os.Stderr, "%d walls only in %s, %d only in %s\n", len(onlyA), fs.Arg(0), len(onlyB), fs.Arg(1))
+	return nil
+}
+
+// loadDiffMaze reads the maze in the named file, or if there's no such file
+// and name is a number, generates the rows x cols maze with that seed and
+// version of the generators.
+func loadDiffMaze(name string, rows, cols int, algorithm string, version int) (*maze.Grid, error) {
+	b, err := os.ReadFile(name)
+	if err == nil {
+		return maze.ParseMaze(b)
+	}
+	seed, perr := strconv.ParseInt(name, 10, 64)
+	if !errors.Is(err, os.ErrNotExist) || perr != nil {
+		return nil, err
+	}
+	gen, ok := maze.LookupGenerator(algorithm)
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+	g, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	g.GeneratorVersion = version
+	if err := maze.Generate(context.Background(), gen, &g, rand.New(rand.NewSource(seed)), maze.NoBias); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
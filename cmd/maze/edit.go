@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+const editHelp = "arrows/hjkl move  HJKL toggle wall  s start  f finish  u undo  r redo  w save  q quit"
+
+// editor is the state of the edit command.
+type editor struct {
+	grid     *maze.Grid
+	path     string
+	row, col int
+	message  string
+}
+
+// runEdit is the edit command: a full-screen editor for the maze saved in the
+// file named by args, which is created if it doesn't exist yet.
+func runEdit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	rows := fs.Int("rows", 10, "rows in a new maze")
+	cols := fs.Int("cols", 10, "columns in a new maze")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze edit [-rows R] [-cols C] file.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	e := &editor{path: fs.Arg(0)}
+	grid, err := loadGrid(e.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		g, err := maze.NewGrid(*rows, *cols)
+		if err != nil {
+			return err
+		}
+		g.MazifyKruskal(rand.New(rand.NewSource(time.Now().UnixNano())))
+		grid = &g
+		e.message = "new maze, not saved yet"
+	case err != nil:
+		return err
+	}
+	e.grid = grid
+	e.grid.History = &maze.History{}
+
+	restore, err := enterCbreak()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	in := bufio.NewReader(os.Stdin)
+	for {
+		e.draw(os.Stdout)
+		key, err := readKey(in)
+		if err != nil {
+			return err
+		}
+		if !e.handle(key) {
+			return nil
+		}
+	}
+}
+
+// handle acts on a keypress, returning false when it's time to quit.
+func (e *editor) handle(key int) bool {
+	e.message = ""
+	move := map[int]maze.Direction{keyUp: maze.N, 'k': maze.N, keyRight: maze.E, 'l': maze.E, keyDown: maze.S, 'j': maze.S, keyLeft: maze.W, 'h': maze.W}
+	toggle := map[int]maze.Direction{'K': maze.N, 'L': maze.E, 'J': maze.S, 'H': maze.W}
+	if d, ok := move[key]; ok {
+		if next := (maze.Cell{Row: e.row, Col: e.col}).Step(d); e.grid.Contains(next) {
+			e.row, e.col = next.Row, next.Col
+		}
+		return true
+	}
+	if d, ok := toggle[key]; ok {
+		var err error
+		if e.grid.HasWall(maze.Cell{Row: e.row, Col: e.col}, d) {
+			err = e.grid.Link(maze.Cell{Row: e.row, Col: e.col}, d)
+		} else {
+			err = e.grid.Unlink(maze.Cell{Row: e.row, Col: e.col}, d)
+		}
+		if err != nil {
+			e.message = err.Error()
+		}
+		return true
+	}
+	switch key {
+	case 's':
+		e.grid.Entrances = []maze.Cell{{Row: e.row, Col: e.col}}
+	case 'f':
+		e.grid.Exits = []maze.Cell{{Row: e.row, Col: e.col}}
+	case 'u':
+		if !e.grid.Undo() {
+			e.message = "nothing to undo"
+		}
+	case 'r':
+		if !e.grid.Redo() {
+			e.message = "nothing to redo"
+		}
+	case 'w':
+		if err := saveGrid(e.path, e.grid); err != nil {
+			e.message = err.Error()
+		} else {
+			e.message = "saved " + e.path
+		}
+	case 'q', 3: // 3 is ctrl-c
+		return false
+	}
+	return true
+}
+
+// draw redraws the whole screen.
+func (e *editor) draw(w io.Writer) {
+	g := e.grid
+	buf := append([]byte(ansiClear), g.AppendTextTop(nil)...)
+	labels := make([]rune, g.ColCount)
+	colors := make([]string, g.ColCount)
+	for row := 0; row < g.RowCount; row++ {
+		for col := range labels {
+			labels[col], colors[col] = 0, ""
+			if row == e.row && col == e.col {
+				colors[col] = ansiReverse
+			}
+		}
+		for _, c := range g.Entrances {
+			if c.Row == row {
+				labels[c.Col] = 'S'
+			}
+		}
+		for _, c := range g.Exits {
+			if c.Row == row {
+				labels[c.Col] = 'F'
+			}
+		}
+		buf = g.AppendTextRow(buf, row, labels, colors)
+	}
+	buf = append(buf, fmt.Sprintf("(%d,%d)  %s\n", e.row, e.col, editHelp)...)
+	for _, warning := range g.Warnings() {
+		buf = append(buf, "warning: "+warning+"\n"...)
+	}
+	if e.message != "" {
+		buf = append(buf, e.message+"\n"...)
+	}
+	w.Write(buf)
+}
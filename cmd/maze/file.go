@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// loadGrid reads a maze saved by saveGrid.
+func loadGrid(path string) (*maze.Grid, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g maze.Grid
+	if strings.HasSuffix(path, ".pb") {
+		err = g.UnmarshalProto(b)
+	} else {
+		err = json.Unmarshal(b, &g)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// saveGrid writes g to path as JSON, or as a protocol buffer if the name
+// ends in .pb.
+func saveGrid(path string, g *maze.Grid) error {
+	if strings.HasSuffix(path, ".pb") {
+		return os.WriteFile(path, g.MarshalProto(), 0666)
+	}
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0666)
+}
+
+// saveAs writes g to path in the format its extension names: JSON or a
+// protocol buffer for .json and .pb, as saveGrid does, the text format for
+// .txt, and otherwise the renderer of that name, drawn with opts.
+func saveAs(path string, g *maze.Grid, opts maze.RenderOptions) error {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "json" || ext == "pb" {
+		return saveGrid(path, g)
+	}
+	if ext == "txt" {
+		ext = "text"
+	}
+	renderer, ok := maze.LookupRenderer(ext)
+	if !ok {
+		return fmt.Errorf("can't save a .%s file; have .json, .pb, .txt and %s", ext, strings.Join(maze.RendererNames(), ", "))
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := renderer.Render(g, f, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadImage reads a PNG, JPEG or GIF image.
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
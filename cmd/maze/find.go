@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// findMeasures are what the find command can filter mazes on, each with a
+// --min- and a --max- flag, in the order it prints them.
+var findMeasures = []struct {
+	name, what string
+	measure    func(g *maze.Grid) int
+}{
+	{"deadends", "dead ends", func(g *maze.Grid) int {
+		n := 0
+		for range g.DeadEnds() {
+			n++
+		}
+		return n
+	}},
+	{"solution", "cells in the solution", func(g *maze.Grid) int { return len(g.Solve(g.Endpoints())) }},
+	{"turns", "turns in the solution", func(g *maze.Grid) int {
+		stats, _ := g.AnalyzeSolution()
+		return stats.Turns
+	}},
+	{"decisions", "decisions along the solution", func(g *maze.Grid) int {
+		stats, _ := g.AnalyzeSolution()
+		return stats.Decisions
+	}},
+	{"difficulty", "difficulty score", (*maze.Grid).Difficulty},
+	{"longest-path", "cells in the longest path", func(g *maze.Grid) int { return len(g.LongestPath()) }},
+}
+
+// runFind is the find command: it generates mazes from one seed after
+// another, until it's found --count or run out of time, and prints the
+// seeds of those that meet all the --min- and --max- criteria, with their
+// measures, so `maze --seed` can make them.
+func runFind(args []string) error {
+	fs := flag.NewFlagSet("find", flag.ExitOnError)
+	rows := fs.Int("rows", 20, "rows in each maze")
+	cols := fs.Int("cols", 20, "columns in each maze")
+	algorithm := fs.String("algorithm", "kruskal", "generation algorithm: "+strings.Join(maze.GeneratorNames(), ", "))
+	bias := fs.Float64("bias", maze.NoBias, biasUsage)
+	seed := fs.Int64("seed", 0, "random seed the seeds tried are drawn from, to repeat a search (0 picks one from the clock)")
+	maxSeconds := fs.Float64("max-seconds", 10, "how long to search for")
+	count := fs.Int("count", 10, "stop after finding this many (0 for no limit)")
+	mins := make([]*int, len(findMeasures))
+	maxes := make([]*int, len(findMeasures))
+	for i, m := range findMeasures {
+		mins[i] = fs.Int("min-"+m.name, 0, "only mazes with at least this many "+m.what)
+		maxes[i] = fs.Int("max-"+m.name, 0, "only mazes with at most this many "+m.what+" (0 for no limit)")
+	}
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze find [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 || *rows < 1 || *cols < 1 || *count < 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	gen, ok := maze.LookupGenerator(*algorithm)
+	if !ok {
+		return fmt.Errorf("unknown algorithm %q", *algorithm)
+	}
+	if err := maze.CheckBias(*algorithm, gen, *bias); err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*maxSeconds*float64(time.Second)))
+	defer cancel()
+
+	// Print each match as it's found, the measures lined up under their
+	// names.
+	fmt.Printf("%-19s", "seed")
+	for _, m := range findMeasures {
+		fmt.Printf("  %s", m.name)
+	}
+	fmt.Println()
+	g, err := maze.NewGrid(*rows, *cols)
+	if err != nil {
+		return err
+	}
+	measures := make([]int, len(findMeasures))
+	found, tried := 0, 0
+	for ; ctx.Err() == nil && (*count == 0 || found < *count); tried++ {
+		s := rng.Int63()
+		g.Clear()
+		// Only the search is time-limited, not each maze.
+		if err := maze.Generate(context.Background(), gen, &g, rand.New(rand.NewSource(s)), *bias); err != nil {
+			return err
+		}
+		matches := true
+		for i, m := range findMeasures {
+			measures[i] = m.measure(&g)
+			if measures[i] < *mins[i] || *maxes[i] > 0 && measures[i] > *maxes[i] {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+		found++
+		fmt.Printf("%-19d", s)
+		for i, m := range findMeasures {
+			fmt.Printf("  %*d", len(m.name), measures[i])
+		}
+		fmt.Println()
+	}
+	cmd := fmt.Sprintf("maze --algorithm %s --seed SEED %d %d", *algorithm, *rows, *cols)
+	if *bias != maze.NoBias {
+		cmd = fmt.Sprintf("maze --algorithm %s --bias %g --seed SEED %d %d", *algorithm, *bias, *rows, *cols)
+	}
+	fmt.Fprintf(os.Stderr, "found %d of %d %dx%d mazes tried; make one with: %s\n", found, tried, *rows, *cols, cmd)
+	return nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	maze "github.com/overthink/maze-go"
+)
+
+const firstPersonHelp = "up/down move  left/right turn  m map  q quit"
+
+// The first person view's field of view, how far a key moves or turns the
+// player, and how close they can get to a wall, in cells and radians.
+const (
+	fpFieldOfView = math.Pi / 3
+	fpStep        = 0.35
+	fpTurn        = math.Pi / 8
+	fpMargin      = 0.2
+)
+
+// fpShades are the characters walls are drawn with, nearest first.
+var fpShades = []rune("█▓▒░")
+
+// firstPerson is the state of play --3d: where in the maze the player is,
+// in cells from the top left corner, and which way they're facing, in
+// radians clockwise from east.
+type firstPerson struct {
+	x, y, angle float64
+	minimap     bool
+	// rows and cols are the size of the screen.
+	rows, cols int
+}
+
+// newFirstPerson puts the player in the middle of the top left cell,
+// facing down its passage, on a screen of rows x cols characters.
+func newFirstPerson(g *maze.Grid, rows, cols int) *firstPerson {
+	fp := &firstPerson{x: 0.5, y: 0.5, minimap: true, rows: rows, cols: cols}
+	if g.HasWall(maze.Cell{Row: 0, Col: 0}, maze.E) {
+		fp.angle = math.Pi / 2
+	}
+	return fp
+}
+
+// handle acts on a keypress like game.handle, with up and down moving the
+// player forwards and backwards and left and right turning them.
+func (fp *firstPerson) handle(g *game, key int) bool {
+	switch key {
+	case keyUp, 'k', 'w':
+		fp.move(g, fpStep)
+	case keyDown, 'j', 's':
+		fp.move(g, -fpStep)
+	case keyLeft, 'h', 'a':
+		fp.angle -= fpTurn
+	case keyRight, 'l', 'd':
+		fp.angle += fpTurn
+	case 'm':
+		fp.minimap = !fp.minimap
+	default:
+		return key != 'q' && key != 3 // 3 is ctrl-c
+	}
+	return true
+}
+
+// move moves the player distance cells the way they're facing, or as far
+// as the walls let them, counting a move each time they step into another
+// cell.
+func (fp *firstPerson) move(g *game, distance float64) {
+	grid := g.grid
+	// Each axis is moved separately, so the player slides along walls.
+	axis := func(pos *float64, delta float64, other float64, forwards, backwards maze.Direction, vertical bool) {
+		cell, across := int(*pos), int(other)
+		row, col := across, cell
+		if vertical {
+			row, col = cell, across
+		}
+		next := *pos + delta
+		if grid.HasWall(maze.Cell{Row: row, Col: col}, forwards) {
+			next = math.Min(next, float64(cell+1)-fpMargin)
+		}
+		if grid.HasWall(maze.Cell{Row: row, Col: col}, backwards) {
+			next = math.Max(next, float64(cell)+fpMargin)
+		}
+		*pos = next
+	}
+	axis(&fp.x, distance*math.Cos(fp.angle), fp.y, maze.E, maze.W, false)
+	axis(&fp.y, distance*math.Sin(fp.angle), fp.x, maze.S, maze.N, true)
+	if row, col := int(fp.y), int(fp.x); row != g.row || col != g.col {
+		g.row, g.col = row, col
+		g.moves++
+	}
+}
+
+// cast follows a ray from the player at angle until it hits a wall, and
+// returns how far away the wall is, whether it runs north to south, and
+// the CellId of the cell on the player's side of it.
+func (fp *firstPerson) cast(g *maze.Grid, angle float64) (distance float64, northSouth bool, cell int) {
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	row, col := int(fp.y), int(fp.x)
+	// How far along the ray the next east-west and north-south cell
+	// boundaries are crossed, and how far apart the crossings are.
+	stepX, stepY := math.Abs(1/dx), math.Abs(1/dy)
+	nextX, nextY := (float64(col+1)-fp.x)*stepX, (float64(row+1)-fp.y)*stepY
+	var dirX, dirY maze.Direction = maze.E, maze.S
+	if dx < 0 {
+		nextX, dirX = (fp.x-float64(col))*stepX, maze.W
+	}
+	if dy < 0 {
+		nextY, dirY = (fp.y-float64(row))*stepY, maze.N
+	}
+	for {
+		if nextX < nextY {
+			next := (maze.Cell{Row: row, Col: col}).Step(dirX)
+			if g.HasWall(maze.Cell{Row: row, Col: col}, dirX) || !g.Contains(next) {
+				return nextX, true, g.CellId(row, col)
+			}
+			col = next.Col
+			nextX += stepX
+		} else {
+			next := (maze.Cell{Row: row, Col: col}).Step(dirY)
+			if g.HasWall(maze.Cell{Row: row, Col: col}, dirY) || !g.Contains(next) {
+				return nextY, false, g.CellId(row, col)
+			}
+			row = next.Row
+			nextY += stepY
+		}
+	}
+}
+
+// fpPixel is a character on the first person screen and its ANSI colour.
+type fpPixel struct {
+	char  rune
+	color string
+}
+
+// draw redraws the screen as the player sees it: the walls in front of
+// them, shaded lighter the further away they are, the finish's in green,
+// and the enemies as red Xs, with a map of the cells around them in the
+// corner if minimap is on.
+func (fp *firstPerson) draw(g *game, w io.Writer) {
+	rows, cols := fp.rows-1, fp.cols
+	screen := make([][]fpPixel, rows)
+	for r := range screen {
+		screen[r] = make([]fpPixel, cols)
+		for c := range screen[r] {
+			screen[r][c].char = ' '
+			if r > rows/2 {
+				screen[r][c].char = '.'
+			}
+		}
+	}
+	// plane is how far the screen is from the player, in columns; terminal
+	// characters are about twice as tall as they are wide.
+	plane := float64(cols) / 2 / math.Tan(fpFieldOfView/2)
+	depth := make([]float64, cols)
+	for c := 0; c < cols; c++ {
+		offset := fpFieldOfView * (float64(c)/float64(cols) - 0.5)
+		distance, northSouth, cell := fp.cast(g.grid, fp.angle+offset)
+		// The distance straight ahead, so walls don't bulge.
+		depth[c] = distance * math.Cos(offset)
+		height := int(plane / depth[c] / 4)
+		shade := int(depth[c] / 2)
+		if !northSouth {
+			shade++
+		}
+		pixel := fpPixel{fpShades[min(shade, len(fpShades)-1)], ""}
+		if cell == g.finish {
+			pixel.color = "\x1b[32m"
+		}
+		for r := max(0, rows/2-height); r < min(rows, rows/2+height+1); r++ {
+			screen[r][c] = pixel
+		}
+	}
+	for _, enemy := range g.enemies {
+		ex, ey := float64(enemy%g.grid.ColCount)+0.5-fp.x, float64(enemy/g.grid.ColCount)+0.5-fp.y
+		offset := math.Remainder(math.Atan2(ey, ex)-fp.angle, 2*math.Pi)
+		dist := math.Hypot(ex, ey) * math.Cos(offset)
+		if math.Abs(offset) > fpFieldOfView/2+0.2 || dist < 0.1 {
+			continue
+		}
+		centre := int((offset/fpFieldOfView + 0.5) * float64(cols))
+		size := int(plane / dist / 4)
+		for c := max(0, centre-size); c < min(cols, centre+size+1); c++ {
+			if dist >= depth[c] {
+				continue
+			}
+			for r := max(0, rows/2-size/2); r < min(rows, rows/2+size/2+1); r++ {
+				screen[r][c] = fpPixel{'X', "\x1b[31m"}
+			}
+		}
+	}
+	if fp.minimap {
+		for r, line := range fp.minimapLines(g) {
+			for c, char := range []rune(line) {
+				if r < rows && c < cols {
+					screen[r][c] = fpPixel{char, ""}
+				}
+			}
+		}
+	}
+
+	buf := []byte(ansiClear)
+	for _, line := range screen {
+		color := ""
+		for _, p := range line {
+			if p.color != color {
+				if color = p.color; color == "" {
+					buf = append(buf, ansiReset...)
+				} else {
+					buf = append(buf, color...)
+				}
+			}
+			buf = append(buf, string(p.char)...)
+		}
+		if color != "" {
+			buf = append(buf, ansiReset...)
+		}
+		buf = append(buf, '\n')
+	}
+	buf = append(buf, fmt.Sprintf("moves %d%s  %s", g.moves, g.coinStatus(), firstPersonHelp)...)
+	w.Write(buf)
+}
+
+// minimapLines returns the text of the cells around the player for the
+// minimap, with the player as an arrow the way they're facing.
+func (fp *firstPerson) minimapLines(g *game) []string {
+	const rowsAround, colsAround = 4, 8
+	grid := g.grid
+	r0, c0 := max(0, g.row-rowsAround), max(0, g.col-colsAround)
+	r1, c1 := min(grid.RowCount, g.row+rowsAround+1), min(grid.ColCount, g.col+colsAround+1)
+	sub, err := grid.Subgrid(maze.Cell{Row: r0, Col: c0}, maze.Cell{Row: r1, Col: c1})
+	if err != nil {
+		return nil
+	}
+	labels := make([]rune, sub.RowCount*sub.ColCount)
+	mark := func(id int, label rune) {
+		if row, col := id/grid.ColCount-r0, id%grid.ColCount-c0; sub.Contains(maze.Cell{Row: row, Col: col}) {
+			labels[sub.CellId(row, col)] = label
+		}
+	}
+	mark(g.finish, 'F')
+	for _, coin := range g.coins {
+		mark(coin, '$')
+	}
+	for _, enemy := range g.enemies {
+		mark(enemy, 'X')
+	}
+	// The arrow for the nearest of the four directions.
+	arrows := []rune(">v<^")
+	quarter := int(math.Round(fp.angle/(math.Pi/2))) % 4
+	labels[sub.CellId(g.row-r0, g.col-c0)] = arrows[(quarter+4)%4]
+
+	buf := sub.AppendTextTop(nil)
+	for row := 0; row < sub.RowCount; row++ {
+		from, to := sub.CellId(row, 0), sub.CellId(row+1, 0)
+		buf = sub.AppendTextRow(buf, row, labels[from:to], nil)
+	}
+	return strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+}
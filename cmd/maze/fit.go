@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// fitFormat picks the text format, or braille if that doesn't fit, to draw
+// a rows x cols maze within a terminal termRows x termCols characters.
+// (halfblock takes the same space as text, so it never fits where text
+// doesn't.)  If neither fits it returns braille and an error saying so.
+func fitFormat(rows, cols, termRows, termCols int) (string, error) {
+	if rows+1 <= termRows && 2*cols+1 <= termCols {
+		return "text", nil
+	}
+	if (2*rows+4)/4 <= termRows && cols+1 <= termCols {
+		return "braille", nil
+	}
+	return "braille", fmt.Errorf("a %dx%d maze doesn't fit in a %dx%d terminal", rows, cols, termRows, termCols)
+}
+
+// terminalSize returns the size of the terminal stdout is on, in lines and
+// columns.
+func terminalSize() (rows, cols int, err error) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdout
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscan(string(out), &rows, &cols); err != nil {
+		return 0, 0, err
+	}
+	return rows, cols, nil
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// generatorVersionUsage is the usage of every command's --generator-version
+// flag.
+const generatorVersionUsage = "carve with this version of the generators, to repeat a maze made by an older one: 1 for Kruskal's walls in their old order"
+
+// checkGeneratorVersion returns an error if v isn't a version of the
+// generators --generator-version can ask for.
+func checkGeneratorVersion(v int) error {
+	if v < 1 || v > maze.LatestGeneratorVersion {
+		return fmt.Errorf("bad --generator-version %d, want 1 to %d", v, maze.LatestGeneratorVersion)
+	}
+	return nil
+}
+
+// biasUsage is the help text for --bias, saying which algorithms take it.
+const biasUsage = "carving direction preference from 0 (north-south) to 1 (east-west), for rec, kruskal, eller, growingtree and division"
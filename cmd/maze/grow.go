@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// runGrow is the grow command: it adds rows and columns to a saved maze.
+func runGrow(args []string) error {
+	fs := flag.NewFlagSet("grow", flag.ExitOnError)
+	rows := fs.Int("rows", 0, "rows to add at the bottom")
+	cols := fs.Int("cols", 0, "columns to add on the right")
+	seed := fs.Int64("seed", 0, "random seed (0 picks one from the clock)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze grow [-rows R] [-cols C] file.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	g, err := loadGrid(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	if err := g.Grow(rand.New(rand.NewSource(*seed)), *rows, *cols); err != nil {
+		return err
+	}
+	return saveGrid(fs.Arg(0), g)
+}
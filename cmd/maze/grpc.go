@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+	"github.com/overthink/maze-go/internal/protowire"
+)
+
+// gRPC status codes.
+const (
+	grpcOK               = 0
+	grpcCancelled        = 1
+	grpcInvalidArgument  = 3
+	grpcDeadlineExceeded = 4
+	grpcUnimplemented    = 12
+	grpcInternal         = 13
+)
+
+// maxServeCells is the biggest maze the servers will generate or take in,
+// and maxSolveTime the longest they'll spend solving one.
+const (
+	maxServeCells = 4_000_000
+	maxSolveTime  = 10 * time.Second
+)
+
+// grpcError is an error with the gRPC status code to report it with.
+type grpcError struct {
+	code int
+	msg  string
+}
+
+func (e *grpcError) Error() string { return e.msg }
+
+func invalidArgument(format string, args ...interface{}) error {
+	return &grpcError{grpcInvalidArgument, fmt.Sprintf(format, args...)}
+}
+
+// contextError gives err, if it's a Context's error, the gRPC status code
+// for it: CANCELLED if the client went away, DEADLINE_EXCEEDED if time ran
+// out.
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &grpcError{grpcCancelled, err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &grpcError{grpcDeadlineExceeded, err.Error()}
+	}
+	return err
+}
+
+// grpcMethods maps the path of each method in maze.proto to a function
+// taking the encoded request to the encoded response.
+var grpcMethods = map[string]func(ctx context.Context, req []byte) ([]byte, error){
+	"/maze.v1.Maze/Generate": grpcGenerate,
+	"/maze.v1.Maze/Solve":    grpcSolve,
+	"/maze.v1.Maze/Render":   grpcRender,
+}
+
+// runGRPCServer is the grpc-server command: it serves the Maze service in
+// maze.proto, and metrics for Prometheus at /metrics.  gRPC needs HTTP/2,
+// which net/http only speaks over TLS, so without -cert and -key it makes
+// up a self-signed certificate; clients then have to skip verifying it.
+func runGRPCServer(args []string) error {
+	fs := flag.NewFlagSet("grpc-server", flag.ExitOnError)
+	addr := fs.String("addr", ":50051", "address to listen on")
+	certFile := fs.String("cert", "", "TLS certificate file")
+	keyFile := fs.String("key", "", "TLS private key file")
+	fs.Parse(args)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveGRPC)
+	mux.HandleFunc("/metrics", serveMetrics)
+	srv := &http.Server{Addr: *addr, Handler: mux}
+	if *certFile == "" || *keyFile == "" {
+		cert, err := selfSignedCert()
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		log.Printf("serving gRPC on %s with a self-signed certificate", *addr)
+	} else {
+		log.Printf("serving gRPC on %s", *addr)
+	}
+	return srv.ListenAndServeTLS(*certFile, *keyFile)
+}
+
+// selfSignedCert makes a certificate for localhost good for a year.
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "maze"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// serveGRPC handles a unary gRPC call: the request body is a single
+// length-prefixed message, and so is the response, followed by the status
+// in the trailers.
+func serveGRPC(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	finish := func(code int, msg string) {
+		w.Header().Set("Grpc-Status", strconv.Itoa(code))
+		w.Header().Set("Grpc-Message", msg)
+	}
+
+	method, ok := grpcMethods[r.URL.Path]
+	if !ok {
+		finish(grpcUnimplemented, "unknown method "+r.URL.Path)
+		return
+	}
+	var prefix [5]byte
+	if _, err := io.ReadFull(r.Body, prefix[:]); err != nil {
+		finish(grpcInvalidArgument, "missing request message")
+		return
+	}
+	if prefix[0] != 0 {
+		finish(grpcUnimplemented, "compressed messages aren't supported")
+		return
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > 4*maxServeCells {
+		finish(grpcInvalidArgument, "request too big")
+		return
+	}
+	req := make([]byte, size)
+	if _, err := io.ReadFull(r.Body, req); err != nil {
+		finish(grpcInvalidArgument, "short request message")
+		return
+	}
+
+	resp, err := method(r.Context(), req)
+	if err != nil {
+		code := grpcInternal
+		if e, ok := err.(*grpcError); ok {
+			code = e.code
+		}
+		finish(code, err.Error())
+		return
+	}
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(resp)))
+	w.Write(prefix[:])
+	w.Write(resp)
+	finish(grpcOK, "")
+}
+
+func grpcGenerate(ctx context.Context, req []byte) ([]byte, error) {
+	var rows, cols int
+	var seed int64
+	algorithm, bias := "kruskal", maze.NoBias
+	err := protowire.Read(req, func(f protowire.Field) error {
+		switch f.Num {
+		case 1:
+			rows = f.Int32()
+		case 2:
+			cols = f.Int32()
+		case 3:
+			algorithm = string(f.Data)
+		case 4:
+			seed = int64(f.V)
+		case 5:
+			bias = f.Double()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, invalidArgument("%v", err)
+	}
+	if rows < 1 || cols < 1 || rows*cols > maxServeCells {
+		return nil, invalidArgument("bad maze size %dx%d", rows, cols)
+	}
+	gen, ok := maze.LookupGenerator(algorithm)
+	if !ok {
+		return nil, invalidArgument("unknown algorithm %q", algorithm)
+	}
+	if err := maze.CheckBias(algorithm, gen, bias); err != nil {
+		return nil, invalidArgument("%v", err)
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	g, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		return nil, invalidArgument("%v", err)
+	}
+	start := time.Now()
+	if err := maze.Generate(ctx, gen, &g, mathrand.New(mathrand.NewSource(seed)), bias); err != nil {
+		return nil, err
+	}
+	observeGeneration(algorithm, rows*cols, time.Since(start))
+	resp := protowire.AppendBytes(nil, 1, g.MarshalProto())
+	return protowire.AppendInt(resp, 2, seed), nil
+}
+
+func grpcSolve(ctx context.Context, req []byte) ([]byte, error) {
+	var g *maze.Grid
+	name := "bfs"
+	err := protowire.Read(req, func(f protowire.Field) error {
+		var err error
+		switch f.Num {
+		case 1:
+			g, err = decodeGrid(f.Data)
+		case 2:
+			name = string(f.Data)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, invalidArgument("%v", err)
+	}
+	if g == nil || g.RowCount == 0 {
+		return nil, invalidArgument("no maze given")
+	}
+	solver, ok := maze.LookupSolver(name)
+	if !ok {
+		return nil, invalidArgument("unknown solver %q", name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, maxSolveTime)
+	defer cancel()
+	start, finish := g.Endpoints()
+	path, err := maze.FindPathContext(ctx, solver, g, start, finish)
+	if ctx.Err() != nil {
+		return nil, contextError(err)
+	}
+	if err != nil && !errors.Is(err, maze.ErrNoPath) {
+		return nil, invalidArgument("%v", err)
+	}
+	return protowire.AppendInts(nil, 1, g.CellIds(path)), nil
+}
+
+func grpcRender(ctx context.Context, req []byte) ([]byte, error) {
+	var g *maze.Grid
+	format, theme, solution := "text", "classic", false
+	err := protowire.Read(req, func(f protowire.Field) error {
+		var err error
+		switch f.Num {
+		case 1:
+			g, err = decodeGrid(f.Data)
+		case 2:
+			format = string(f.Data)
+		case 3:
+			theme = string(f.Data)
+		case 4:
+			solution = f.V != 0
+		}
+		return err
+	})
+	if err != nil {
+		return nil, invalidArgument("%v", err)
+	}
+	if g == nil || g.RowCount == 0 {
+		return nil, invalidArgument("no maze given")
+	}
+	renderer, ok := maze.LookupRenderer(format)
+	if !ok {
+		return nil, invalidArgument("unknown format %q", format)
+	}
+	style, ok := maze.LookupTheme(theme)
+	if !ok {
+		return nil, invalidArgument("unknown theme %q", theme)
+	}
+	opts := maze.RenderOptions{Style: &style}
+	if solution {
+		ctx, cancel := context.WithTimeout(ctx, maxSolveTime)
+		defer cancel()
+		start, finish := g.Endpoints()
+		var path []maze.Cell
+		bfs, _ := maze.LookupSolver("bfs")
+		path, err = maze.FindPathContext(ctx, bfs, g, start, finish)
+		opts.Path = path
+		if ctx.Err() != nil {
+			return nil, contextError(err)
+		}
+		if errors.Is(err, maze.ErrNoPath) {
+			return nil, invalidArgument("no solution")
+		} else if err != nil {
+			return nil, invalidArgument("%v", err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := renderer.Render(g, &buf, opts); err != nil {
+		return nil, err
+	}
+	rendersTotal.inc(format)
+	return protowire.AppendBytes(nil, 1, buf.Bytes()), nil
+}
+
+// decodeGrid decodes a Grid message from a client, checking that it's a
+// maze the server should work on.
+func decodeGrid(b []byte) (*maze.Grid, error) {
+	var g maze.Grid
+	if err := g.UnmarshalProto(b); err != nil {
+		return nil, err
+	}
+	if g.RowCount*g.ColCount > maxServeCells {
+		return nil, fmt.Errorf("maze of %dx%d is too big", g.RowCount, g.ColCount)
+	}
+	return &g, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runHyper is the hyper command: it generates a maze of as many dimensions
+// as the size given has, e.g. 6x6x3x3 for a 4D one 6 rows by 6 columns by 3
+// by 3, and prints it as a grid of 2D slices with HyperGrid.Fprint.  The
+// start is the top left of the first slice and the finish the bottom right
+// of the last.
+func runHyper(args []string) error {
+	fs := flag.NewFlagSet("hyper", flag.ExitOnError)
+	algorithm := fs.String("algorithm", "kruskal", "generation algorithm: kruskal, prim, rec or wilson")
+	seed := fs.Int64("seed", 0, "random seed, to repeat a maze (0 picks one from the clock)")
+	solution := fs.Bool("solution", false, "mark the shortest route from start to finish")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze hyper [flags] rowsxcolsxZxW...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var dims []int
+	for _, s := range strings.Split(fs.Arg(0), "x") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("bad size %q, want e.g. 6x6x3x3", fs.Arg(0))
+		}
+		dims = append(dims, n)
+	}
+	h, err := maze.NewHyperGrid(dims)
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	if err := h.Mazify(context.Background(), rand.New(rand.NewSource(*seed)), *algorithm); err != nil {
+		return err
+	}
+	var path []int
+	if *solution {
+		path = h.Solve()
+	}
+	return h.Fprint(os.Stdout, path)
+}
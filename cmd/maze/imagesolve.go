@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runSolveImage is the solve-image command: it solves a picture of a maze,
+// named by args, and writes it with the solution drawn in as a PNG.
+func runSolveImage(args []string) error {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: maze solve-image in.png|in.jpg out.png")
+		os.Exit(2)
+	}
+	img, err := loadImage(args[0])
+	if err != nil {
+		return err
+	}
+	solved, err := maze.SolveImage(img)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(args[1])
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, solved); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runInfo is the info command: it prints the parameters embedded in the
+// rendered maze named by args and the command that regenerates it.
+func runInfo(args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: maze info file.svg|file.png|file.pdf|file.dxf")
+		os.Exit(2)
+	}
+	b, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	info, err := maze.ReadInfo(b)
+	if err != nil {
+		return err
+	}
+	if len(info) == 0 {
+		return fmt.Errorf("%s has no maze info", args[0])
+	}
+	for _, key := range slices.Sorted(maps.Keys(info)) {
+		fmt.Printf("%s: %s\n", key, info[key])
+	}
+	fmt.Printf("regenerate with: %s\n", maze.RegenerateCommand(info))
+	return nil
+}
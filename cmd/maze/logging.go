@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// logLevels maps the names --log-level takes to their slog levels.
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// newLogger returns a logger writing records at level, one of logLevels,
+// and above to w as text, for --log-level and --debug.
+func newLogger(w io.Writer, level string) (*slog.Logger, error) {
+	l, ok := logLevels[strings.ToLower(level)]
+	if !ok {
+		return nil, fmt.Errorf("unknown log level %q, want debug, info, warn or error", level)
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: l})), nil
+}
+
+// logPhases sets g's Observer, keeping any callbacks it already has, to
+// send logger the algorithms' internals at debug level and each phase they
+// reach, with how long since the last one and since the start, and each
+// attempt cleared to start over.
+func logPhases(g *maze.Grid, logger *slog.Logger) {
+	if g.Observer == nil {
+		g.Observer = &maze.Observer{}
+	}
+	g.Observer.Log = logger
+	start := time.Now()
+	last, attempt := start, 0
+	event := g.Observer.Event
+	g.Observer.Event = func(name string) {
+		now := time.Now()
+		if name == "clear" {
+			attempt++
+			logger.Debug("starting over", "attempt", attempt, "since", now.Sub(last), "elapsed", now.Sub(start))
+		} else {
+			logger.Debug("phase", "name", name, "since", now.Sub(last), "elapsed", now.Sub(start))
+		}
+		last = now
+		if event != nil {
+			event(name)
+		}
+	}
+}
@@ -0,0 +1,804 @@
+// Command maze generates mazes and prints them, and has subcommands to
+// solve, edit, play, serve and analyse them.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "bench":
+			run = runBench
+		case "edit":
+			run = runEdit
+		case "solve":
+			run = runSolve
+		case "solve-image":
+			run = runSolveImage
+		case "info":
+			run = runInfo
+		case "book":
+			run = runBook
+		case "poster":
+			run = runPoster
+		case "find":
+			run = runFind
+		case "campaign":
+			run = runCampaign
+		case "play":
+			run = runPlay
+		case "race-server":
+			run = runRaceServer
+		case "race":
+			run = runRace
+		case "grpc-server":
+			run = runGRPCServer
+		case "ssh-server":
+			run = runSSHServer
+		case "serve":
+			run = runServe
+		case "grow":
+			run = runGrow
+		case "chunks":
+			run = runChunks
+		case "diff":
+			run = runDiff
+		case "repl":
+			run = runRepl
+		case "uniformity":
+			run = runUniformity
+		case "view":
+			run = runView
+		case "replay":
+			run = runReplay
+		case "hyper":
+			run = runHyper
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	count := flag.Int("count", 1, "number of mazes to generate; more than one writes numbered files")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of mazes generated concurrently with --count")
+	out := flag.String("out", "maze", "output file prefix used with --count")
+	archive := flag.String("zip", "", "with --count, write the mazes, their solutions and a manifest.json into this ZIP file")
+	dedupe := flag.Bool("dedupe", false, "with --count, skip mazes identical to one already written")
+	unique := flag.Bool("unique", false, "with --count, skip identical mazes and keep going until there are --count different ones")
+	stream := flag.Bool("stream", false, "generate with Eller's algorithm and print each row as it's made")
+	checkpoint := flag.String("checkpoint", "", "with --stream, save progress to `file` as it goes and carry on from it if it exists")
+	progress := flag.Bool("progress", false, "report generation progress on stderr")
+	animate := flag.Bool("animate", false, "show the maze being carved, redrawn in place in the terminal")
+	trace := flag.String("trace", "", "write each wall the generator removes to `file`, a line of JSON apiece")
+	frameDelay := flag.Duration("frame-delay", 10*time.Millisecond, "with --animate, how long to pause after each wall is carved")
+	algorithm := flag.String("algorithm", "kruskal", "generation algorithm: "+strings.Join(maze.GeneratorNames(), ", "))
+	bias := flag.Float64("bias", maze.NoBias, biasUsage)
+	rooms := flag.Float64("rooms", 0, "with --algorithm division or blobby, the chance of leaving each small region open as a room")
+	texture := flag.Float64("texture", maze.GrowingTreeTexture, "with --algorithm growingtree, its texture from 0 (long winding passages) to 1 (short bushy ones)")
+	fill := flag.Float64("fill", maze.CaveFill, "with --algorithm caves, the share of cells that start as cave, from 0 to 1")
+	pitch := flag.Float64("pitch", maze.SpiralPitch, "with --algorithm spiral, how tightly its passages wind, in degrees from 0 (rings) to 90 (spokes)")
+	ice := flag.Int("ice", 0, "regenerate until the maze can be solved sliding on ice, each move going on until a wall, in at least `N` slides")
+	routes := flag.Int("routes", 0, "knock down walls until there are at least this many different routes to the finish of about the same length")
+	routeSlack := flag.Float64("route-slack", 0.2, "with --routes, how much longer than the shortest, as a fraction, the routes can be")
+	loops := flag.Float64("loops", 0, "knock down this fraction of the walls left after generating, for a maze with loops")
+	braid := flag.Float64("braid", 0, "after generating, open up each dead end with this probability, for a maze with loops and fewer dead ends")
+	straighten := flag.Float64("straighten", 0, "after generating, rewire each bend into a straight with this probability, for a calmer maze with fewer zig-zags")
+	straightenSlack := flag.Float64("straighten-slack", 0.1, "with --straighten, how much the solution's length can change, as a fraction")
+	place := flag.Bool("place", false, "suggest a spawn, an exit, a boss, treasure and enemies for a roguelike level, drawn in the text format and saved with the maze")
+	treasure := flag.Int("treasure", 5, "with --place, how many treasures to put in the deepest dead ends")
+	enemies := flag.Int("enemies", 5, "with --place, how many enemies to scatter away from the spawn")
+	oneWay := flag.Float64("one-way", 0, "make this fraction of the passages one-way, always leaving a way to the finish")
+	minRatio := flag.Float64("min-solution-ratio", 0, "regenerate until the solution is at least this many times the grid perimeter")
+	var waypoints []string
+	flag.Func("waypoint", "make the solution pass through `row,col` (repeat for more, visited in order)", func(s string) error {
+		waypoints = append(waypoints, s)
+		return nil
+	})
+	search := flag.Duration("search", 0, "spend this long trying seeds and keep the maze scoring best on --objective")
+	objective := flag.String("objective", "difficulty", "what --search maximises: "+strings.Join(maze.ObjectiveNames(), ", "))
+	regions := flag.Int("regions", 0, "colour the maze by splitting it into this many regions")
+	text := flag.String("text", "", "write this in the maze, in passages walled off in the shape of the letters")
+	shape := flag.String("shape", "", "carve the maze in a shape instead of a rectangle: "+strings.Join(maze.ShapeNames(), ", "))
+	topology := flag.String("topology", "", "carve the maze on a surface, drawn flat with the cells joined across its seams labelled alike: "+strings.Join(maze.TopologyNames(), ", "))
+	rowWidths := flag.String("row-widths", "", "carve a ragged maze with rows this many cells wide, e.g. 1,3,5,7 for a pyramid, instead of rows x cols")
+	align := flag.String("align", "left", "with --row-widths, line the rows up on the left, right or centre")
+	hybrid := flag.String("hybrid", "", "carve each region of the maze with its own algorithm, e.g. kruskal,rec for Kruskal's passages on the left and rec's on the right")
+	hybridSplit := flag.String("hybrid-split", "columns", "with --hybrid, divide the maze into regions as "+strings.Join(maze.HybridSplitNames(), ", ")+", or as drawn in `file` in digits, 0 for the first")
+	symmetry := flag.String("symmetry", "", "make a symmetric maze: mirror, rotate180 or rotate90")
+	timeout := flag.Duration("timeout", 0, "give up if generation takes longer than this (0 means no limit)")
+	format := flag.String("format", "text", "output format: "+strings.Join(maze.RendererNames(), ", "))
+	theme := flag.String("theme", "classic", "drawing style for the svg, png and pdf formats: "+strings.Join(maze.ThemeNames(), ", "))
+	glyphNamed := flag.String("glyphs", "single", "characters for the unicode format's walls: "+strings.Join(maze.GlyphNames(), ", ")+", or 18 of your own, the corners in the order 1 up + 2 right + 4 down + 8 left, then the horizontal and vertical walls")
+	palette := flag.String("palette", "default", "colours for regions, compared paths, the solution and the heatmap, e.g. colour blind safe ones: "+strings.Join(maze.PaletteNames(), ", "))
+	cellSize := flag.Int("cell-size", 0, "cell width in pixels for the svg and png formats (0 uses the theme's)")
+	corridor := flag.Int("corridor", 1, "how many tiles wide passages are in the tiles format; over 1 thickens the walls of the svg, png and pdf formats to match")
+	material := flag.String("material", "", "with --format dxf, the sheet to laser cut from, `WxHxT` millimetres: the walls' outlines are cut T thick, or their middles if T is 0 (default A4, 297x210x0)")
+	kerf := flag.Float64("kerf", 0, "with --format dxf, how wide the laser cuts, in millimetres, to allow for")
+	wallWidth := flag.Int("wall-width", 0, "wall thickness in pixels for the svg, png and pdf formats (0 uses the theme's)")
+	showSolution := flag.Bool("solution", false, "draw the solution")
+	arrows := flag.Bool("arrows", false, "with --solution, draw it in the text formats as arrows rather than dots")
+	markers := flag.Bool("markers", false, "mark the start and finish: S and F in the text format, a green dot and a chequered flag in the svg, png and pdf formats")
+	openings := flag.Bool("openings", false, "leave gaps in the outer wall by the start and finish, with arrows in and out in the svg, png and pdf formats")
+	showSpine := flag.Bool("longest-path", false, "draw the longest path through the maze with the svg, png, pdf and html formats")
+	background := flag.String("background", "", "draw the png format over this PNG, JPEG or GIF image")
+	textCell := flag.String("text-cell", "", "draw the text format in blocks, each cell `WxH` characters")
+	textWall := flag.Int("text-wall", 1, "with --text-cell, how many characters thick walls are")
+	preview := flag.Int("preview", 0, "with the text or png format, draw a shaded character or pixel for each `N`xN block of cells")
+	goPackage := flag.String("go-package", "main", "with --format go, the package of the Go file")
+	chat := flag.String("chat", "discord", "with --format emoji, the chat app to fit the maze to a message of: "+strings.Join(maze.ChatNames(), ", "))
+	goName := flag.String("go-name", "Maze", "with --format go, the name of the constant holding the maze")
+	output := flag.String("o", "", "write the maze to `file` instead of stdout, e.g. for go:generate")
+	viewport := flag.String("viewport", "", "draw only `r0,c0,r1,c1`: rows r0 to r1 and columns c0 to c1, not including r1 and c1")
+	seed := flag.Int64("seed", 0, "random seed, to repeat a maze (0 picks one from the clock)")
+	generatorVersion := flag.Int("generator-version", maze.LatestGeneratorVersion, generatorVersionUsage)
+	daily := flag.Bool("daily", false, "make today's maze: the seed comes from the date (UTC) and --namespace")
+	namespace := flag.String("namespace", "", "with --daily, gives a different maze of the day for each name")
+	crypto := flag.Bool("crypto", false, "draw randomness from crypto/rand so the maze can't be predicted (or repeated)")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile := flag.String("memprofile", "", "write a heap profile to this file when done")
+	logLevel := flag.String("log-level", "warn", "log what's going on to stderr at this level and above: debug, info, warn or error")
+	debug := flag.Bool("debug", false, "log at debug level, with each phase of generation and its timings and the algorithm's internals, e.g. each edge Kruskal's considers")
+	config := flag.String("config", "", "read settings from a YAML or TOML `file`, each key a flag, plus rows, cols and outputs, the files to write; flags given win")
+	flag.Parse()
+	args := flag.Args()
+	var outputs []string
+	if *config != "" {
+		sized, files, err := applyConfig(flag.CommandLine, *config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(args) == 0 {
+			args = sized
+		}
+		outputs = files
+	}
+	if *debug {
+		*logLevel = "debug"
+	}
+	logger, err := newLogger(os.Stderr, *logLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		if err := stopProfiling(); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	var rows int = 10
+	var cols int = 10
+	if len(args) > 0 {
+		rows, err = strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(args) > 1 {
+		cols, err = strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	var ragged *maze.RaggedGrid
+	if *rowWidths != "" {
+		if len(args) > 0 {
+			log.Fatal("--row-widths gives the maze's size, so rows and cols can't be given too")
+		}
+		var widths []int
+		for _, s := range strings.Split(*rowWidths, ",") {
+			w, err := strconv.Atoi(s)
+			if err != nil {
+				log.Fatalf("bad --row-widths %q: %v", *rowWidths, err)
+			}
+			widths = append(widths, w)
+		}
+		if ragged, err = maze.NewRaggedGrid(widths, *align); err != nil {
+			log.Fatal(err)
+		}
+		rows, cols = len(widths), ragged.Cols()
+	}
+	var surf *maze.Surface
+	if *topology != "" {
+		// The grid is the surface's net, which for a cube is bigger.
+		if surf, err = maze.NewSurface(*topology, rows, cols); err != nil {
+			log.Fatal(err)
+		}
+		rows, cols = surf.Rows, surf.Cols
+	}
+	// A streamed maze is never held in memory, so it can be any size.
+	if *stream && (rows < 1 || cols < 1) {
+		log.Fatalf("bad grid size %dx%d: need at least one row and column", rows, cols)
+	} else if err := maze.CheckGridSize(rows, cols); err != nil && !*stream {
+		log.Fatal(err)
+	}
+
+	if *ice > 0 {
+		// Recursive backtracking's long twisty corridors stop a slide at
+		// almost every turn; in the other algorithms' mazes past about 10x10
+		// there's nearly always a junction that can't be turned at.
+		algorithmSet := false
+		flag.Visit(func(f *flag.Flag) { algorithmSet = algorithmSet || f.Name == "algorithm" })
+		if !algorithmSet {
+			*algorithm = "rec"
+		}
+	}
+	gen, ok := maze.LookupGenerator(*algorithm)
+	if !ok {
+		log.Fatalf("unknown algorithm %q", *algorithm)
+	}
+	if *rooms > 0 {
+		switch *algorithm {
+		case "division":
+			gen = maze.DivisionGenerator(*rooms)
+		case "blobby":
+			gen = maze.BlobbyGenerator(*rooms)
+		default:
+			log.Fatal("--rooms only works with --algorithm division or blobby")
+		}
+	}
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["pitch"] {
+		switch {
+		case *algorithm != "spiral":
+			log.Fatal("--pitch only works with --algorithm spiral")
+		case !(*pitch >= 0 && *pitch <= 90):
+			log.Fatalf("bad --pitch %g, want 0 to 90", *pitch)
+		}
+		gen = maze.SpiralGenerator(*pitch)
+	}
+	if set["texture"] {
+		switch {
+		case *algorithm != "growingtree":
+			log.Fatal("--texture only works with --algorithm growingtree")
+		case !(*texture >= 0 && *texture <= 1):
+			log.Fatalf("bad --texture %g, want 0 to 1", *texture)
+		}
+		gen = maze.GrowingTreeGenerator(*texture)
+	}
+	if set["fill"] {
+		switch {
+		case *algorithm != "caves":
+			log.Fatal("--fill only works with --algorithm caves")
+		case !(*fill >= 0 && *fill <= 1):
+			log.Fatalf("bad --fill %g, want 0 to 1", *fill)
+		}
+		gen = maze.CavesGenerator(*fill)
+	}
+	renderer, ok := maze.LookupRenderer(*format)
+	if !ok {
+		log.Fatalf("unknown format %q", *format)
+	}
+	style, ok := maze.LookupTheme(*theme)
+	if !ok {
+		log.Fatalf("unknown theme %q", *theme)
+	}
+	pal, ok := maze.LookupPalette(*palette)
+	if !ok {
+		log.Fatalf("unknown palette %q", *palette)
+	}
+	glyphs, err := maze.LookupGlyphs(*glyphNamed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *cellSize > 0 {
+		style.CellSize = *cellSize
+	}
+	if *corridor < 1 {
+		log.Fatalf("bad --corridor %d", *corridor)
+	}
+	if *textWall < 1 {
+		log.Fatalf("bad --text-wall %d", *textWall)
+	}
+	if *corridor > 1 {
+		// Walls a tile thick and passages corridor tiles wide.
+		style.WallWidth = max(style.CellSize/(*corridor+1), 1)
+	}
+	if *wallWidth > 0 {
+		style.WallWidth = *wallWidth
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	if *daily {
+		if *seed != 0 {
+			log.Fatal("--daily and --seed can't both be used")
+		}
+		*seed = dailySeed(time.Now(), *namespace)
+	}
+	if *crypto && *seed != 0 {
+		log.Fatal("--crypto can't be used with --seed or --daily")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+	if *crypto {
+		rng = maze.CryptoRand
+	}
+	if *search > 0 {
+		if *symmetry != "" || len(waypoints) > 0 || *minRatio > 0 || *count > 1 || *stream {
+			log.Fatal("--search can't be used with --symmetry, --waypoint, --min-solution-ratio, --count or --stream")
+		}
+		score, ok := maze.LookupObjective(*objective)
+		if !ok {
+			log.Fatalf("unknown objective %q", *objective)
+		}
+		searchCtx, cancel := context.WithTimeout(ctx, *search)
+		g, err := maze.NewGrid(rows, cols)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.GeneratorVersion = *generatorVersion
+		found, best, tried, err := maze.SearchSeeds(searchCtx, &g, rng, *bias, gen, score)
+		cancel()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(os.Stderr, "best %s %d out of %d mazes, seed %d\n", *objective, best, tried, found)
+		// Carry on as if the winning seed had been given with --seed, so
+		// the maze can be made again from its info.
+		*seed, *crypto = found, false
+		rng = rand.New(rand.NewSource(found))
+	}
+	if *shape != "" && (*symmetry != "" || len(waypoints) > 0 || *text != "" || *minRatio > 0 || *stream || *count > 1 || *showSpine) {
+		log.Fatal("--shape can't be used with --symmetry, --waypoint, --text, --min-solution-ratio, --stream, --count or --longest-path")
+	}
+	if *rowWidths != "" && (*shape != "" || *symmetry != "" || len(waypoints) > 0 || *text != "" || *minRatio > 0 || *stream || *count > 1 || *showSpine || *animate) {
+		log.Fatal("--row-widths can't be used with --shape, --symmetry, --waypoint, --text, --min-solution-ratio, --stream, --count, --longest-path or --animate")
+	}
+	if *topology != "" && (*shape != "" || *rowWidths != "" || *symmetry != "" || len(waypoints) > 0 || *text != "" || *minRatio > 0 || *ice > 0 || *oneWay > 0 || *stream || *count > 1 || *showSolution || *showSpine || *trace != "") {
+		// The seams aren't passages in the grid, so solving it or tracing
+		// its carving would miss them.
+		log.Fatal("--topology can't be used with --shape, --row-widths, --symmetry, --waypoint, --text, --min-solution-ratio, --ice, --one-way, --stream, --count, --solution, --longest-path or --trace")
+	}
+	if *hybrid != "" && (*shape != "" || *rowWidths != "" || *topology != "" || *symmetry != "" || len(waypoints) > 0 || *text != "" || *minRatio > 0 || *ice > 0 || *stream || *count > 1) {
+		log.Fatal("--hybrid can't be used with --shape, --row-widths, --topology, --symmetry, --waypoint, --text, --min-solution-ratio, --ice, --stream or --count")
+	}
+	if *ice > 0 && (*shape != "" || *symmetry != "" || len(waypoints) > 0 || *text != "" || *minRatio > 0 || *stream || *count > 1) {
+		log.Fatal("--ice can't be used with --shape, --symmetry, --waypoint, --text, --min-solution-ratio, --stream or --count")
+	}
+	if err := maze.CheckBias(*algorithm, gen, *bias); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkGeneratorVersion(*generatorVersion); err != nil {
+		log.Fatal(err)
+	}
+	if *routes > 1 && (*shape != "" || *rowWidths != "" || *topology != "" || *ice > 0 || *oneWay > 0 || *stream || *count > 1) {
+		// New routes could go through the cells outside a shape, or the
+		// seams of a topology.
+		log.Fatal("--routes can't be used with --shape, --row-widths, --topology, --ice, --one-way, --stream or --count")
+	}
+	if *loops > 0 && (*shape != "" || *rowWidths != "" || *topology != "" || *ice > 0 || *routes > 1 || *stream || *count > 1) {
+		// Loops could open into the cells outside a shape, or make
+		// shortcuts round ice slides and between the routes.
+		log.Fatal("--loops can't be used with --shape, --row-widths, --topology, --ice, --routes, --stream or --count")
+	}
+	if *loops < 0 || *loops > 1 {
+		log.Fatalf("bad --loops %g, want 0 to 1", *loops)
+	}
+	if *braid > 0 && (*shape != "" || *rowWidths != "" || *topology != "" || *ice > 0 || *routes > 1 || *stream || *count > 1) {
+		// As with --loops.
+		log.Fatal("--braid can't be used with --shape, --row-widths, --topology, --ice, --routes, --stream or --count")
+	}
+	if *braid < 0 || *braid > 1 {
+		log.Fatalf("bad --braid %g, want 0 to 1", *braid)
+	}
+	if *straighten > 0 && (*shape != "" || *rowWidths != "" || *topology != "" || *ice > 0 || *routes > 1 || *stream || *count > 1) {
+		// As with --loops.
+		log.Fatal("--straighten can't be used with --shape, --row-widths, --topology, --ice, --routes, --stream or --count")
+	}
+	if *straighten < 0 || *straighten > 1 {
+		log.Fatalf("bad --straighten %g, want 0 to 1", *straighten)
+	}
+	if *straightenSlack < 0 {
+		log.Fatalf("bad --straighten-slack %g, want 0 or more", *straightenSlack)
+	}
+	if *place && (*shape != "" || *rowWidths != "" || *topology != "" || *stream || *count > 1) {
+		// The cells outside a shape, or across a topology's seams, would
+		// throw out the distances.
+		log.Fatal("--place can't be used with --shape, --row-widths, --topology, --stream or --count")
+	}
+	if *treasure < 0 || *enemies < 0 {
+		log.Fatal("--treasure and --enemies can't be negative")
+	}
+	if len(waypoints) > 0 && (*algorithm != "kruskal" || *bias != maze.NoBias) {
+		// The route through the waypoints is carved by Kruskal's.
+		log.Fatal("--waypoint can't be used with --algorithm or --bias")
+	}
+	if *symmetry != "" && (*algorithm != "kruskal" || *bias != maze.NoBias) {
+		// MazifySymmetric carves by Kruskal's.
+		log.Fatal("--symmetry can't be used with --algorithm or --bias")
+	}
+	if *text != "" && (*algorithm != "kruskal" || *bias != maze.NoBias) {
+		// The letters are walled off by weighting Kruskal's.
+		log.Fatal("--text can't be used with --algorithm or --bias")
+	}
+	if len(outputs) > 0 && (*stream || *count > 1 || *animate) {
+		log.Fatal("a config file's outputs can't be used with --stream, --count or --animate")
+	}
+	if *count > 1 && (*format != "text" || *showSolution) {
+		// The mazes are written as text, with their solutions in the --zip.
+		log.Fatal("--format and --solution can't be used with --count")
+	}
+	if *stream && (*algorithm != "kruskal" || *bias != maze.NoBias || *format != "text" || *showSolution) {
+		// Streamed rows are carved by Eller's and printed as text as they go.
+		log.Fatal("--algorithm, --bias, --format and --solution can't be used with --stream")
+	}
+	if *oneWay > 0 && (*stream || *count > 1) {
+		log.Fatal("--one-way can't be used with --stream or --count")
+	}
+	if *trace != "" && (*stream || *count > 1) {
+		log.Fatal("--trace can't be used with --stream or --count")
+	}
+	if *animate && (*stream || *count > 1 || *algorithm == "parallel") {
+		// Parallel carves tiles at the same time as they'd be drawn.
+		log.Fatal("--animate can't be used with --stream, --count or --algorithm parallel")
+	}
+	if *stream {
+		if *checkpoint != "" {
+			if *crypto {
+				log.Fatal("--checkpoint can't be used with --crypto")
+			}
+			err = streamCheckpointed(ctx, *checkpoint, *seed, rows, cols)
+		} else {
+			err = maze.StreamEllerContext(ctx, os.Stdout, rng, rows, cols)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	} else if *checkpoint != "" {
+		log.Fatal("--checkpoint only works with --stream")
+	}
+	if *count > 1 {
+		if err := maze.WriteBatch(rng, maze.BatchOptions{
+			Rows: rows, Cols: cols, Count: *count, Workers: *workers,
+			Prefix: *out, Archive: *archive, Dedupe: *dedupe, Unique: *unique,
+			Generator: gen, Algorithm: *algorithm, Bias: *bias, GeneratorVersion: *generatorVersion,
+		}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	grid, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		log.Fatal(err)
+	}
+	grid.GeneratorVersion = *generatorVersion
+	if *debug {
+		logPhases(&grid, logger)
+	}
+	if *progress {
+		grid.Observer = &maze.Observer{Progress: func(percent int) {
+			fmt.Fprintf(os.Stderr, "\r%3d%%", percent)
+			if percent == 100 {
+				fmt.Fprintln(os.Stderr)
+			}
+		}}
+	}
+	if *animate {
+		if !isTerminal(os.Stdout) {
+			log.Fatal("--animate needs stdout to be a terminal")
+		}
+		if termRows, termCols, err := terminalSize(); err == nil && termRows > 0 && (rows+2 > termRows || 2*cols+1 > termCols) {
+			log.Fatalf("a %dx%d maze is too big to animate in a %dx%d terminal", rows, cols, termRows, termCols)
+		}
+		if grid.Observer == nil {
+			grid.Observer = &maze.Observer{}
+		}
+		grid.Observer.Carve = animateCarving(os.Stdout, &grid, *frameDelay)
+	}
+	var carveOrder []int
+	if *format == "order" {
+		carveOrder = trackCarveOrder(&grid)
+	}
+	var finishTrace func() error
+	if *trace != "" {
+		traceFile, err := os.Create(*trace)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer traceFile.Close()
+		finishTrace = traceCarves(&grid, traceFile)
+	}
+	logger.Info("generating", "algorithm", *algorithm, "rows", rows, "cols", cols, "seed", *seed)
+	started := time.Now()
+	// mask is the cells of a maze carved in a shape or with ragged rows.
+	var textMask, mask []bool
+	if *shape != "" {
+		if mask, err = maze.ShapeMask(*shape, rows, cols); err != nil {
+			log.Fatal(err)
+		}
+		err = grid.MazifyMask(ctx, rng, mask, *algorithm)
+	} else if surf != nil {
+		err = grid.MazifySurface(ctx, rng, surf, *algorithm)
+		mask = surf.Mask()
+	} else if ragged != nil {
+		// The ragged grid tells grid's Observer what it carves, as grid
+		// would have.
+		ragged.Observer = grid.Observer
+		err = ragged.Mazify(ctx, rng, *algorithm)
+		grid, mask = ragged.Grid()
+		grid.Observer = ragged.Observer
+	} else if *hybrid != "" {
+		names := strings.Split(*hybrid, ",")
+		var region []int
+		if region, err = maze.HybridRegions(*hybridSplit, rng, rows, cols, len(names)); err != nil {
+			log.Fatal(err)
+		}
+		err = grid.MazifyHybrid(ctx, rng, region, names)
+	} else if *symmetry != "" {
+		sym, ok := maze.LookupSymmetry(*symmetry)
+		if !ok {
+			log.Fatalf("unknown symmetry %q", *symmetry)
+		}
+		err = grid.MazifySymmetricContext(ctx, rng, sym)
+	} else if len(waypoints) > 0 {
+		stops := []maze.Cell{{Row: 0, Col: 0}}
+		for _, waypoint := range waypoints {
+			c, err := parseCell(waypoint)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if !grid.Contains(c) {
+				log.Fatalf("waypoint %s is outside the grid", waypoint)
+			}
+			stops = append(stops, c)
+		}
+		stops = append(stops, maze.Cell{Row: rows - 1, Col: cols - 1})
+		err = grid.MazifyThroughContext(ctx, rng, stops)
+	} else if *text != "" {
+		if textMask, err = maze.TextMask(*text, rows, cols); err != nil {
+			log.Fatal(err)
+		}
+		err = grid.MazifyWeightedKruskalContext(ctx, rng, maze.TextWeight(textMask, cols))
+	} else if *ice > 0 {
+		err = maze.MazifySliding(ctx, &grid, rng, *bias, gen, *ice)
+	} else if *minRatio > 0 {
+		minLength := int(math.Ceil(*minRatio * float64(2*(rows+cols))))
+		err = maze.MazifyMinSolution(ctx, &grid, rng, *bias, gen, minLength)
+	} else {
+		err = maze.Generate(ctx, gen, &grid, rng, *bias)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.Info("generated", "took", time.Since(started))
+	if finishTrace != nil {
+		if err := finishTrace(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *straighten > 0 {
+		grid.Straighten(rng, *straighten, *straightenSlack)
+	}
+	if *braid > 0 {
+		grid.Braid(rng, *braid)
+	}
+	if *loops > 0 {
+		grid.AddLoops(rng, *loops)
+	}
+	var routePaths []maze.LabeledPath
+	if *routes > 1 {
+		found, err := grid.AddRoutes(rng, *routes, *routeSlack)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i, route := range found {
+			routePaths = append(routePaths, maze.LabeledPath{Label: fmt.Sprintf("route %d (%d)", i+1, len(route)-1), Cells: route})
+		}
+	}
+	if *oneWay > 0 {
+		grid.AddOneWays(rng, *oneWay)
+		if grid.WayBack(grid.Endpoints()) == nil {
+			fmt.Fprintln(os.Stderr, "no way back from the finish: the maze is one-way solvable only")
+		}
+	}
+	if *place {
+		if _, err := grid.PlaceLevel(rng, *treasure, *enemies); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *animate {
+		// The maze is drawn again, as asked for, over the animation.
+		os.Stdout.WriteString(ansiClear)
+	}
+	opts := maze.RenderOptions{Style: &style, Palette: &pal, Glyphs: &glyphs, Corridor: *corridor, Markers: *markers, Openings: *openings, Preview: *preview, GoPackage: *goPackage, GoName: *goName, Chat: *chat, Info: map[string]string{
+		"seed":              strconv.FormatInt(*seed, 10),
+		"rows":              strconv.Itoa(rows),
+		"cols":              strconv.Itoa(cols),
+		"algorithm":         *algorithm,
+		"bias":              strconv.FormatFloat(*bias, 'g', -1, 64),
+		"generator-version": strconv.Itoa(*generatorVersion),
+	}}
+	if *crypto {
+		delete(opts.Info, "seed")
+		opts.Info["crypto"] = "true"
+	}
+	if *symmetry != "" {
+		opts.Info["symmetry"] = *symmetry
+	}
+	if *shape != "" {
+		opts.Info["shape"] = *shape
+	}
+	if *topology != "" {
+		opts.Info["topology"] = *topology
+	}
+	if *hybrid != "" {
+		opts.Info["hybrid"] = *hybrid
+		opts.Info["hybrid-split"] = *hybridSplit
+	}
+	if *rowWidths != "" {
+		opts.Info["row-widths"] = *rowWidths
+		opts.Info["align"] = *align
+	}
+	opts.Mask = mask
+	opts.Order = carveOrder
+	if *text != "" {
+		opts.Info["text"] = *text
+		opts.Regions = maze.TextRegions(textMask)
+	}
+	if len(waypoints) > 0 {
+		opts.Info["waypoints"] = strings.Join(waypoints, " ")
+	}
+	if *rooms > 0 {
+		opts.Info["rooms"] = strconv.FormatFloat(*rooms, 'g', -1, 64)
+	}
+	if set["pitch"] {
+		opts.Info["pitch"] = strconv.FormatFloat(*pitch, 'g', -1, 64)
+	}
+	if set["texture"] {
+		opts.Info["texture"] = strconv.FormatFloat(*texture, 'g', -1, 64)
+	}
+	if set["fill"] {
+		opts.Info["fill"] = strconv.FormatFloat(*fill, 'g', -1, 64)
+	}
+	if *ice > 0 {
+		opts.Info["ice"] = strconv.Itoa(*ice)
+	}
+	if *oneWay > 0 {
+		opts.Info["one-way"] = strconv.FormatFloat(*oneWay, 'g', -1, 64)
+	}
+	if *straighten > 0 {
+		opts.Info["straighten"] = strconv.FormatFloat(*straighten, 'g', -1, 64)
+		opts.Info["straighten-slack"] = strconv.FormatFloat(*straightenSlack, 'g', -1, 64)
+	}
+	if *place {
+		opts.Info["place"] = "true"
+		opts.Info["treasure"] = strconv.Itoa(*treasure)
+		opts.Info["enemies"] = strconv.Itoa(*enemies)
+	}
+	if *braid > 0 {
+		opts.Info["braid"] = strconv.FormatFloat(*braid, 'g', -1, 64)
+	}
+	if *loops > 0 {
+		opts.Info["loops"] = strconv.FormatFloat(*loops, 'g', -1, 64)
+	}
+	if *corridor > 1 {
+		opts.Info["corridor"] = strconv.Itoa(*corridor)
+	}
+	if *material != "" || *kerf > 0 {
+		cut := maze.Cut{Width: 297, Height: 210, Kerf: *kerf}
+		if *material != "" {
+			if _, err := fmt.Sscanf(*material, "%gx%gx%g", &cut.Width, &cut.Height, &cut.Thickness); err != nil || cut.Width <= 0 || cut.Height <= 0 || cut.Thickness < 0 {
+				log.Fatalf("bad --material %q, want WxHxT in millimetres", *material)
+			}
+		}
+		if *kerf < 0 {
+			log.Fatalf("bad --kerf %g", *kerf)
+		}
+		opts.Cut = &cut
+		opts.Info["material"] = fmt.Sprintf("%gx%gx%g", cut.Width, cut.Height, cut.Thickness)
+		opts.Info["kerf"] = strconv.FormatFloat(*kerf, 'g', -1, 64)
+	}
+	if *routes > 1 {
+		opts.Info["routes"] = strconv.Itoa(*routes)
+		opts.Info["route-slack"] = strconv.FormatFloat(*routeSlack, 'g', -1, 64)
+		opts.Paths = routePaths
+	}
+	if *minRatio > 0 {
+		opts.Info["min-solution-ratio"] = strconv.FormatFloat(*minRatio, 'g', -1, 64)
+	}
+	if *background != "" {
+		if opts.Background, err = loadImage(*background); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *textCell != "" {
+		var width, height int
+		if _, err := fmt.Sscanf(*textCell, "%dx%d", &width, &height); err != nil || width < 1 || height < 1 {
+			log.Fatalf("bad --text-cell %q, want WxH", *textCell)
+		}
+		// Characters are about twice as tall as they are wide.
+		opts.TextSize = &maze.TextSize{CellWidth: width, CellHeight: height, WallWidth: *textWall, WallHeight: (*textWall + 1) / 2}
+	}
+	if *showSolution {
+		opts.Path = grid.Solve(grid.Endpoints())
+		if *ice > 0 {
+			opts.Path = grid.SolveSliding(grid.Endpoints())
+		}
+		opts.Arrows = *arrows
+	}
+	if *showSpine {
+		opts.Spine = grid.LongestPath()
+	}
+	if *regions > 0 {
+		opts.Regions = grid.RandomRegions(rng, *regions)
+		opts.Info["regions"] = strconv.Itoa(*regions)
+	}
+	if *viewport != "" {
+		v, err := parseViewport(*viewport, rows, cols)
+		if err != nil {
+			log.Fatal(err)
+		}
+		grid, opts, err = grid.Viewport(opts, maze.Cell{Row: v[0], Col: v[1]}, maze.Cell{Row: v[2], Col: v[3]})
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Info["viewport"] = *viewport
+	}
+	// Unless asked for a particular format, fit the maze to the terminal.
+	formatSet := false
+	flag.Visit(func(f *flag.Flag) { formatSet = formatSet || f.Name == "format" })
+	if !formatSet && opts.TextSize == nil && opts.Preview == 0 && *output == "" && isTerminal(os.Stdout) {
+		if termRows, termCols, err := terminalSize(); err == nil {
+			fit, err := fitFormat(grid.RowCount, grid.ColCount, termRows, termCols)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "warning:", err)
+			}
+			renderer, _ = maze.LookupRenderer(fit)
+		}
+	}
+	for _, path := range outputs {
+		if err := saveAs(path, &grid, opts); err != nil {
+			log.Fatal(err)
+		}
+		logger.Info("wrote", "file", path)
+	}
+	if len(outputs) > 0 && *output == "" {
+		// Written to the outputs instead.
+		return
+	}
+	dest := os.Stdout
+	if *output != "" {
+		if dest, err = os.Create(*output); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := renderer.Render(&grid, dest, opts); err != nil {
+		log.Fatal(err)
+	}
+	if err := dest.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The metrics the servers export at /metrics, in the Prometheus text format.
+var (
+	mazesGenerated = &counterVec{
+		name:  "maze_generated_total",
+		help:  "Mazes generated, by algorithm.",
+		label: "algorithm",
+	}
+	generationSeconds = &histogramVec{
+		name:    "maze_generation_seconds",
+		help:    "Time taken to generate a maze, by size: small up to 100 cells, medium up to 10000, large up to a million, then huge.",
+		label:   "size",
+		buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 60},
+	}
+	rendersTotal = &counterVec{
+		name:  "maze_renders_total",
+		help:  "Mazes rendered, by format.",
+		label: "format",
+	}
+	cacheLookups = &counterVec{
+		name:  "maze_cache_lookups_total",
+		help:  "Lookups in maze serve's cache of rendered mazes, by result: hit or miss.",
+		label: "result",
+	}
+	metrics = []interface{ write(w io.Writer) }{mazesGenerated, generationSeconds, rendersTotal, cacheLookups}
+)
+
+// sizeClass is the size label of generationSeconds for a maze with this
+// many cells.  Sizes are grouped to keep the number of series down.
+func sizeClass(cells int) string {
+	switch {
+	case cells <= 100:
+		return "small"
+	case cells <= 10000:
+		return "medium"
+	case cells <= 1000000:
+		return "large"
+	}
+	return "huge"
+}
+
+// observeGeneration records a maze generated with algorithm taking d.
+func observeGeneration(algorithm string, cells int, d time.Duration) {
+	mazesGenerated.inc(algorithm)
+	generationSeconds.observe(sizeClass(cells), d.Seconds())
+}
+
+// serveMetrics writes every metric in the Prometheus text format.
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// counterVec is a counter for each value of a label.
+type counterVec struct {
+	name, help, label string
+	mu                sync.Mutex
+	values            map[string]uint64
+}
+
+func (c *counterVec) inc(value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = map[string]uint64{}
+	}
+	c.values[value]++
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	var values []string
+	for value := range c.values {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", c.name, c.label, value, c.values[value])
+	}
+}
+
+// histogramVec is a histogram for each value of a label.
+type histogramVec struct {
+	name, help, label string
+	buckets           []float64
+	mu                sync.Mutex
+	series            map[string]*histogram
+}
+
+// histogram is the counts of observations in each bucket, not cumulative
+// as they're written, with the last past the highest bucket.
+type histogram struct {
+	counts []uint64
+	sum    float64
+}
+
+func (h *histogramVec) observe(value string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.series == nil {
+		h.series = map[string]*histogram{}
+	}
+	s := h.series[value]
+	if s == nil {
+		s = &histogram{counts: make([]uint64, len(h.buckets)+1)}
+		h.series[value] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	var values []string
+	for value := range h.series {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		s := h.series[value]
+		var total uint64
+		for i, count := range s.counts {
+			total += count
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			fmt.Fprintf(w, "%s_bucket{%s=%q,le=%q} %d\n", h.name, h.label, value, formatLe(le), total)
+		}
+		fmt.Fprintf(w, "%s_sum{%s=%q} %g\n", h.name, h.label, value, s.sum)
+		fmt.Fprintf(w, "%s_count{%s=%q} %d\n", h.name, h.label, value, total)
+	}
+}
+
+// formatLe formats a bucket's upper bound the way Prometheus does.
+func formatLe(le float64) string {
+	if math.IsInf(le, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+const playHelp = "arrows/hjkl move  q quit"
+
+// game is the state of the play command.
+type game struct {
+	grid     *maze.Grid
+	row, col int
+	finish   int
+	moves    int
+	// enemies are the CellIds of the cells the enemies are in.
+	enemies []int
+	// fp, if not nil, is the first person view of --3d.
+	fp *firstPerson
+	// ice makes each move slide on until a wall, for --ice.
+	ice bool
+	// morph, if not nil, moves walls of the maze after each move, morphs
+	// times, for --morph.
+	morph  *maze.OriginShift
+	morphs int
+	// coins are the CellIds of the coins still to collect for --coins, and
+	// totalCoins how many there were; the finish only opens once they're
+	// all collected.
+	coins      []int
+	totalCoins int
+}
+
+// playSettings are the settings for making a game, from the flags play and
+// ssh-server share.
+type playSettings struct {
+	algorithm   string
+	version     int
+	enemies     int
+	speed       float64
+	firstPerson bool
+	ice         bool
+	morph       int
+	coins       int
+}
+
+// addFlags defines the flags for s on fs.
+func (s *playSettings) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&s.algorithm, "algorithm", "kruskal", "generation algorithm: "+strings.Join(maze.GeneratorNames(), ", "))
+	fs.IntVar(&s.version, "generator-version", maze.LatestGeneratorVersion, generatorVersionUsage)
+	fs.IntVar(&s.enemies, "enemies", 0, "number of enemies chasing the player")
+	fs.Float64Var(&s.speed, "speed", 2, "moves a second each enemy makes")
+	fs.BoolVar(&s.firstPerson, "3d", false, "explore the maze in first person, with a map you can toggle")
+	fs.BoolVar(&s.ice, "ice", false, "play on ice: each move slides on until a wall stops you")
+	fs.IntVar(&s.morph, "morph", 0, "walls of the maze that move after each move")
+	fs.IntVar(&s.coins, "coins", 0, fmt.Sprintf("coins to collect before the finish opens, up to %d", maze.MaxCoins))
+}
+
+// check returns an error if s can't make a rows x cols game, once fs, which
+// s's flags are on, is parsed.
+func (s *playSettings) check(fs *flag.FlagSet, rows, cols int) error {
+	if s.enemies < 0 || s.enemies > rows*cols-2 || s.speed <= 0 {
+		return fmt.Errorf("can't have %d enemies at %g moves a second in a %dx%d maze", s.enemies, s.speed, rows, cols)
+	}
+	if err := checkGeneratorVersion(s.version); err != nil {
+		return err
+	}
+	if s.morph < 0 {
+		return fmt.Errorf("bad --morph %d", s.morph)
+	}
+	if s.morph > 0 && s.ice {
+		// Moving walls would soon leave a maze that can't be solved on ice.
+		return errors.New("--morph can't be used with --ice")
+	}
+	if s.coins < 0 {
+		return fmt.Errorf("bad --coins %d", s.coins)
+	}
+	if s.coins > 0 && s.ice {
+		// Slides could go over coins without stopping on them.
+		return errors.New("--coins can't be used with --ice")
+	}
+	if s.ice && s.firstPerson {
+		return errors.New("--ice can't be used with --3d")
+	}
+	if s.ice {
+		// As with maze --ice, rec unless asked for another.
+		algorithmSet := false
+		fs.Visit(func(f *flag.Flag) { algorithmSet = algorithmSet || f.Name == "algorithm" })
+		if !algorithmSet {
+			s.algorithm = "rec"
+		}
+	}
+	if _, ok := maze.LookupGenerator(s.algorithm); !ok {
+		return fmt.Errorf("unknown algorithm %q", s.algorithm)
+	}
+	return nil
+}
+
+// newGame generates a rows x cols maze from seed and sets a game up in it
+// as s says, with a first person view, for --3d, sized to a termRows x
+// termCols terminal.  It returns the game and the fewest moves it can be
+// finished in, to score the player's efficiency against.
+func (s *playSettings) newGame(seed int64, rows, cols, termRows, termCols int) (*game, int, error) {
+	grid, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		return nil, 0, err
+	}
+	grid.GeneratorVersion = s.version
+	rng := rand.New(rand.NewSource(seed))
+	gen, _ := maze.LookupGenerator(s.algorithm)
+	if s.ice {
+		// Solvable on ice, and in more slides than it's wide or tall.
+		err = maze.MazifySliding(context.Background(), &grid, rng, maze.NoBias, gen, max(rows, cols))
+	} else {
+		err = maze.Generate(context.Background(), gen, &grid, rng, maze.NoBias)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	g := &game{grid: &grid, finish: grid.RowCount*grid.ColCount - 1, ice: s.ice}
+	if s.coins > 0 {
+		coins, err := grid.PlaceCoins(rng, s.coins)
+		if err != nil {
+			return nil, 0, err
+		}
+		g.coins = grid.CellIds(coins)
+		g.totalCoins = len(g.coins)
+	}
+	// Efficiency is against the maze as it was at the start, however it
+	// morphs, and collecting the coins the best way round.
+	fewest := len(grid.Solve(maze.Cell{Row: 0, Col: 0}, maze.Cell{Row: rows - 1, Col: cols - 1})) - 1
+	if s.ice {
+		fewest = len(grid.SlideMoves(maze.Cell{Row: 0, Col: 0}, maze.Cell{Row: rows - 1, Col: cols - 1})) - 1
+	}
+	if s.coins > 0 {
+		route, err := grid.CollectRoute(maze.Cell{Row: 0, Col: 0}, grid.CellOf(g.finish), grid.PathCells(g.coins))
+		if err != nil {
+			return nil, 0, err
+		}
+		fewest = len(route) - 1
+	}
+	if s.morph > 0 {
+		// Rooted at the finish, so morphing never cuts a cell off from it.
+		g.morph, g.morphs = maze.NewOriginShift(&grid, rng, grid.CellOf(g.finish)), s.morph
+	}
+	g.placeEnemies(s.enemies)
+	if s.firstPerson {
+		g.fp = newFirstPerson(&grid, termRows, termCols)
+	}
+	return g, fewest, nil
+}
+
+// runPlay is the play command: walk a generated maze from the top left to the
+// bottom right in the terminal, optionally chased by enemies.  Finishing
+// records the time and moves taken in the scores file and shows the best
+// times for that maze.
+func runPlay(args []string) error {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	var settings playSettings
+	settings.addFlags(fs)
+	seed := fs.Int64("seed", 0, "random seed, to play a maze again (0 picks one from the clock)")
+	scoresPath := fs.String("scores", defaultScoresPath(), "file the scores are kept in")
+	best := fs.Bool("best", false, "print the best time for every maze played and exit")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze play [flags] [rows] [cols]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *best {
+		scores, err := loadScores(*scoresPath)
+		if err != nil {
+			return err
+		}
+		printScores(os.Stdout, bestScores(scores))
+		return nil
+	}
+	rows, cols, err := parseSize(fs.Args())
+	if err != nil {
+		return err
+	}
+	if err := settings.check(fs, rows, cols); err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	termRows, termCols := 24, 80
+	if settings.firstPerson {
+		if r, c, err := terminalSize(); err == nil {
+			termRows, termCols = r, c
+		}
+	}
+	g, fewest, err := settings.newGame(*seed, rows, cols, termRows, termCols)
+	if err != nil {
+		return err
+	}
+
+	restore, err := enterCbreak()
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	quit, err := g.run(os.Stdin, os.Stdout, settings.speed)
+	restore()
+	if quit || err != nil {
+		return err
+	}
+	if g.caught() {
+		fmt.Println("caught!")
+		return nil
+	}
+
+	score := Score{
+		Seed: *seed, Rows: rows, Cols: cols, Algorithm: settings.algorithm, GeneratorVersion: settings.version,
+		Enemies: settings.enemies, Ice: settings.ice, Morph: settings.morph, Coins: settings.coins, Seconds: time.Since(started).Seconds(), Moves: g.moves,
+		Efficiency: 1, When: started.UTC(),
+	}
+	if g.moves > 0 {
+		score.Efficiency = float64(fewest) / float64(g.moves)
+	}
+	fmt.Printf("finished in %.1fs with %d moves (%.0f%% efficient)\n\n", score.Seconds, score.Moves, 100*score.Efficiency)
+	scores, err := loadScores(*scoresPath)
+	if err != nil {
+		return err
+	}
+	scores = append(scores, score)
+	if err := saveScores(*scoresPath, scores); err != nil {
+		return err
+	}
+	printScores(os.Stdout, scoresFor(scores, score))
+	return nil
+}
+
+// run plays the game, reading keys from in and drawing to out, with the
+// enemies making speed moves a second, until the player finishes, is
+// caught or quits.  It returns whether they quit.
+func (g *game) run(in io.Reader, out io.Writer, speed float64) (quit bool, err error) {
+	// Keys are read in the background so the enemies can move on the tick
+	// while the player is thinking.
+	keys := make(chan int)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		r := bufio.NewReader(in)
+		for {
+			key, err := readKey(r)
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case keys <- key:
+			case <-done:
+				return
+			}
+		}
+	}()
+	var tick <-chan time.Time
+	if len(g.enemies) > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / speed))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for !g.finished() && !g.caught() {
+		g.draw(out)
+		select {
+		case key := <-keys:
+			if !g.handle(key) {
+				return true, nil
+			}
+			g.collect()
+		case err := <-errs:
+			return false, err
+		case <-tick:
+			g.chase()
+		}
+	}
+	g.draw(out)
+	return false, nil
+}
+
+// parseSize reads the optional rows and cols arguments, which default to 10.
+func parseSize(args []string) (rows, cols int, err error) {
+	rows, cols = 10, 10
+	if len(args) > 0 {
+		if _, err := fmt.Sscan(args[0], &rows); err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(args) > 1 {
+		if _, err := fmt.Sscan(args[1], &cols); err != nil {
+			return 0, 0, err
+		}
+	}
+	if rows < 1 || cols < 1 {
+		return 0, 0, fmt.Errorf("bad maze size %dx%d", rows, cols)
+	}
+	return rows, cols, nil
+}
+
+// handle acts on a keypress, returning false when it's time to quit.
+func (g *game) handle(key int) bool {
+	if g.fp != nil {
+		return g.fp.handle(g, key)
+	}
+	move := map[int]maze.Direction{keyUp: maze.N, 'k': maze.N, keyRight: maze.E, 'l': maze.E, keyDown: maze.S, 'j': maze.S, keyLeft: maze.W, 'h': maze.W}
+	if d, ok := move[key]; ok {
+		if g.ice {
+			if cells := g.grid.Slide(maze.Cell{Row: g.row, Col: g.col}, d, g.grid.CellOf(g.finish)); len(cells) > 0 {
+				stop := cells[len(cells)-1]
+				g.row, g.col = stop.Row, stop.Col
+				g.moves++
+			}
+		} else if !g.grid.HasWall(maze.Cell{Row: g.row, Col: g.col}, d) {
+			next := (maze.Cell{Row: g.row, Col: g.col}).Step(d)
+			g.row, g.col = next.Row, next.Col
+			g.moves++
+			g.shift()
+		}
+		return true
+	}
+	return key != 'q' && key != 3 // 3 is ctrl-c
+}
+
+// collect picks up the coin in the player's cell, if there is one.
+func (g *game) collect() {
+	player := g.grid.CellId(g.row, g.col)
+	for i, coin := range g.coins {
+		if coin == player {
+			g.coins = append(g.coins[:i], g.coins[i+1:]...)
+			return
+		}
+	}
+}
+
+// finished reports whether the player has collected all the coins and
+// reached the finish.
+func (g *game) finished() bool {
+	return len(g.coins) == 0 && g.grid.CellId(g.row, g.col) == g.finish
+}
+
+// shift moves the walls for --morph, if it's set.
+func (g *game) shift() {
+	for i := 0; g.morph != nil && i < g.morphs; i++ {
+		g.morph.Shift()
+	}
+}
+
+// placeEnemies puts n enemies in the cells furthest from the player's start,
+// leaving out the finish.
+func (g *game) placeEnemies(n int) {
+	dist := g.grid.Distances(maze.Cell{Row: 0, Col: 0})
+	var cells []int
+	for id := 1; id < len(dist); id++ {
+		if id != g.finish {
+			cells = append(cells, id)
+		}
+	}
+	sort.SliceStable(cells, func(i, j int) bool { return dist[cells[i]] > dist[cells[j]] })
+	g.enemies = cells[:n]
+}
+
+// chase moves each enemy a step along the shortest path to the player.
+func (g *game) chase() {
+	player := maze.Cell{Row: g.row, Col: g.col}
+	for i, enemy := range g.enemies {
+		if path := g.grid.Solve(g.grid.CellOf(enemy), player); len(path) > 1 {
+			g.enemies[i] = g.grid.CellIdOf(path[1])
+		}
+	}
+}
+
+// caught reports whether an enemy has reached the player.
+func (g *game) caught() bool {
+	player := g.grid.CellId(g.row, g.col)
+	for _, enemy := range g.enemies {
+		if enemy == player {
+			return true
+		}
+	}
+	return false
+}
+
+// draw redraws the whole screen, with the player as @, the finish as F,
+// enemies as X and coins as $.
+func (g *game) draw(w io.Writer) {
+	if g.fp != nil {
+		g.fp.draw(g, w)
+		return
+	}
+	grid := g.grid
+	buf := append([]byte(ansiClear), grid.AppendTextTop(nil)...)
+	labels := make([]rune, grid.ColCount)
+	for row := 0; row < grid.RowCount; row++ {
+		for col := range labels {
+			labels[col] = 0
+			if grid.CellId(row, col) == g.finish {
+				labels[col] = 'F'
+			}
+		}
+		for _, coin := range g.coins {
+			if coin/grid.ColCount == row {
+				labels[coin%grid.ColCount] = '$'
+			}
+		}
+		for _, enemy := range g.enemies {
+			if enemy/grid.ColCount == row {
+				labels[enemy%grid.ColCount] = 'X'
+			}
+		}
+		if row == g.row {
+			labels[g.col] = '@'
+		}
+		buf = grid.AppendTextRow(buf, row, labels, nil)
+	}
+	buf = append(buf, fmt.Sprintf("moves %d%s  %s\n", g.moves, g.coinStatus(), playHelp)...)
+	w.Write(buf)
+}
+
+// coinStatus returns how many coins have been collected, for the status
+// line, or "" if there are none to collect.
+func (g *game) coinStatus() string {
+	if g.totalCoins == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  coins %d/%d", g.totalCoins-len(g.coins), g.totalCoins)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// posterMaze is one of the mazes on a poster, with what its caption says.
+type posterMaze struct {
+	grid       maze.Grid
+	seed       int64
+	difficulty int
+}
+
+// caption is what's printed under maze i of a poster: enough to make it
+// again with --seed.
+func (m posterMaze) caption(i int) string {
+	return fmt.Sprintf("Maze %d: seed %d, difficulty %d", i+1, m.seed, m.difficulty)
+}
+
+// runPoster is the poster command: it lays a grid of independent mazes out
+// on one US letter sheet, as SVG or PDF by the extension of the file it
+// writes, each with a caption of its seed and Difficulty, for classroom
+// handouts and activity sheets.
+func runPoster(args []string) error {
+	fs := flag.NewFlagSet("poster", flag.ExitOnError)
+	layout := fs.String("layout", "3x2", "how to lay the mazes out, `RxC`: R rows of C")
+	rows := fs.Int("rows", 10, "rows in each maze")
+	cols := fs.Int("cols", 10, "columns in each maze")
+	algorithm := fs.String("algorithm", "kruskal", "generation algorithm: "+strings.Join(maze.GeneratorNames(), ", "))
+	seed := fs.Int64("seed", 0, "random seed, to repeat a poster (0 picks one from the clock)")
+	version := fs.Int("generator-version", maze.LatestGeneratorVersion, generatorVersionUsage)
+	theme := fs.String("theme", "print", "drawing style: "+strings.Join(maze.ThemeNames(), ", "))
+	title := fs.String("title", "", "heading for the top of the sheet")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze poster [flags] out.svg|out.pdf")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *rows < 1 || *cols < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var down, across int
+	if _, err := fmt.Sscanf(*layout, "%dx%d", &down, &across); err != nil || down < 1 || across < 1 {
+		return fmt.Errorf("bad --layout %q, want RxC", *layout)
+	}
+	format := strings.TrimPrefix(filepath.Ext(fs.Arg(0)), ".")
+	if format != "svg" && format != "pdf" {
+		return fmt.Errorf("%s: a poster is written as .svg or .pdf", fs.Arg(0))
+	}
+	gen, ok := maze.LookupGenerator(*algorithm)
+	if !ok {
+		return fmt.Errorf("unknown algorithm %q", *algorithm)
+	}
+	style, ok := maze.LookupTheme(*theme)
+	if !ok {
+		return fmt.Errorf("unknown theme %q", *theme)
+	}
+	if err := checkGeneratorVersion(*version); err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	// Each maze has its own seed, so `maze --seed` (with the same
+	// --generator-version) can make it on its own.
+	mazes := make([]posterMaze, down*across)
+	for i := range mazes {
+		m := &mazes[i]
+		m.seed = rng.Int63()
+		var err error
+		if m.grid, err = maze.NewGrid(*rows, *cols); err != nil {
+			return err
+		}
+		m.grid.GeneratorVersion = *version
+		if err := maze.Generate(context.Background(), gen, &m.grid, rand.New(rand.NewSource(m.seed)), maze.NoBias); err != nil {
+			return err
+		}
+		m.difficulty = m.grid.Difficulty()
+	}
+	info := map[string]string{
+		"command":           "poster",
+		"seed":              strconv.FormatInt(*seed, 10),
+		"algorithm":         *algorithm,
+		"generator-version": strconv.Itoa(*version),
+		"layout":            *layout,
+		"rows":              strconv.Itoa(*rows),
+		"cols":              strconv.Itoa(*cols),
+	}
+	if *title != "" {
+		info["title"] = *title
+	}
+
+	poster := maze.Poster{Across: across, Title: *title, Style: style, Info: info}
+	for i, m := range mazes {
+		poster.Mazes = append(poster.Mazes, m.grid)
+		poster.Captions = append(poster.Captions, m.caption(i))
+	}
+	var buf bytes.Buffer
+	write := poster.WritePDF
+	if format == "svg" {
+		write = poster.WriteSVG
+	}
+	if err := write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(fs.Arg(0), buf.Bytes(), 0o644)
+}
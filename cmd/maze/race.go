@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// raceMessage is a line of JSON sent between the race server and its
+// clients.  The server sends a "maze" message to each player as they join,
+// "start" once everyone is there, "positions" whenever anyone moves and
+// "finished" as players reach the finish, or "error" before hanging up.
+// Clients send {"move": "N"} (or E, S, W) to take a step.
+type raceMessage struct {
+	Type    string       `json:"type,omitempty"`
+	You     int          `json:"you,omitempty"`
+	Maze    *maze.Grid   `json:"maze,omitempty"`
+	Players []racePlayer `json:"players,omitempty"`
+	ID      int          `json:"id,omitempty"`
+	Place   int          `json:"place,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Move    string       `json:"move,omitempty"`
+}
+
+// racePlayer is where a player is.  Place is 0 until they finish.
+type racePlayer struct {
+	ID    int `json:"id"`
+	Cell  int `json:"cell"`
+	Place int `json:"place,omitempty"`
+}
+
+// raceServer runs a single race: players join until there are enough of
+// them, then they all race from the top left to the bottom right of the
+// same maze.
+type raceServer struct {
+	grid  *maze.Grid
+	count int
+	mu    sync.Mutex
+	conns []*raceConn
+	// started is set once count players have joined, after which no one
+	// else can.
+	started  bool
+	finished int
+	// done is closed when no one is still racing.
+	done chan struct{}
+}
+
+// raceConn is a connected player.
+type raceConn struct {
+	racePlayer
+	conn net.Conn
+	enc  *json.Encoder
+	// gone is set once the player's been dropped from the race.
+	gone bool
+}
+
+// raceWriteTimeout is how long the server waits to send a player a message
+// before giving up on them, so one who stops reading can't hold up the rest.
+const raceWriteTimeout = 5 * time.Second
+
+// runRaceServer is the race-server command.
+func runRaceServer(args []string) error {
+	fs := flag.NewFlagSet("race-server", flag.ExitOnError)
+	addr := fs.String("addr", ":7777", "address to listen on")
+	players := fs.Int("players", 2, "number of players to wait for before starting")
+	algorithm := fs.String("algorithm", "kruskal", "generation algorithm: "+strings.Join(maze.GeneratorNames(), ", "))
+	seed := fs.Int64("seed", 0, "random seed, to race a maze again (0 picks one from the clock)")
+	version := fs.Int("generator-version", maze.LatestGeneratorVersion, generatorVersionUsage)
+	wait := fs.Duration("wait", 10*time.Minute, "how long to wait for the players to join before giving up, or 0 to wait forever")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze race-server [flags] [rows] [cols]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	rows, cols, err := parseSize(fs.Args())
+	if err != nil {
+		return err
+	}
+	if *players < 1 {
+		return fmt.Errorf("can't race with %d players", *players)
+	}
+	gen, ok := maze.LookupGenerator(*algorithm)
+	if !ok {
+		return fmt.Errorf("unknown algorithm %q", *algorithm)
+	}
+	if err := checkGeneratorVersion(*version); err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	grid, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		return err
+	}
+	grid.GeneratorVersion = *version
+	if err := maze.Generate(context.Background(), gen, &grid, rand.New(rand.NewSource(*seed)), maze.NoBias); err != nil {
+		return err
+	}
+	grid.Entrances, grid.Exits = []maze.Cell{{Row: 0, Col: 0}}, []maze.Cell{{Row: grid.RowCount - 1, Col: grid.ColCount - 1}}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	fmt.Printf("waiting for %d players on %s, seed %d\n", *players, ln.Addr(), *seed)
+	s := &raceServer{grid: &grid, count: *players, done: make(chan struct{})}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	var timeout <-chan time.Time
+	if *wait > 0 {
+		t := time.NewTimer(*wait)
+		defer t.Stop()
+		timeout = t.C
+	}
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case <-timeout:
+			s.mu.Lock()
+			started, joined := s.started, len(s.conns)
+			if !started {
+				s.broadcast(raceMessage{Type: "error", Error: "not enough players joined"})
+			}
+			s.mu.Unlock()
+			if !started {
+				return fmt.Errorf("only %d of %d players joined in %v", joined, *players, *wait)
+			}
+			timeout = nil
+		}
+	}
+}
+
+// serve handles a player's connection until they hang up.
+func (s *raceServer) serve(conn net.Conn) {
+	defer conn.Close()
+	p := s.join(conn)
+	if p == nil {
+		return
+	}
+	dec := json.NewDecoder(conn)
+	for {
+		var m raceMessage
+		if err := dec.Decode(&m); err != nil {
+			break
+		}
+		s.move(p, m.Move)
+	}
+	s.leave(p)
+}
+
+// join adds a player, or returns nil if the race has already started.
+func (s *raceServer) join(conn net.Conn) *raceConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enc := json.NewEncoder(conn)
+	conn.SetWriteDeadline(time.Now().Add(raceWriteTimeout))
+	if s.started {
+		enc.Encode(raceMessage{Type: "error", Error: "the race has already started"})
+		return nil
+	}
+	id := 1
+	if len(s.conns) > 0 {
+		id = s.conns[len(s.conns)-1].ID + 1
+	}
+	if err := enc.Encode(raceMessage{Type: "maze", You: id, Maze: s.grid}); err != nil {
+		return nil
+	}
+	p := &raceConn{racePlayer: racePlayer{ID: id}, conn: conn, enc: enc}
+	s.conns = append(s.conns, p)
+	fmt.Printf("player %d joined from %s\n", id, conn.RemoteAddr())
+	s.broadcast(raceMessage{Type: "positions", Players: s.positions()})
+	if len(s.conns) == s.count {
+		s.started = true
+		fmt.Println("go!")
+		s.broadcast(raceMessage{Type: "start"})
+	}
+	return p
+}
+
+// move takes a step in the direction named by move for player p, if the race
+// is on and there's no wall in the way.
+func (s *raceServer) move(p *raceConn, move string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := map[string]maze.Direction{"N": maze.N, "E": maze.E, "S": maze.S, "W": maze.W}[move]
+	if !ok || !s.started || p.Place > 0 || p.gone {
+		return
+	}
+	row, col := p.Cell/s.grid.ColCount, p.Cell%s.grid.ColCount
+	if s.grid.HasWall(maze.Cell{Row: row, Col: col}, d) {
+		return
+	}
+	p.Cell = s.grid.CellIdOf(maze.Cell{Row: row, Col: col}.Step(d))
+	if p.Cell == s.grid.CellIdOf(s.grid.Exits[0]) {
+		s.finished++
+		p.Place = s.finished
+		fmt.Printf("player %d finished %s\n", p.ID, ordinal(p.Place))
+		s.broadcast(raceMessage{Type: "finished", ID: p.ID, Place: p.Place})
+	}
+	s.broadcast(raceMessage{Type: "positions", Players: s.positions()})
+	s.checkDone()
+}
+
+// leave removes a player who hung up, if broadcast hasn't already.
+func (s *raceServer) leave(p *raceConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !p.gone {
+		s.conns = slices.DeleteFunc(s.conns, func(q *raceConn) bool { return q == p })
+		p.gone = true
+		fmt.Printf("player %d left\n", p.ID)
+	}
+	s.broadcast(raceMessage{Type: "positions", Players: s.positions()})
+	s.checkDone()
+}
+
+// checkDone ends the race once it has started and no one is still racing.
+// s.mu must be held.
+func (s *raceServer) checkDone() {
+	if !s.started {
+		return
+	}
+	for _, p := range s.conns {
+		if p.Place == 0 {
+			return
+		}
+	}
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+// positions returns where every player is.  s.mu must be held.
+func (s *raceServer) positions() []racePlayer {
+	players := make([]racePlayer, len(s.conns))
+	for i, p := range s.conns {
+		players[i] = p.racePlayer
+	}
+	return players
+}
+
+// broadcast sends m to every player.  s.mu must be held.  Players who can't
+// keep up are disconnected and dropped from the race; their serve goroutine
+// sees the closed connection and calls leave, which checks whether the race
+// is done.
+func (s *raceServer) broadcast(m raceMessage) {
+	s.conns = slices.DeleteFunc(s.conns, func(p *raceConn) bool {
+		p.conn.SetWriteDeadline(time.Now().Add(raceWriteTimeout))
+		if err := p.enc.Encode(m); err != nil {
+			p.conn.Close()
+			p.gone = true
+			fmt.Printf("player %d dropped: %v\n", p.ID, err)
+			return true
+		}
+		return false
+	})
+}
+
+// ordinal returns n as 1st, 2nd, 3rd, etc.
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+const raceHelp = "arrows/hjkl move  q quit"
+
+// raceClient is the state of the race command.
+type raceClient struct {
+	grid    *maze.Grid
+	you     int
+	players []racePlayer
+	status  string
+}
+
+// runRace is the race command: join a race-server and race the other
+// players to the finish.
+func runRace(args []string) error {
+	fs := flag.NewFlagSet("race", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze race host:port")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	conn, err := net.Dial("tcp", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	dec, enc := json.NewDecoder(conn), json.NewEncoder(conn)
+	var m raceMessage
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	if m.Type == "error" {
+		return fmt.Errorf("%s", m.Error)
+	}
+	if m.Type != "maze" || m.Maze == nil || m.Maze.RowCount < 1 || m.Maze.ColCount < 1 {
+		return fmt.Errorf("expected a maze from the server, got %q", m.Type)
+	}
+	c := &raceClient{grid: m.Maze, you: m.You, status: "waiting for the other players"}
+
+	restore, err := enterCbreak()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	messages := make(chan raceMessage)
+	go func() {
+		defer close(messages)
+		for {
+			var m raceMessage
+			if err := dec.Decode(&m); err != nil {
+				return
+			}
+			messages <- m
+		}
+	}()
+	keys := make(chan int)
+	go func() {
+		in := bufio.NewReader(os.Stdin)
+		for {
+			key, err := readKey(in)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- key
+		}
+	}()
+	move := map[int]maze.Direction{keyUp: maze.N, 'k': maze.N, keyRight: maze.E, 'l': maze.E, keyDown: maze.S, 'j': maze.S, keyLeft: maze.W, 'h': maze.W}
+	for {
+		c.draw(os.Stdout)
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				c.status += " (the server hung up)"
+				c.draw(os.Stdout)
+				return nil
+			}
+			c.handle(m)
+		case key, ok := <-keys:
+			if !ok || key == 'q' || key == 3 { // 3 is ctrl-c
+				return nil
+			}
+			if d, ok := move[key]; ok {
+				if err := enc.Encode(raceMessage{Move: d.String()}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// handle updates the client with a message from the server.
+func (c *raceClient) handle(m raceMessage) {
+	switch m.Type {
+	case "start":
+		c.status = "go!"
+	case "positions":
+		c.players = m.Players
+	case "finished":
+		if m.ID == c.you {
+			c.status = "you finished " + ordinal(m.Place)
+		} else {
+			c.status = fmt.Sprintf("player %d finished %s", m.ID, ordinal(m.Place))
+		}
+	case "error":
+		c.status = m.Error
+	}
+}
+
+// draw redraws the whole screen, with you as @, the other players as their
+// numbers and the finish as F.
+func (c *raceClient) draw(w io.Writer) {
+	g := c.grid
+	_, finish := g.Endpoints()
+	buf := append([]byte(ansiClear), g.AppendTextTop(nil)...)
+	labels := make([]rune, g.ColCount)
+	for row := 0; row < g.RowCount; row++ {
+		for col := range labels {
+			labels[col] = 0
+			if (maze.Cell{Row: row, Col: col}) == finish {
+				labels[col] = 'F'
+			}
+		}
+		for _, p := range c.players {
+			if p.Cell/g.ColCount != row || p.ID == c.you {
+				continue
+			}
+			label := '*'
+			if p.ID < 10 {
+				label = rune('0' + p.ID)
+			}
+			labels[p.Cell%g.ColCount] = label
+		}
+		for _, p := range c.players {
+			if p.ID == c.you && p.Cell/g.ColCount == row {
+				labels[p.Cell%g.ColCount] = '@'
+			}
+		}
+		buf = g.AppendTextRow(buf, row, labels, nil)
+	}
+	buf = append(buf, fmt.Sprintf("you are player %d  %s\n%s\n", c.you, raceHelp, c.status)...)
+	w.Write(buf)
+}
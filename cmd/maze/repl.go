@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+const replHelp = `commands:
+  gen ROWS COLS [ALGORITHM [SEED]]  generate a new maze (kruskal, random seed)
+  solve [R,C R,C] [SOLVER]          solve from start to finish (the maze's own, bfs)
+  braid P                           knock through dead ends with probability P
+  loops P                           knock through any wall with probability P
+  straighten P                      rewire bends into straights with probability P
+  undo, redo                        undo or redo the last braid, loops or straighten
+  show                              print the maze again
+  stats                             print the size, dead ends and solution analysis
+  save FILE                         save as JSON (.json, .pb) or render (.txt, .svg, .png, ...)
+  load FILE                         load a maze maze solve can read
+  help                              print this
+  quit                              leave`
+
+// replShowCells is the biggest maze the repl prints after each command;
+// bigger ones only print with show.
+const replShowCells = 40 * 80
+
+// repl is the state of the repl command: the maze being worked on, the
+// path solved through it, if any, and the settings it was generated with,
+// for images to record, until it's changed.
+type repl struct {
+	grid *maze.Grid
+	path []int
+	seed int64
+	info map[string]string
+	out  io.Writer
+}
+
+// runRepl is the repl command: it reads commands like "gen 30 40 kruskal",
+// "solve 0,0 29,39", "braid 0.3" or "save maze.svg" from stdin, one a line,
+// and runs them on one maze kept between them, printing it after each.
+func runRepl(args []string) error {
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze repl")
+		fmt.Fprintln(fs.Output(), replHelp)
+	}
+	fs.Parse(args)
+
+	r := &repl{out: os.Stdout}
+	fmt.Fprintln(r.out, "a new 10x10 maze; help lists the commands")
+	if err := r.run("gen 10 10"); err != nil {
+		return err
+	}
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(r.out, "maze> ")
+		if !in.Scan() {
+			fmt.Fprintln(r.out)
+			return in.Err()
+		}
+		line := strings.TrimSpace(in.Text())
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if err := r.run(line); err != nil {
+			fmt.Fprintln(r.out, "error:", err)
+		}
+	}
+}
+
+// run runs one command line.
+func (r *repl) run(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "gen":
+		return r.gen(args)
+	case "solve":
+		return r.solve(args)
+	case "braid", "loops", "straighten":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s P", cmd)
+		}
+		p, err := strconv.ParseFloat(args[0], 64)
+		if err != nil || p < 0 || p > 1 {
+			return fmt.Errorf("bad probability %q", args[0])
+		}
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		switch cmd {
+		case "braid":
+			r.grid.Braid(rng, p)
+		case "loops":
+			r.grid.AddLoops(rng, p)
+		default:
+			r.grid.Straighten(rng, p, 0.1)
+		}
+		r.info = nil
+		r.changed()
+	case "undo", "redo":
+		step := r.grid.Undo
+		if cmd == "redo" {
+			step = r.grid.Redo
+		}
+		if !step() {
+			return fmt.Errorf("nothing to %s", cmd)
+		}
+		r.changed()
+	case "show":
+		r.show(true)
+	case "stats":
+		deadEnds := 0
+		for range r.grid.DeadEnds() {
+			deadEnds++
+		}
+		fmt.Fprintf(r.out, "%dx%d, %d dead ends", r.grid.RowCount, r.grid.ColCount, deadEnds)
+		if stats, ok := r.grid.AnalyzeSolution(); ok {
+			fmt.Fprintf(r.out, ", solution %d steps with %d turns and %d decisions, %d cells of dead ends off it, difficulty %d\n",
+				stats.Steps, stats.Turns, stats.Decisions, stats.DeadEndLength, r.grid.Difficulty())
+		} else {
+			fmt.Fprintln(r.out, ", no solution")
+		}
+		if r.info != nil {
+			fmt.Fprintf(r.out, "regenerate with: %s\n", maze.RegenerateCommand(r.info))
+		}
+	case "save":
+		if len(args) != 1 {
+			return errors.New("usage: save FILE")
+		}
+		if err := r.save(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, "saved", args[0])
+	case "load":
+		if len(args) != 1 {
+			return errors.New("usage: load FILE")
+		}
+		return r.load(args[0])
+	case "help":
+		fmt.Fprintln(r.out, replHelp)
+	default:
+		return fmt.Errorf("unknown command %q; try help", cmd)
+	}
+	return nil
+}
+
+// gen is the gen command.
+func (r *repl) gen(args []string) error {
+	if len(args) < 2 || len(args) > 4 {
+		return errors.New("usage: gen ROWS COLS [ALGORITHM [SEED]]")
+	}
+	rows, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	cols, err := strconv.Atoi(args[1])
+	if err != nil {
+		return err
+	}
+	algorithm := "kruskal"
+	if len(args) > 2 {
+		algorithm = args[2]
+	}
+	gen, ok := maze.LookupGenerator(algorithm)
+	if !ok {
+		return fmt.Errorf("unknown algorithm %q; have %s", algorithm, strings.Join(maze.GeneratorNames(), ", "))
+	}
+	seed := time.Now().UnixNano()
+	if len(args) > 3 {
+		if seed, err = strconv.ParseInt(args[3], 10, 64); err != nil {
+			return err
+		}
+	}
+	g, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		return err
+	}
+	if err := maze.Generate(context.Background(), gen, &g, rand.New(rand.NewSource(seed)), maze.NoBias); err != nil {
+		return err
+	}
+	g.History = &maze.History{}
+	r.grid, r.seed = &g, seed
+	r.info = map[string]string{
+		"seed":              strconv.FormatInt(seed, 10),
+		"rows":              strconv.Itoa(rows),
+		"cols":              strconv.Itoa(cols),
+		"algorithm":         algorithm,
+		"generator-version": strconv.Itoa(maze.LatestGeneratorVersion),
+	}
+	r.changed()
+	return nil
+}
+
+// solve is the solve command.
+func (r *repl) solve(args []string) error {
+	start, finish := r.grid.Endpoints()
+	name := "bfs"
+	if len(args) == 1 || len(args) == 3 {
+		name = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+	if len(args) == 2 {
+		var err error
+		if start, err = parseCell(args[0]); err != nil {
+			return err
+		}
+		if finish, err = parseCell(args[1]); err != nil {
+			return err
+		}
+	} else if len(args) != 0 {
+		return errors.New("usage: solve [R,C R,C] [SOLVER]")
+	}
+	solver, ok := maze.LookupSolver(name)
+	if !ok {
+		return fmt.Errorf("unknown solver %q; have %s", name, strings.Join(maze.SolverNames(), ", "))
+	}
+	path, err := maze.FindPath(solver, r.grid, start, finish)
+	if err != nil {
+		return err
+	}
+	r.path = r.grid.CellIds(path)
+	r.show(false)
+	fmt.Fprintf(r.out, "%s: %d steps\n", name, len(path)-1)
+	return nil
+}
+
+// save is the save command: JSON or a protocol buffer for .json and .pb,
+// otherwise rendered in the format named by the extension, with the
+// solution if there is one.
+func (r *repl) save(path string) error {
+	style, _ := maze.LookupTheme("classic")
+	return saveAs(path, r.grid, maze.RenderOptions{Style: &style, Path: r.grid.PathCells(r.path), Info: r.info})
+}
+
+// load is the load command.
+func (r *repl) load(path string) error {
+	var g *maze.Grid
+	var err error
+	if strings.HasSuffix(path, ".pb") {
+		g, err = loadGrid(path)
+	} else {
+		var b []byte
+		if b, err = os.ReadFile(path); err == nil {
+			g, err = maze.ParseMaze(b)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	g.History = &maze.History{}
+	r.grid, r.seed, r.info = g, 0, nil
+	r.changed()
+	return nil
+}
+
+// changed forgets the path, which may no longer be right, and shows the
+// changed maze.
+func (r *repl) changed() {
+	r.path = nil
+	r.show(false)
+}
+
+// show prints the maze with the path, if any, unless it's too big for the
+// screen and always isn't set.
+func (r *repl) show(always bool) {
+	if !always && r.grid.RowCount*r.grid.ColCount > replShowCells {
+		fmt.Fprintf(r.out, "%dx%d maze; show prints it\n", r.grid.RowCount, r.grid.ColCount)
+		return
+	}
+	r.out.Write(r.grid.AppendText(nil, r.grid.PathCells(r.path)))
+}
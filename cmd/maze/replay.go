@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"os"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runReplay is the replay command: it reads a generation trace, as --trace
+// writes it, from the file named by args and shows the maze being carved
+// again, in the terminal as --animate does or as an animated GIF, without
+// generating it again.  Only the trace's last attempt is shown, the one
+// that made the maze.  The size of the maze is worked out from the cells
+// the trace carves into.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	frameDelay := fs.Duration("frame-delay", 10*time.Millisecond, "how long to pause after each frame")
+	perFrame := fs.Int("walls-per-frame", 0, "how many walls to carve a frame (0 is 1 in the terminal, or enough for about 200 frames in a GIF)")
+	gifFile := fs.String("gif", "", "write the replay to `file` as an animated GIF instead of animating it in the terminal")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze replay [flags] trace.jsonl")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	steps, err := maze.ReadTrace(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("%s: the trace is empty", fs.Arg(0))
+	}
+	last := len(steps) - 1
+	for last > 0 && steps[last-1].Attempt == steps[len(steps)-1].Attempt {
+		last--
+	}
+	steps = steps[last:]
+	for i := range steps {
+		steps[i].Attempt = 0
+	}
+	rows, cols := maze.TraceSize(steps)
+	g, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		return err
+	}
+
+	if *gifFile != "" {
+		n := *perFrame
+		if n <= 0 {
+			n = (len(steps) + raceFrames - 1) / raceFrames
+		}
+		out, err := os.Create(*gifFile)
+		if err != nil {
+			return err
+		}
+		if err := writeReplayGIF(out, &g, steps, n, *frameDelay); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	}
+
+	if !isTerminal(os.Stdout) {
+		return fmt.Errorf("replaying in the terminal needs stdout to be a terminal; use --gif to write a file")
+	}
+	if termRows, termCols, err := terminalSize(); err == nil && termRows > 0 && (rows+2 > termRows || 2*cols+1 > termCols) {
+		return fmt.Errorf("a %dx%d maze is too big to replay in a %dx%d terminal; use --gif to write a file", rows, cols, termRows, termCols)
+	}
+	n := max(*perFrame, 1)
+	draw := animateCarving(os.Stdout, &g, 0)
+	carved := 0
+	g.Observer = &maze.Observer{Carve: func(c maze.Cell, d maze.Direction) {
+		draw(c, d)
+		if carved++; carved%n == 0 {
+			time.Sleep(*frameDelay)
+		}
+	}}
+	if err := maze.ReplaySteps(&g, steps); err != nil {
+		return err
+	}
+	// Leave the cursor under the maze.
+	fmt.Printf("\x1b[%d;1H", rows+2)
+	return nil
+}
+
+// writeReplayGIF writes an animated GIF of steps, the walls a trace
+// removes, being carved into g, perFrame walls a frame, frameDelay apart,
+// with the cells carved into in each frame picked out.
+func writeReplayGIF(w io.Writer, g *maze.Grid, steps []maze.TraceStep, perFrame int, frameDelay time.Duration) error {
+	cell := min(max(400/max(g.RowCount, g.ColCount), 3), 12)
+	const margin = 4
+	width, height := 2*margin+g.ColCount*cell+1, 2*margin+g.RowCount*cell+1
+	// White, black, then the colour of the newest cells.
+	classic, _ := maze.LookupTheme("classic")
+	palette := color.Palette{color.White, color.Black, classic.Solution}
+	// GIF delays are in hundredths of a second, and viewers slow down
+	// anything under two.
+	delay := max(int(frameDelay/(10*time.Millisecond)), 2)
+
+	anim := &gif.GIF{}
+	var fresh []int
+	g.Observer = &maze.Observer{Carve: func(c maze.Cell, d maze.Direction) {
+		fresh = append(fresh, g.CellIdOf(c), g.CellIdOf(c.Step(d)))
+	}}
+	for start := 0; start < len(steps); start += perFrame {
+		fresh = fresh[:0]
+		if err := maze.ReplaySteps(g, steps[start:min(start+perFrame, len(steps))]); err != nil {
+			return err
+		}
+		img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		for _, id := range fresh {
+			x, y := margin+id%g.ColCount*cell, margin+id/g.ColCount*cell
+			for dy := 1; dy < cell; dy++ {
+				for dx := 1; dx < cell; dx++ {
+					img.Pix[img.PixOffset(x+dx, y+dy)] = 2
+				}
+			}
+		}
+		drawGIFWalls(img, g, margin, margin, cell)
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	// Hold the finished maze, without the newest cells picked out.
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	drawGIFWalls(img, g, margin, margin, cell)
+	anim.Image = append(anim.Image, img)
+	anim.Delay = append(anim.Delay, 300)
+	return gif.EncodeAll(w, anim)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// Score is one finished game of the play command.
+type Score struct {
+	Seed      int64  `json:"seed"`
+	Rows      int    `json:"rows"`
+	Cols      int    `json:"cols"`
+	Algorithm string `json:"algorithm"`
+	// GeneratorVersion is the version of the generators that made the
+	// maze.  Scores from before it was recorded have 0, and were on
+	// version 1.
+	GeneratorVersion int     `json:"generatorVersion,omitempty"`
+	Enemies          int     `json:"enemies,omitempty"`
+	Ice              bool    `json:"ice,omitempty"`
+	Morph            int     `json:"morph,omitempty"`
+	Coins            int     `json:"coins,omitempty"`
+	Seconds          float64 `json:"seconds"`
+	Moves            int     `json:"moves"`
+	// Efficiency is the fewest moves the maze can be finished in over the
+	// moves taken, so 1 is a perfect game.
+	Efficiency float64   `json:"efficiency"`
+	When       time.Time `json:"when"`
+}
+
+// sameMaze reports whether s and t were games on the same maze, with the
+// same number of enemies, on ice or not, morphing as much and with as many
+// coins.
+func (s Score) sameMaze(t Score) bool {
+	return s.Seed == t.Seed && s.Rows == t.Rows && s.Cols == t.Cols && s.Algorithm == t.Algorithm &&
+		s.version() == t.version() &&
+		s.Enemies == t.Enemies && s.Ice == t.Ice && s.Morph == t.Morph && s.Coins == t.Coins
+}
+
+// version returns the version of the generators that made s's maze.
+func (s Score) version() int {
+	if s.GeneratorVersion == 0 {
+		return 1
+	}
+	return s.GeneratorVersion
+}
+
+// defaultScoresPath is where the scores are kept unless --scores says
+// otherwise: maze/scores.json in the user's config directory.
+func defaultScoresPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "maze-scores.json"
+	}
+	return filepath.Join(dir, "maze", "scores.json")
+}
+
+// loadScores reads the scores saved by saveScores.  A file that doesn't exist
+// yet has no scores.
+func loadScores(path string) ([]Score, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var scores []Score
+	if err := json.Unmarshal(b, &scores); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return scores, nil
+}
+
+// saveScores writes scores to path as JSON, creating its directory if need
+// be.
+func saveScores(path string, scores []Score) error {
+	b, err := json.MarshalIndent(scores, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0666)
+}
+
+// scoresFor returns the games played on the same maze as s, fastest first.
+func scoresFor(scores []Score, s Score) []Score {
+	var same []Score
+	for _, t := range scores {
+		if t.sameMaze(s) {
+			same = append(same, t)
+		}
+	}
+	sort.SliceStable(same, func(i, j int) bool { return same[i].Seconds < same[j].Seconds })
+	return same
+}
+
+// bestScores returns the fastest game on each maze, smallest mazes first.
+func bestScores(scores []Score) []Score {
+	var best []Score
+	for _, s := range scores {
+		i := 0
+		for i < len(best) && !best[i].sameMaze(s) {
+			i++
+		}
+		if i == len(best) {
+			best = append(best, s)
+		} else if s.Seconds < best[i].Seconds {
+			best[i] = s
+		}
+	}
+	sort.SliceStable(best, func(i, j int) bool {
+		if a, b := best[i].Rows*best[i].Cols, best[j].Rows*best[j].Cols; a != b {
+			return a < b
+		}
+		return best[i].Seconds < best[j].Seconds
+	})
+	return best
+}
+
+// printScores writes scores to w as a table.
+func printScores(w io.Writer, scores []Score) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "size\tseed\talgorithm\tenemies\ttime\tmoves\tefficiency\tdate")
+	for _, s := range scores {
+		algorithm := s.Algorithm
+		if v := s.version(); v != maze.LatestGeneratorVersion {
+			algorithm += fmt.Sprintf(" version %d", v)
+		}
+		if s.Ice {
+			algorithm += " on ice"
+		}
+		if s.Morph > 0 {
+			algorithm += fmt.Sprintf(" morphing %d", s.Morph)
+		}
+		if s.Coins > 0 {
+			algorithm += fmt.Sprintf(" with %d coins", s.Coins)
+		}
+		fmt.Fprintf(tw, "%dx%d\t%d\t%s\t%d\t%.1fs\t%d\t%.0f%%\t%s\n", s.Rows, s.Cols, s.Seed, algorithm, s.Enemies,
+			s.Seconds, s.Moves, 100*s.Efficiency, s.When.Local().Format("2006-01-02"))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// contentTypes are the Content-Types of the output formats, for the ones that
+// aren't plain text.
+var contentTypes = map[string]string{
+	"svg":  "image/svg+xml",
+	"png":  "image/png",
+	"pdf":  "application/pdf",
+	"html": "text/html; charset=utf-8",
+}
+
+// runServe is the serve command: an HTTP server for generating, solving and
+// rendering mazes, with metrics for Prometheus at /metrics and, with -pprof,
+// profiles under /debug/pprof/.  Mazes asked for by seed are cached, up to
+// -cache megabytes of them.  With -token, every request needs the token;
+// with -rate, each client gets that many requests a second.
+//
+//	GET /maze?rows=20&cols=20&algorithm=kruskal&seed=1&bias=0.5&format=svg&theme=dark&solution=true
+//	POST /solve?solver=astar&format=png, with a maze that ParseMaze reads as the body
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	profiling := fs.Bool("pprof", false, "serve profiles under /debug/pprof/")
+	cacheMB := fs.Int("cache", 64, "megabytes of rendered mazes to cache, or 0 for none")
+	token := fs.String("token", "", "API token requests must send as \"Authorization: Bearer <token>\" (default $MAZE_TOKEN)")
+	rate := fs.Float64("rate", 0, "requests a second each client may make, big mazes counting as one per 10000 cells, or 0 for no limit")
+	burst := fs.Int("burst", 100, "requests a client may make at once, with -rate")
+	fs.Parse(args)
+	if *token == "" {
+		*token = os.Getenv("MAZE_TOKEN")
+	}
+	mazeCache = newRenderCache(*cacheMB << 20)
+	limiter = newRateLimiter(*rate, *burst)
+
+	mux := http.NewServeMux()
+	mux.Handle("/maze", errorHandler(serveMaze))
+	mux.Handle("/solve", errorHandler(serveSolve))
+	mux.HandleFunc("/metrics", serveMetrics)
+	if *profiling {
+		handlePprof(mux)
+	}
+	log.Printf("serving on %s", *addr)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           requireToken(*token, mux),
+		ReadHeaderTimeout: serveHeaderTimeout,
+		ReadTimeout:       serveReadTimeout,
+		WriteTimeout:      serveWriteTimeout,
+		IdleTimeout:       serveIdleTimeout,
+	}
+	return srv.ListenAndServe()
+}
+
+// The serve command's timeouts, so slow clients can't hold connections open
+// forever.  Writing has long enough for the slowest solve, maxSolveTime, and
+// a 30-second profile.
+const (
+	serveHeaderTimeout = 10 * time.Second
+	serveReadTimeout   = 30 * time.Second
+	serveWriteTimeout  = time.Minute
+	serveIdleTimeout   = 2 * time.Minute
+)
+
+// mazeCache caches the mazes /maze renders for a seed it's given, and
+// limiter limits how fast each client can ask for mazes.
+var (
+	mazeCache *renderCache
+	limiter   *rateLimiter
+)
+
+// httpError is an error with the HTTP status to report it with.
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+// errorHandler is a handler that responds with the error f returns, if any.
+type errorHandler func(w http.ResponseWriter, r *http.Request) error
+
+func (f errorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := f(w, r)
+	if err == nil {
+		return
+	}
+	status := http.StatusInternalServerError
+	if e, ok := err.(*httpError); ok {
+		status = e.status
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func serveMaze(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return &httpError{http.StatusMethodNotAllowed, "GET only"}
+	}
+	q := r.URL.Query()
+	rows, err := queryInt(q, "rows", 10)
+	if err != nil {
+		return err
+	}
+	cols, err := queryInt(q, "cols", 10)
+	if err != nil {
+		return err
+	}
+	if rows < 1 || cols < 1 || rows > maxServeCells || cols > maxServeCells || rows*cols > maxServeCells {
+		return badRequest("bad maze size %dx%d", rows, cols)
+	}
+	algorithm := queryString(q, "algorithm", "kruskal")
+	gen, ok := maze.LookupGenerator(algorithm)
+	if !ok {
+		return badRequest("unknown algorithm %q", algorithm)
+	}
+	seed, err := strconv.ParseInt(queryString(q, "seed", "0"), 10, 64)
+	if err != nil {
+		return badRequest("bad seed: %v", err)
+	}
+	bias, err := strconv.ParseFloat(queryString(q, "bias", strconv.FormatFloat(maze.NoBias, 'g', -1, 64)), 64)
+	if err != nil {
+		return badRequest("bad bias: %v", err)
+	}
+	if err := maze.CheckBias(algorithm, gen, bias); err != nil {
+		return badRequest("%v", err)
+	}
+	// Only mazes asked for by seed are cached: the rest are random, and
+	// never asked for again.
+	key := renderKey{
+		rows: rows, cols: cols, algorithm: algorithm, seed: seed, bias: bias,
+		format:   queryString(q, "format", "text"),
+		theme:    queryString(q, "theme", "classic"),
+		solution: q.Get("solution") == "true",
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	} else if cached, ok := mazeCache.get(key); ok {
+		if err := limiter.allow(w, r, 0); err != nil {
+			return err
+		}
+		w.Header().Set("X-Maze-Seed", strconv.FormatInt(seed, 10))
+		return writeCached(w, cached)
+	}
+	if err := limiter.allow(w, r, rows*cols); err != nil {
+		return err
+	}
+
+	g, err := maze.NewGrid(rows, cols)
+	if err != nil {
+		return badRequest("%v", err)
+	}
+	start := time.Now()
+	if err := maze.Generate(r.Context(), gen, &g, rand.New(rand.NewSource(seed)), bias); err != nil {
+		return err
+	}
+	observeGeneration(algorithm, rows*cols, time.Since(start))
+	w.Header().Set("X-Maze-Seed", strconv.FormatInt(seed, 10))
+	var path []maze.Cell
+	if key.solution {
+		ctx, cancel := context.WithTimeout(r.Context(), maxSolveTime)
+		defer cancel()
+		if path, err = g.SolveContext(ctx, maze.Cell{Row: 0, Col: 0}, maze.Cell{Row: rows - 1, Col: cols - 1}); err != nil {
+			return solveTimedOut(err)
+		}
+	}
+	out, err := render(&g, q, path, map[string]string{
+		"seed":              strconv.FormatInt(seed, 10),
+		"rows":              strconv.Itoa(rows),
+		"cols":              strconv.Itoa(cols),
+		"algorithm":         algorithm,
+		"bias":              strconv.FormatFloat(bias, 'g', -1, 64),
+		"generator-version": strconv.Itoa(maze.LatestGeneratorVersion),
+	})
+	if err != nil {
+		return err
+	}
+	if key.seed != 0 {
+		mazeCache.put(key, out)
+	}
+	return writeCached(w, out)
+}
+
+func serveSolve(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &httpError{http.StatusMethodNotAllowed, "POST only"}
+	}
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 16*maxServeCells))
+	if err != nil {
+		return badRequest("%v", err)
+	}
+	g, err := maze.ParseMaze(b)
+	if err != nil {
+		return badRequest("%v", err)
+	}
+	if g.RowCount*g.ColCount > maxServeCells {
+		return badRequest("bad maze size %dx%d", g.RowCount, g.ColCount)
+	}
+	if err := limiter.allow(w, r, g.RowCount*g.ColCount); err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	name := queryString(q, "solver", "bfs")
+	solver, ok := maze.LookupSolver(name)
+	if !ok {
+		return badRequest("unknown solver %q", name)
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), maxSolveTime)
+	defer cancel()
+	start, finish := g.Endpoints()
+	path, err := maze.FindPathContext(ctx, solver, g, start, finish)
+	if ctx.Err() != nil {
+		return solveTimedOut(err)
+	}
+	if errors.Is(err, maze.ErrNoPath) {
+		return &httpError{http.StatusUnprocessableEntity, "no solution"}
+	} else if err != nil {
+		return badRequest("%v", err)
+	}
+	out, err := render(g, q, path, nil)
+	if err != nil {
+		return err
+	}
+	return writeCached(w, out)
+}
+
+// solveTimedOut is the error to respond with when solving gave up with
+// err, its Context's error, because the client went away or it took over
+// maxSolveTime.
+func solveTimedOut(err error) error {
+	return &httpError{http.StatusServiceUnavailable, fmt.Sprintf("gave up solving: %v", err)}
+}
+
+// render renders g in the format and theme given by the query.
+func render(g *maze.Grid, q url.Values, path []maze.Cell, info map[string]string) (rendered, error) {
+	format := queryString(q, "format", "text")
+	renderer, ok := maze.LookupRenderer(format)
+	if !ok {
+		return rendered{}, badRequest("unknown format %q", format)
+	}
+	style, ok := maze.LookupTheme(queryString(q, "theme", "classic"))
+	if !ok {
+		return rendered{}, badRequest("unknown theme %q", q.Get("theme"))
+	}
+	var buf bytes.Buffer
+	if err := renderer.Render(g, &buf, maze.RenderOptions{Style: &style, Path: path, Info: info}); err != nil {
+		return rendered{}, err
+	}
+	rendersTotal.inc(format)
+	contentType, ok := contentTypes[format]
+	if !ok {
+		contentType = "text/plain; charset=utf-8"
+	}
+	return rendered{contentType, buf.Bytes()}, nil
+}
+
+// writeCached writes a rendered maze as the response.
+func writeCached(w http.ResponseWriter, r rendered) error {
+	w.Header().Set("Content-Type", r.contentType)
+	_, err := w.Write(r.body)
+	return err
+}
+
+// queryString returns the query parameter key, or def if it isn't given.
+func queryString(q url.Values, key, def string) string {
+	if v := q.Get(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// queryInt returns the integer query parameter key, or def if it isn't
+// given.
+func queryInt(q url.Values, key string, def int) (int, error) {
+	n, err := strconv.Atoi(queryString(q, key, strconv.Itoa(def)))
+	if err != nil {
+		return 0, badRequest("bad %s: %v", key, err)
+	}
+	return n, nil
+}
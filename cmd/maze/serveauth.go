@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requireToken wraps h so it only serves requests carrying token as an
+// "Authorization: Bearer" header, and answers the rest with 401
+// Unauthorized.  An empty token lets every request through.
+func requireToken(token string, h http.Handler) http.Handler {
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="maze"`)
+			http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// cellsPerRequest is how many cells of maze count as one request against
+// a client's rate limit, so a million-cell maze costs as much as a hundred
+// small ones.
+const cellsPerRequest = 10000
+
+// rateLimiter limits how many requests a second each client (by IP
+// address) can make, with a token bucket for each: a client can make burst
+// requests at once, then rate a second.  It's safe to use from several
+// goroutines, and a nil *rateLimiter lets every request through.
+type rateLimiter struct {
+	rate, burst float64
+	mu          sync.Mutex
+	clients     map[string]*bucket
+	swept       time.Time
+}
+
+// bucket is a client's tokens as of when it last made a request.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a rateLimiter allowing rate requests a second in
+// bursts of up to burst, or nil if rate isn't positive.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), clients: map[string]*bucket{}}
+}
+
+// allow takes the cost of a request for cells cells of maze from the
+// client r came from, and returns an httpError with status 429 Too Many
+// Requests, setting Retry-After, if it hasn't enough left.
+func (l *rateLimiter) allow(w http.ResponseWriter, r *http.Request, cells int) error {
+	if l == nil {
+		return nil
+	}
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	// Even the biggest maze must fit in a burst, or it could never be made.
+	cost := math.Min(1+float64(cells)/cellsPerRequest, l.burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.sweep(now)
+	b, ok := l.clients[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < cost {
+		wait := math.Ceil((cost - b.tokens) / l.rate)
+		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", wait))
+		return &httpError{http.StatusTooManyRequests, fmt.Sprintf("rate limited: try again in %.0fs", wait)}
+	}
+	b.tokens -= cost
+	return nil
+}
+
+// sweep forgets, once a minute, the clients whose buckets have filled up
+// again, so the limiter doesn't grow with every client it's ever seen.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for client, b := range l.clients {
+		if now.Sub(b.last) > full {
+			delete(l.clients, client)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// renderKey is everything that decides what /maze returns, so two requests
+// with the same renderKey get the same bytes back.
+type renderKey struct {
+	rows, cols    int
+	algorithm     string
+	seed          int64
+	bias          float64
+	format, theme string
+	solution      bool
+}
+
+// rendered is a rendered maze as /maze returns it.
+type rendered struct {
+	contentType string
+	body        []byte
+}
+
+// renderCache is a least recently used cache of rendered mazes, holding at
+// most max bytes of them, so asking again for a maze by its seed (the daily
+// puzzle, say) doesn't generate and render it all over again.  It's safe to
+// use from several goroutines, and a nil *renderCache caches nothing.
+type renderCache struct {
+	mu      sync.Mutex
+	max     int
+	size    int
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[renderKey]*list.Element
+}
+
+type cacheEntry struct {
+	key renderKey
+	rendered
+}
+
+// newRenderCache returns an empty renderCache holding up to max bytes, or
+// nil if max isn't positive.
+func newRenderCache(max int) *renderCache {
+	if max <= 0 {
+		return nil
+	}
+	return &renderCache{max: max, order: list.New(), entries: map[renderKey]*list.Element{}}
+}
+
+// get returns the maze cached under key, if there is one.
+func (c *renderCache) get(key renderKey) (rendered, bool) {
+	if c == nil {
+		return rendered{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		cacheLookups.inc("miss")
+		return rendered{}, false
+	}
+	cacheLookups.inc("hit")
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).rendered, true
+}
+
+// put caches r under key, dropping the least recently used mazes to make
+// room.  Mazes bigger than the whole cache aren't kept.
+func (c *renderCache) put(key renderKey, r rendered) {
+	if c == nil || len(r.body) > c.max {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.size -= len(e.Value.(*cacheEntry).body)
+		c.order.Remove(e)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key, r})
+	c.size += len(r.body)
+	for c.size > c.max {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.body)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// runSolve is the solve command: it solves the maze in the file named by
+// args, or stdin if that's "-", and prints it with the path drawn in.  The
+// file can be in any form ParseMaze reads.  The path runs from -start to
+// -finish, or the maze's first entrance to its first exit, or corner to
+// corner if it doesn't have them.  Given several solvers, it draws each
+// one's path in its own colour, with a legend, to compare them.
+func runSolve(args []string) error {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	names := fs.String("solver", "bfs", "solving algorithm, or several separated by commas to compare them in the graphical formats: "+strings.Join(maze.SolverNames(), ", "))
+	format := fs.String("format", "text", "output format: "+strings.Join(maze.RendererNames(), ", "))
+	startCell := fs.String("start", "", "start at `row,col` instead of the maze's start")
+	finishCell := fs.String("finish", "", "finish at `row,col` instead of the maze's finish")
+	glyphNamed := fs.String("glyphs", "single", "characters for the unicode format's walls: "+strings.Join(maze.GlyphNames(), ", ")+", or 18 of your own, the corners in the order 1 up + 2 right + 4 down + 8 left, then the horizontal and vertical walls")
+	palette := fs.String("palette", "default", "colours for compared paths and the solution: "+strings.Join(maze.PaletteNames(), ", "))
+	arrows := fs.Bool("arrows", false, "draw the path in the text formats as arrows rather than dots")
+	race := fs.Bool("race", false, "animate the solvers exploring the maze side by side in the terminal")
+	raceGIF := fs.String("race-gif", "", "write the race --race animates to `file` as an animated GIF")
+	var execs []string
+	fs.Func("exec", "solve with an external `command` too, given the maze on stdin as ExecSolver documents and answering with the path, instead of --solver unless it's set (repeat for more)", func(s string) error {
+		if len(strings.Fields(s)) == 0 {
+			return errors.New("no command")
+		}
+		execs = append(execs, s)
+		return nil
+	})
+	execTimeout := fs.Duration("exec-timeout", time.Minute, "how long each --exec command has to answer")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze solve [flags] file|-")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	// The built in solvers given, unless there are only --exec ones.
+	solverSet := false
+	fs.Visit(func(f *flag.Flag) { solverSet = solverSet || f.Name == "solver" })
+	var named []string
+	if solverSet || len(execs) == 0 {
+		named = strings.Split(*names, ",")
+	}
+	var chosen []maze.Solver
+	for _, name := range named {
+		solver, ok := maze.LookupSolver(name)
+		if !ok {
+			return fmt.Errorf("unknown solver %q", name)
+		}
+		chosen = append(chosen, solver)
+	}
+	for _, command := range execs {
+		fields := strings.Fields(command)
+		chosen = append(chosen, &maze.ExecSolver{Command: fields[0], Args: fields[1:], Timeout: *execTimeout})
+		named = append(named, command)
+	}
+	if len(execs) > 0 && (*race || *raceGIF != "" || *format == "visits") {
+		// Only the built in solvers report the cells they visit.
+		return errors.New("--exec can't be used with --race, --race-gif or --format visits")
+	}
+	renderer, ok := maze.LookupRenderer(*format)
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	pal, ok := maze.LookupPalette(*palette)
+	if !ok {
+		return fmt.Errorf("unknown palette %q", *palette)
+	}
+	glyphs, err := maze.LookupGlyphs(*glyphNamed)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	if fs.Arg(0) == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	g, err := maze.ParseMaze(b)
+	if err != nil {
+		return err
+	}
+	for _, c := range []struct {
+		flag  string
+		cells *[]maze.Cell
+	}{{*startCell, &g.Entrances}, {*finishCell, &g.Exits}} {
+		if c.flag == "" {
+			continue
+		}
+		cell, err := parseCell(c.flag)
+		if err != nil {
+			return err
+		}
+		if !g.Contains(cell) {
+			return fmt.Errorf("%s is outside the grid", c.flag)
+		}
+		*c.cells = []maze.Cell{cell}
+	}
+	start, finish := g.Endpoints()
+	opts := maze.RenderOptions{Palette: &pal, Glyphs: &glyphs, Arrows: *arrows}
+	if *race || *raceGIF != "" {
+		if !g.Contains(start) || !g.Contains(finish) {
+			return errors.New("start or finish is outside the grid")
+		}
+		traces := traceSolvers(g, named, chosen, start, finish)
+		if *raceGIF != "" {
+			f, err := os.Create(*raceGIF)
+			if err != nil {
+				return err
+			}
+			if err := writeRaceGIF(f, g, traces, opts); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+		if *race {
+			return playRace(os.Stdout, g, traces, 50*time.Millisecond)
+		}
+		return nil
+	}
+	var steps []string
+	took := make([]time.Duration, len(chosen))
+	for i, name := range named {
+		var path []maze.Cell
+		began := time.Now()
+		if es, ok := chosen[i].(*maze.ExecSolver); ok {
+			// Run says what went wrong, where FindPath would only have nil.
+			path, err = es.Run(g, start, finish)
+		} else if *format == "visits" {
+			// Shade the cells by how often all the solvers visited them.
+			var visits []int
+			path, visits, err = maze.CountVisits(chosen[i], g, start, finish)
+			if opts.Visits == nil {
+				opts.Visits = make([]int, len(visits))
+			}
+			for id, n := range visits {
+				opts.Visits[id] += n
+			}
+		} else {
+			path, err = maze.FindPath(chosen[i], g, start, finish)
+		}
+		took[i] = time.Since(began)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		steps = append(steps, fmt.Sprintf("%s: %d steps", name, len(path)-1))
+		if len(chosen) == 1 {
+			opts.Path = path
+		} else {
+			opts.Paths = append(opts.Paths, maze.LabeledPath{Label: steps[i], Cells: path})
+		}
+	}
+	if err := renderer.Render(g, os.Stdout, opts); err != nil {
+		return err
+	}
+	for i, s := range steps {
+		fmt.Fprintf(os.Stderr, "%s in %v\n", s, took[i].Round(time.Microsecond))
+	}
+	if g.HasOneWays() {
+		if back := g.WayBack(start, finish); back != nil {
+			fmt.Fprintf(os.Stderr, "way back from the finish: %d steps\n", len(back)-1)
+		} else {
+			fmt.Fprintln(os.Stderr, "no way back from the finish: the maze is one-way solvable only")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"strings"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+	"github.com/overthink/maze-go/internal/font"
+)
+
+// raceFrames is about how many frames a solver race is animated in, however
+// many cells the solvers visit.
+const raceFrames = 200
+
+// solverTrace is what a solver did in a race: the CellIds of the cells it
+// visited, in order, and the path it found.
+type solverTrace struct {
+	name   string
+	visits []int
+	path   []int
+}
+
+// traceSolvers runs each solver on a copy of g from start to finish,
+// recording the cells it visits with an Observer.
+func traceSolvers(g *maze.Grid, names []string, chosen []maze.Solver, start, finish maze.Cell) []solverTrace {
+	traces := make([]solverTrace, len(chosen))
+	for i, s := range chosen {
+		c := g.Clone()
+		t := &traces[i]
+		t.name = names[i]
+		c.Observer = &maze.Observer{Visit: func(v maze.Cell) { t.visits = append(t.visits, c.CellIdOf(v)) }}
+		t.path = c.CellIds(s.Solve(&c, start, finish))
+	}
+	return traces
+}
+
+// raceSteps returns the number of visits shown in each frame of a race: the
+// solvers all visit a cell a step, so the quicker ones finish first.
+func raceSteps(traces []solverTrace) []int {
+	longest := 0
+	for _, t := range traces {
+		if len(t.visits) > longest {
+			longest = len(t.visits)
+		}
+	}
+	stride := (longest + raceFrames - 1) / raceFrames
+	if stride < 1 {
+		stride = 1
+	}
+	var steps []int
+	for n := 0; n < longest; n += stride {
+		steps = append(steps, n)
+	}
+	return append(steps, longest)
+}
+
+// raceLabel is the caption of a solver's panel after n steps.
+func raceLabel(t solverTrace, n int) string {
+	if n < len(t.visits) {
+		return fmt.Sprintf("%s %d", t.name, n)
+	}
+	if t.path == nil {
+		return fmt.Sprintf("%s %d no path", t.name, len(t.visits))
+	}
+	return fmt.Sprintf("%s %d done", t.name, len(t.visits))
+}
+
+// playRace animates the race on a terminal: the solvers' mazes side by side,
+// each with the cells it has visited so far coloured in and, once it's
+// finished, its path drawn in, under a count of the cells visited.
+func playRace(w io.Writer, g *maze.Grid, traces []solverTrace, delay time.Duration) error {
+	// Panels are as wide as the maze or the longest label.
+	width := 2*g.ColCount + 1
+	for _, t := range traces {
+		if n := len(raceLabel(t, len(t.visits))); n > width {
+			width = n
+		}
+	}
+	pad := strings.Repeat(" ", width-(2*g.ColCount+1))
+	colors := maze.RenderOptions{}.RegionANSI()
+	var frame []byte
+	for _, n := range raceSteps(traces) {
+		frame = append(frame[:0], ansiClear...)
+		panels := make([][]string, len(traces))
+		for i, t := range traces {
+			panels[i] = racePanelText(g, t, n, colors[i%len(colors)])
+		}
+		for i, t := range traces {
+			if i > 0 {
+				frame = append(frame, "  "...)
+			}
+			label := raceLabel(t, n)
+			frame = append(frame, fmt.Sprintf("%-*s", width, label)...)
+		}
+		frame = append(frame, '\n')
+		for line := range panels[0] {
+			for i := range panels {
+				if i > 0 {
+					frame = append(frame, "  "...)
+				}
+				frame = append(frame, panels[i][line]...)
+				if i < len(panels)-1 {
+					frame = append(frame, pad...)
+				}
+			}
+			frame = append(frame, '\n')
+		}
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+		time.Sleep(delay)
+	}
+	return nil
+}
+
+// racePanelText returns the lines of one solver's maze after n steps, with
+// the cells it has visited in the ANSI colour ansi.
+func racePanelText(g *maze.Grid, t solverTrace, n int, ansi string) []string {
+	colors := make([]string, g.RowCount*g.ColCount)
+	for _, id := range t.visits[:min(n, len(t.visits))] {
+		colors[id] = ansi
+	}
+	labels := make([]rune, g.RowCount*g.ColCount)
+	if n >= len(t.visits) {
+		for _, id := range t.path {
+			labels[id] = '.'
+		}
+	}
+	buf := g.AppendTextTop(nil)
+	for row := 0; row < g.RowCount; row++ {
+		from, to := g.CellId(row, 0), g.CellId(row+1, 0)
+		buf = g.AppendTextRow(buf, row, labels[from:to], colors[from:to])
+	}
+	return strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+}
+
+// writeRaceGIF writes the race as an animated GIF, the solvers' mazes side
+// by side as in playRace, with each solver's visited cells in a light tint
+// of its colour and its path in the colour itself.
+func writeRaceGIF(w io.Writer, g *maze.Grid, traces []solverTrace, opts maze.RenderOptions) error {
+	cell := 400 / g.ColCount
+	if cell > 12 {
+		cell = 12
+	} else if cell < 3 {
+		cell = 3
+	}
+	const margin, label = 4, font.Height + 6
+	panelWidth := g.ColCount*cell + 1
+	for _, t := range traces {
+		if lw := len(raceLabel(t, len(t.visits)))*(font.Width+1) + 1; lw > panelWidth {
+			panelWidth = lw
+		}
+	}
+	width := margin + len(traces)*(panelWidth+margin)
+	height := margin + label + g.RowCount*cell + 1 + margin
+
+	// The palette: white, black, then a tint and a full colour per solver.
+	palette := color.Palette{color.White, color.Black}
+	for i := range traces {
+		c := opts.PathColor(i)
+		tint := func(v uint8) uint8 { return uint8(255 - (255-int(v))*2/5) }
+		palette = append(palette, color.RGBA{tint(c.R), tint(c.G), tint(c.B), 255}, c)
+	}
+
+	anim := &gif.GIF{}
+	steps := raceSteps(traces)
+	for s, n := range steps {
+		img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		for i, t := range traces {
+			left := margin + i*(panelWidth+margin)
+			top := margin + label
+			drawText(img, left, margin, strings.ToUpper(raceLabel(t, n)), 1)
+			fill := func(id int, index uint8) {
+				x, y := left+id%g.ColCount*cell, top+id/g.ColCount*cell
+				for dy := 1; dy < cell; dy++ {
+					for dx := 1; dx < cell; dx++ {
+						img.Pix[img.PixOffset(x+dx, y+dy)] = index
+					}
+				}
+			}
+			for _, id := range t.visits[:min(n, len(t.visits))] {
+				fill(id, uint8(2+2*i))
+			}
+			if n >= len(t.visits) {
+				for _, id := range t.path {
+					fill(id, uint8(3+2*i))
+				}
+			}
+			drawGIFWalls(img, g, left, top, cell)
+		}
+		delay := 4
+		if s == len(steps)-1 {
+			delay = 300
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// drawGIFWalls draws g's walls in black with its top left corner at (left,
+// top) and cells cell pixels apart.
+func drawGIFWalls(img *image.Paletted, g *maze.Grid, left, top, cell int) {
+	line := func(x0, y0, x1, y1 int) {
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				img.Pix[img.PixOffset(x, y)] = 1
+			}
+		}
+	}
+	line(left, top, left+g.ColCount*cell, top)
+	line(left, top, left, top+g.RowCount*cell)
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			x, y := left+col*cell, top+row*cell
+			if g.HasWall(maze.Cell{Row: row, Col: col}, maze.E) {
+				line(x+cell, y, x+cell, y+cell)
+			}
+			if g.HasWall(maze.Cell{Row: row, Col: col}, maze.S) {
+				line(x, y+cell, x+cell, y+cell)
+			}
+		}
+	}
+}
+
+// drawText draws text in the font with its top left corner at (x, y), in the
+// palette colour index, skipping any characters it doesn't have.
+func drawText(img *image.Paletted, x, y int, text string, index uint8) {
+	for _, r := range text {
+		for dy, pixels := range font.Glyphs[r] {
+			for dx, pixel := range pixels {
+				if pixel == '#' {
+					img.Pix[img.PixOffset(x+dx, y+dy)] = index
+				}
+			}
+		}
+		x += font.Width + 1
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshVersion is the version line the server sends.
+const sshVersion = "SSH-2.0-maze"
+
+// sshFingerprint returns the SHA-256 fingerprint of key's public half, as
+// ssh prints it when asking whether to trust a host.
+func sshFingerprint(key ed25519.PrivateKey) string {
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		// An ed25519 key always makes an SSH one.
+		panic(err)
+	}
+	return ssh.FingerprintSHA256(pub)
+}
+
+// sshSession is a shell session a client has opened over SSH.  Reading it
+// gets what they type, and writing it writes to their terminal, which is in
+// raw mode, so lines need to end "\r\n".
+type sshSession struct {
+	conn *ssh.ServerConn
+	ch   ssh.Channel
+	// User is the name they logged in as, Term their $TERM, and Rows and
+	// Cols the size of their terminal, or 0 if they didn't ask for one.
+	User       string
+	Term       string
+	Rows, Cols int
+
+	mu sync.Mutex // guards Rows and Cols once the shell's started
+	// done is closed once the client's hung up.
+	done chan struct{}
+}
+
+// sshPtyRequest and sshWindowChange are the payloads of the "pty-req" and
+// "window-change" channel requests (RFC 4254 sections 6.2 and 6.7).
+type sshPtyRequest struct {
+	Term          string
+	Cols, Rows    uint32
+	Width, Height uint32
+	Modes         string
+}
+
+type sshWindowChange struct {
+	Cols, Rows    uint32
+	Width, Height uint32
+}
+
+// sshAccept runs the server side of an SSH connection on conn up to the
+// client asking for a shell, logging it in whatever its name or password,
+// and returns the session.  The client's side is then handled in the
+// background until it or the session is closed.
+func sshAccept(conn net.Conn, hostKey ed25519.PrivateKey) (*sshSession, error) {
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ServerConfig{
+		// Anyone can play, under any name.
+		NoClientAuth:  true,
+		ServerVersion: sshVersion,
+	}
+	config.AddHostKey(signer)
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return nil, err
+	}
+	// Keepalives, port forwarding and the like are turned down.
+	go ssh.DiscardRequests(reqs)
+
+	// Wait for a session channel, and on it a shell.
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions")
+			continue
+		}
+		ch, requests, err := newChannel.Accept()
+		if err != nil {
+			sconn.Close()
+			return nil, err
+		}
+		go func() {
+			for newChannel := range chans {
+				newChannel.Reject(ssh.ResourceShortage, "only one session")
+			}
+		}()
+		s := &sshSession{conn: sconn, ch: ch, User: sconn.User(), done: make(chan struct{})}
+		for req := range requests {
+			ok := true
+			switch req.Type {
+			case "pty-req":
+				var pty sshPtyRequest
+				if ok = ssh.Unmarshal(req.Payload, &pty) == nil; ok {
+					s.Term, s.Cols, s.Rows = pty.Term, int(pty.Cols), int(pty.Rows)
+				}
+			case "window-change":
+				var size sshWindowChange
+				if ok = ssh.Unmarshal(req.Payload, &size) == nil; ok {
+					s.Cols, s.Rows = int(size.Cols), int(size.Rows)
+				}
+			case "env":
+			case "shell":
+			default:
+				// Commands and subsystems aren't on offer.
+				ok = false
+			}
+			req.Reply(ok, nil)
+			if req.Type == "shell" {
+				go s.serve(requests)
+				return s, nil
+			}
+		}
+		sconn.Close()
+		return nil, io.EOF
+	}
+	sconn.Close()
+	return nil, io.EOF
+}
+
+// serve handles the client's requests on the session once the shell's
+// started, until it hangs up, keeping track of the terminal size.
+func (s *sshSession) serve(requests <-chan *ssh.Request) {
+	for req := range requests {
+		if req.Type == "window-change" {
+			var size sshWindowChange
+			if ssh.Unmarshal(req.Payload, &size) == nil {
+				s.mu.Lock()
+				s.Cols, s.Rows = int(size.Cols), int(size.Rows)
+				s.mu.Unlock()
+			}
+		}
+		req.Reply(false, nil)
+	}
+	close(s.done)
+}
+
+// Read reads what the client types.
+func (s *sshSession) Read(p []byte) (int, error) {
+	return s.ch.Read(p)
+}
+
+// Write writes p to the client's terminal, waiting for the client to make
+// room if it's behind.
+func (s *sshSession) Write(p []byte) (int, error) {
+	return s.ch.Write(p)
+}
+
+// Size returns the size of the client's terminal, as it last said.
+func (s *sshSession) Size() (rows, cols int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Rows, s.Cols
+}
+
+// Close ends the session with the exit status status, as if the shell had
+// exited, and hangs up.
+func (s *sshSession) Close(status int) error {
+	_, err := s.ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+	if err == nil {
+		err = s.ch.CloseWrite()
+	}
+	if cerr := s.ch.Close(); err == nil {
+		err = cerr
+	}
+	// Give the client a moment to close its end too, so it doesn't see the
+	// connection cut off.
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+	}
+	// The client may well have hung up by now.
+	if cerr := s.conn.Close(); err == nil && !errors.Is(cerr, net.ErrClosed) {
+		err = cerr
+	}
+	return err
+}
+
+// crlfWriter writes to w with each "\n" made "\r\n", for a terminal in raw
+// mode.
+type crlfWriter struct{ w io.Writer }
+
+func (c crlfWriter) Write(p []byte) (int, error) {
+	if _, err := c.w.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TestSSHSession logs in to sshAccept with an SSH client, as a player
+// would, and checks the session sees their terminal and they see its
+// output and exit status.
+func TestSSHSession(t *testing.T) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Over a net.Pipe both ends would block sending their version lines.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan *sshSession)
+	go func() {
+		serverConn, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			accepted <- nil
+			return
+		}
+		s, err := sshAccept(serverConn, hostKey)
+		if err != nil {
+			t.Error(err)
+		}
+		accepted <- s
+	}()
+
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, chans, reqs, err := ssh.NewClientConn(clientConn, ln.Addr().String(), &ssh.ClientConfig{
+		User:            "player",
+		HostKeyCallback: ssh.FixedHostKey(signer.PublicKey()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ssh.NewClient(conn, chans, reqs)
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.RequestPty("xterm", 24, 80, nil); err != nil {
+		t.Fatal(err)
+	}
+	in, err := session.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := session.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Shell(); err != nil {
+		t.Fatal(err)
+	}
+	s := <-accepted
+	if s == nil {
+		t.FailNow()
+	}
+	if s.User != "player" || s.Term != "xterm" {
+		t.Errorf("user %q, term %q, want player and xterm", s.User, s.Term)
+	}
+	if rows, cols := s.Size(); rows != 24 || cols != 80 {
+		t.Errorf("size %dx%d, want 24x80", rows, cols)
+	}
+
+	if err := session.WindowChange(30, 100); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if rows, cols := s.Size(); rows == 30 && cols == 100 {
+			break
+		}
+		if time.Now().After(deadline) {
+			rows, cols := s.Size()
+			t.Fatalf("size %dx%d after a window change, want 30x100", rows, cols)
+		}
+	}
+
+	io.WriteString(in, "q")
+	typed := make([]byte, 1)
+	if _, err := io.ReadFull(s, typed); err != nil || string(typed) != "q" {
+		t.Errorf("read %q, %v, want q", typed, err)
+	}
+	go func() {
+		io.WriteString(crlfWriter{s}, "bye\n")
+		s.Close(3)
+	}()
+	got, err := io.ReadAll(out)
+	if err != nil || string(got) != "bye\r\n" {
+		t.Errorf("client got %q, %v, want %q", got, err, "bye\r\n")
+	}
+	var exit *ssh.ExitError
+	if err := session.Wait(); !errors.As(err, &exit) || exit.ExitStatus() != 3 {
+		t.Errorf("session ended with %v, want exit status 3", err)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	mathrand "math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// runSSHServer is the ssh-server command: it serves the play command's game
+// over SSH, so anyone can `ssh -p 2222 host` and play a fresh maze, each
+// session with its own seed, without installing anything.  Any name and
+// password log in, and scores aren't kept.  Players who don't press a key
+// for -idle are hung up on, and at most -max-sessions play at once.
+func runSSHServer(args []string) error {
+	fs := flag.NewFlagSet("ssh-server", flag.ExitOnError)
+	addr := fs.String("addr", ":2222", "address to listen on")
+	hostKeyPath := fs.String("host-key", "maze_host_key", "`file` the server's ed25519 host key is kept in, made if it doesn't exist")
+	seed := fs.Int64("seed", 0, "random seed the sessions' seeds are drawn from, to repeat them (0 picks one from the clock)")
+	idle := fs.Duration("idle", 5*time.Minute, "how long a player can go without pressing a key before they're hung up on")
+	maxSessions := fs.Int("max-sessions", 100, "how many sessions can play at once, or 0 for no limit")
+	var settings playSettings
+	settings.addFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze ssh-server [flags] [rows] [cols]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	rows, cols, err := parseSize(fs.Args())
+	if err != nil {
+		return err
+	}
+	if err := settings.check(fs, rows, cols); err != nil {
+		return err
+	}
+	if *idle <= 0 {
+		return fmt.Errorf("bad -idle %v", *idle)
+	}
+	if *maxSessions < 0 {
+		return fmt.Errorf("bad -max-sessions %d", *maxSessions)
+	}
+	// The game's flags, for the command to play a session's maze again,
+	// with the version of the generators whether it was set or not.
+	flags := fmt.Sprintf(" --generator-version=%d", settings.version)
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr", "host-key", "seed", "generator-version", "idle", "max-sessions":
+		default:
+			flags += fmt.Sprintf(" --%s=%s", f.Name, f.Value)
+		}
+	})
+	hostKey, err := loadHostKey(*hostKeyPath)
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := mathrand.New(mathrand.NewSource(*seed))
+	var mu sync.Mutex
+	nextSeed := func() int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return rng.Int63()
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	log.Printf("serving %dx%d mazes over SSH on %s, host key %s", rows, cols, ln.Addr(), sshFingerprint(hostKey))
+	var sessions chan struct{}
+	if *maxSessions > 0 {
+		sessions = make(chan struct{}, *maxSessions)
+	}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		if sessions != nil {
+			select {
+			case sessions <- struct{}{}:
+			default:
+				log.Printf("%s: turned away, %d sessions already playing", conn.RemoteAddr(), *maxSessions)
+				conn.Close()
+				continue
+			}
+		}
+		go func() {
+			if sessions != nil {
+				defer func() { <-sessions }()
+			}
+			if err := servePlaySSH(conn, hostKey, &settings, flags, nextSeed(), rows, cols, *idle); err != nil && !errors.Is(err, io.EOF) {
+				log.Printf("%s: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// sshHandshakeTimeout is how long a client has to log in and start a shell.
+const sshHandshakeTimeout = 30 * time.Second
+
+// servePlaySSH plays a game of a rows x cols maze made from seed with the
+// client on conn, then hangs up, telling them how to play it again with
+// the play command and flags, the settings' flags that were set.  The connection is
+// dropped once they've gone idle without pressing a key.
+func servePlaySSH(conn net.Conn, hostKey ed25519.PrivateKey, settings *playSettings, flags string, seed int64, rows, cols int, idle time.Duration) error {
+	conn.SetDeadline(time.Now().Add(sshHandshakeTimeout))
+	s, err := sshAccept(conn, hostKey)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(idle))
+	log.Printf("%s: %s playing seed %d", conn.RemoteAddr(), s.User, seed)
+	out := crlfWriter{s}
+	termRows, termCols := s.Size()
+	if termRows == 0 || termCols == 0 {
+		termRows, termCols = 24, 80
+	}
+	if !settings.firstPerson && (rows+2 > termRows || 2*cols+1 > termCols) {
+		fmt.Fprintf(out, "a %dx%d maze doesn't fit in a %dx%d terminal\n", rows, cols, termRows, termCols)
+		return s.Close(1)
+	}
+	g, fewest, err := settings.newGame(seed, rows, cols, termRows, termCols)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		s.Close(1)
+		return err
+	}
+	io.WriteString(out, ansiHide)
+	started := time.Now()
+	quit, err := g.run(idleReader{s, conn, idle}, out, settings.speed)
+	io.WriteString(out, ansiShow)
+	if g.fp != nil {
+		// The first person view leaves the cursor at the end of its status
+		// line.
+		io.WriteString(out, "\n")
+	}
+	switch {
+	case err != nil:
+		s.Close(1)
+		return err
+	case quit:
+	case g.caught():
+		fmt.Fprintln(out, "caught!")
+	default:
+		efficiency := 1.0
+		if g.moves > 0 {
+			efficiency = float64(fewest) / float64(g.moves)
+		}
+		fmt.Fprintf(out, "finished in %.1fs with %d moves (%.0f%% efficient)\n", time.Since(started).Seconds(), g.moves, 100*efficiency)
+	}
+	fmt.Fprintf(out, "that was seed %d: play it again with maze play%s --seed %d %d %d\n", seed, flags, seed, rows, cols)
+	return s.Close(0)
+}
+
+// idleReader reads r, pushing conn's deadline back to idle from now each
+// time something's read, so a connection is only dropped once nothing's
+// been typed on it for that long.
+type idleReader struct {
+	r    io.Reader
+	conn net.Conn
+	idle time.Duration
+}
+
+func (r idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.conn.SetDeadline(time.Now().Add(r.idle))
+	}
+	return n, err
+}
+
+// loadHostKey reads the ed25519 private key saved in PKCS #8 PEM at path,
+// making one and saving it there if there's no file yet, so clients see
+// the same host key every time.
+func loadHostKey(path string) (ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: not a PEM private key", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	ed, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 key", path)
+	}
+	return ed, nil
+}
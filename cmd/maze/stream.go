@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// checkpointCells is about how many cells --stream generates between
+// checkpoints.
+const checkpointCells = 1 << 24
+
+// streamCheckpointed is --stream --checkpoint: it streams the maze to
+// stdout, saving a checkpoint to path as it goes, or carries on from the
+// checkpoint already there.  When carrying on into a file, stdout is cut
+// back to what had been written at the checkpoint, so nothing is written
+// twice; it needs opening with >> so the shell doesn't empty it first.
+// Interrupting it saves a checkpoint before stopping, to pause it.
+func streamCheckpointed(ctx context.Context, path string, seed int64, rows, cols int) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	c := maze.NewEllerCheckpoint(seed, rows, cols)
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, c); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if c.Rows != rows || c.Cols != cols {
+			return fmt.Errorf("%s is for a %dx%d maze, not %dx%d", path, c.Rows, c.Cols, rows, cols)
+		}
+		if info, err := os.Stdout.Stat(); err == nil && info.Mode().IsRegular() {
+			if info.Size() < c.Written {
+				return fmt.Errorf("stdout has %d bytes but %s expects %d; append to the output with >>", info.Size(), path, c.Written)
+			}
+			if err := os.Stdout.Truncate(c.Written); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stderr, "carrying on from row %d of %d, seed %d\n", c.Row, c.Rows, c.Seed)
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	every := checkpointCells / cols
+	if every < 1 {
+		every = 1
+	}
+	err = maze.StreamEllerFrom(ctx, os.Stdout, c, every, func(c *maze.EllerCheckpoint) error {
+		b, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		// Write a new file and rename it over the old one, so a crash while
+		// saving leaves the previous checkpoint whole.
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, append(b, '\n'), 0666); err != nil {
+			return err
+		}
+		return os.Rename(tmp, path)
+	})
+	if err == nil {
+		return os.Remove(path)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("stopped at row %d of %d; run the same command, appending with >>, to carry on", c.Row, c.Rows)
+	}
+	return err
+}
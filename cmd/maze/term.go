@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Keys returned by readKey for the arrow keys.  Everything else is returned
+// as the byte that was read.
+const (
+	keyUp = 0x100 + iota
+	keyDown
+	keyRight
+	keyLeft
+)
+
+// ANSI sequences for drawing full-screen terminal UIs.
+const (
+	ansiClear   = "\x1b[H\x1b[2J"
+	ansiReverse = "\x1b[7m"
+	ansiHide    = "\x1b[?25l"
+	ansiShow    = "\x1b[?25h"
+	ansiReset   = "\x1b[0m"
+)
+
+// enterCbreak switches the terminal on stdin to reading a key at a time
+// without echo, using stty, and returns a function that puts it back.
+func enterCbreak() (restore func(), err error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	os.Stdout.WriteString(ansiHide)
+	return func() {
+		os.Stdout.WriteString(ansiShow)
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// readKey reads one keypress, translating arrow key escape sequences.
+func readKey(r *bufio.Reader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0x1b || r.Buffered() < 2 {
+		return int(b), nil
+	}
+	if next, _ := r.Peek(1); next[0] != '[' {
+		return int(b), nil
+	}
+	r.ReadByte()
+	code, _ := r.ReadByte()
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	}
+	return int(b), nil
+}
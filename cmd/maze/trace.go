@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// traceCarves sets g's Observer, keeping any callbacks it already has, to
+// write each wall the generator removes to w as a line of JSON, a
+// TraceStep.  The returned function flushes what's left, and returns the
+// first error writing it, once the maze is done.
+func traceCarves(g *maze.Grid, w io.Writer) func() error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	step := maze.TraceStep{Phase: "carve"}
+	var err error
+	if g.Observer == nil {
+		g.Observer = &maze.Observer{}
+	}
+	carve, event := g.Observer.Carve, g.Observer.Event
+	g.Observer.Carve = func(c maze.Cell, d maze.Direction) {
+		step.Row, step.Col, step.Dir = c.Row, c.Col, d.String()
+		if err == nil {
+			err = enc.Encode(step)
+		}
+		step.Step++
+		if carve != nil {
+			carve(c, d)
+		}
+	}
+	g.Observer.Event = func(name string) {
+		if name == "clear" {
+			step.Attempt++
+			step.Phase = "carve"
+		} else {
+			step.Phase = name
+		}
+		if event != nil {
+			event(name)
+		}
+	}
+	return func() error {
+		if err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	maze "github.com/overthink/maze-go"
+)
+
+// uniformityMaxCells is the biggest grid the uniformity command tests: a
+// 4x4 grid already has 100,352 spanning trees, and each needs to come up
+// a few times for the test to mean anything.
+const uniformityMaxCells = 16
+
+// runUniformity is the uniformity command: it generates lots of small mazes
+// with each algorithm, counts how often each possible maze (each spanning
+// tree of the grid) comes up, and runs a chi-square test of the counts
+// against every maze being equally likely.  Wilson's algorithm passes; the
+// backtracker, Kruskal's and the rest are measurably biased.
+func runUniformity(args []string) error {
+	fs := flag.NewFlagSet("uniformity", flag.ExitOnError)
+	names := fs.String("algorithm", strings.Join(maze.GeneratorNames(), ","), "algorithms to test, separated by commas")
+	rows := fs.Int("rows", 3, "grid height")
+	cols := fs.Int("cols", 3, "grid width")
+	samples := fs.Int("samples", 0, "mazes to generate with each algorithm (default 50 per spanning tree)")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "random seed")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze uniformity [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *rows < 1 || *cols < 1 || *rows**cols > uniformityMaxCells {
+		return fmt.Errorf("grid must have 1 to %d cells, not %dx%d", uniformityMaxCells, *rows, *cols)
+	}
+	var chosen []maze.Generator
+	for _, name := range strings.Split(*names, ",") {
+		gen, ok := maze.LookupGenerator(name)
+		if !ok {
+			return fmt.Errorf("unknown algorithm %q; have %s", name, strings.Join(maze.GeneratorNames(), ", "))
+		}
+		chosen = append(chosen, gen)
+	}
+	trees := spanningTrees(*rows, *cols)
+	if *samples <= 0 {
+		*samples = 50 * int(trees)
+	}
+	if float64(*samples) < 5*trees {
+		fmt.Fprintf(os.Stderr, "warning: %d samples is under 5 per spanning tree, so the test isn't reliable\n", *samples)
+	}
+	fmt.Printf("%dx%d grid: %.0f spanning trees, %d samples each, seed %d\n", *rows, *cols, trees, *samples, *seed)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "algorithm\tseen\tmost\tleast\tchi-square\tp\t\t")
+	for i, name := range strings.Split(*names, ",") {
+		counts, err := countTrees(chosen[i], *rows, *cols, *samples, rand.New(rand.NewSource(*seed)))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		most, least := 0, *samples
+		for _, n := range counts {
+			most, least = max(most, n), min(least, n)
+		}
+		if float64(len(counts)) < trees {
+			least = 0
+		}
+		chi, p := chiSquareTest(counts, *samples, trees)
+		verdict := "uniform"
+		if p < 0.01 {
+			verdict = "biased"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f\t%.3g\t%s\t\n", name, len(counts), most, least, chi, p, verdict)
+	}
+	return w.Flush()
+}
+
+// chiSquareTest returns the chi-square statistic of counts, samples mazes
+// spread over trees possible ones, against every maze being equally likely,
+// and the probability of one at least that big if they are.
+func chiSquareTest(counts map[string]int, samples int, trees float64) (chi, p float64) {
+	expected := float64(samples) / trees
+	for _, n := range counts {
+		chi += (float64(n) - expected) * (float64(n) - expected) / expected
+	}
+	// The trees that never came up count too.
+	chi += (trees - float64(len(counts))) * expected
+	return chi, chiSquareP(chi, trees-1)
+}
+
+// countTrees generates samples rows x cols mazes with gen and returns how
+// many times each came up, keyed by its Fingerprint.
+func countTrees(gen maze.Generator, rows, cols, samples int, rng *rand.Rand) (map[string]int, error) {
+	counts := map[string]int{}
+	for i := 0; i < samples; i++ {
+		g, err := maze.NewGrid(rows, cols)
+		if err != nil {
+			return nil, err
+		}
+		if err := maze.Generate(context.Background(), gen, &g, rng, maze.NoBias); err != nil {
+			return nil, err
+		}
+		links := 0
+		for range g.Links() {
+			links++
+		}
+		// A perfect maze is a spanning tree: it links every cell with one
+		// fewer passages than cells, and no loops.
+		dist := g.Distances(maze.Cell{Row: 0, Col: 0})
+		if links != rows*cols-1 || slices.Contains(dist, -1) {
+			return nil, errors.New("made a maze that isn't a spanning tree")
+		}
+		counts[g.Fingerprint()]++
+	}
+	return counts, nil
+}
+
+// spanningTrees returns the number of spanning trees of a rows x cols grid
+// (the number of different perfect mazes that fit it) by Kirchhoff's
+// theorem: the determinant of its Laplacian matrix with a row and column
+// taken out.
+func spanningTrees(rows, cols int) float64 {
+	g, _ := maze.NewGrid(rows, cols)
+	n := rows*cols - 1
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+	}
+	var adjacent []int
+	for a := 1; a <= n; a++ {
+		adjacent = g.Adjacent(a, adjacent[:0])
+		m[a-1][a-1] = float64(len(adjacent))
+		for _, b := range adjacent {
+			if b > 0 {
+				m[a-1][b-1] = -1
+			}
+		}
+	}
+	// Gaussian elimination, which needs no pivoting: what's left of the
+	// Laplacian is positive definite.
+	det := 1.0
+	for i := 0; i < n; i++ {
+		det *= m[i][i]
+		for j := i + 1; j < n; j++ {
+			f := m[j][i] / m[i][i]
+			for k := i; k < n; k++ {
+				m[j][k] -= f * m[i][k]
+			}
+		}
+	}
+	return math.Round(det)
+}
+
+// chiSquareP returns the probability of a chi-square statistic of at least
+// x with df degrees of freedom, by the Wilson-Hilferty approximation, which
+// is close for all but the smallest grids.
+func chiSquareP(x, df float64) float64 {
+	if df <= 0 {
+		return 1
+	}
+	v := 2 / (9 * df)
+	z := (math.Cbrt(x/df) - (1 - v)) / math.Sqrt(v)
+	return math.Erfc(z/math.Sqrt2) / 2
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	maze "github.com/overthink/maze-go"
+)
+
+func TestSpanningTrees(t *testing.T) {
+	for _, tc := range []struct {
+		rows, cols int
+		want       float64
+	}{
+		{1, 1, 1},
+		{1, 5, 1},
+		{2, 2, 4},
+		{2, 3, 15},
+		{3, 3, 192},
+		{4, 4, 100352},
+	} {
+		if got := spanningTrees(tc.rows, tc.cols); got != tc.want {
+			t.Errorf("spanningTrees(%d, %d) = %v, want %v", tc.rows, tc.cols, got, tc.want)
+		}
+	}
+}
+
+// TestUniformity runs the uniformity command's chi-square test on a 2x3
+// grid with a fixed seed: Wilson's algorithm has to pass it and the
+// backtracker, which favours long corridors, has to fail it.
+func TestUniformity(t *testing.T) {
+	const rows, cols, seed = 2, 3, 1
+	trees := spanningTrees(rows, cols)
+	samples := 200 * int(trees)
+	for _, tc := range []struct {
+		algorithm string
+		uniform   bool
+	}{
+		{"wilson", true},
+		{"rec", false},
+	} {
+		gen, _ := maze.LookupGenerator(tc.algorithm)
+		counts, err := countTrees(gen, rows, cols, samples, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.algorithm, err)
+		}
+		chi, p := chiSquareTest(counts, samples, trees)
+		if uniform := p >= 0.01; uniform != tc.uniform {
+			t.Errorf("%s: chi-square %.1f, p %.3g, uniform %v, want %v", tc.algorithm, chi, p, uniform, tc.uniform)
+		}
+	}
+}
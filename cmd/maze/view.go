@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	maze "github.com/overthink/maze-go"
+)
+
+const viewHelp = "arrows/hjkl pan  +/- zoom  g go to  s solution  q quit"
+
+// viewZoom is a zoom level of the view command: how many cells wide and
+// tall each character is, and what draws them.
+type viewZoom struct {
+	name                 string
+	cellsWide, cellsTall float64
+	opts                 func(maze.RenderOptions) (maze.Renderer, maze.RenderOptions)
+}
+
+// viewZooms are the view command's zoom levels, closest first: the text
+// format, braille, then shaded previews of bigger and bigger blocks.
+var viewZooms = []viewZoom{
+	{"text", 0.5, 1, func(opts maze.RenderOptions) (maze.Renderer, maze.RenderOptions) { return lookupRenderer("text"), opts }},
+	{"braille", 1, 2, func(opts maze.RenderOptions) (maze.Renderer, maze.RenderOptions) {
+		return lookupRenderer("braille"), opts
+	}},
+}
+
+// lookupRenderer returns the built in renderer for format.
+func lookupRenderer(format string) maze.Renderer {
+	r, _ := maze.LookupRenderer(format)
+	return r
+}
+
+func init() {
+	for block := 4; block <= 64; block *= 2 {
+		block := block
+		viewZooms = append(viewZooms, viewZoom{fmt.Sprintf("1:%d", block), float64(block), float64(block),
+			func(opts maze.RenderOptions) (maze.Renderer, maze.RenderOptions) {
+				opts.Preview = block
+				return lookupRenderer("text"), opts
+			}})
+	}
+}
+
+// viewer is the state of the view command: the maze, the cell at the
+// middle of the screen and the zoom level.
+type viewer struct {
+	grid         *maze.Grid
+	solution     []maze.Cell
+	showSolution bool
+	row, col     int
+	zoom         int
+	// rows and cols are the size of the screen.
+	rows, cols int
+	// jump is what's been typed after g, the row and column to go to.
+	jump    *string
+	message string
+}
+
+// runView is the view command: it opens the maze in the file named by args,
+// in any form ParseMaze reads, in a full-screen viewer that pans and zooms
+// around mazes too big to see at once.
+func runView(args []string) error {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: maze view file")
+		fmt.Fprintln(fs.Output(), viewHelp)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	b, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	g, err := maze.ParseMaze(b)
+	if err != nil {
+		return err
+	}
+	rows, cols, err := terminalSize()
+	if err != nil || rows < 3 || cols < 3 {
+		rows, cols = 24, 80
+	}
+	v := &viewer{grid: g, solution: g.Solve(g.Endpoints()), rows: rows, cols: cols}
+	// Start on the top left corner, as close in as fits the whole maze if
+	// any level does.
+	for v.zoom < len(viewZooms)-1 && !v.fits() {
+		v.zoom++
+	}
+	if !v.fits() {
+		v.zoom = 0
+	}
+	v.row, v.col = v.viewRows()/2, v.viewCols()/2
+
+	restore, err := enterCbreak()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	in := bufio.NewReader(os.Stdin)
+	for {
+		v.draw(os.Stdout)
+		key, err := readKey(in)
+		if err != nil {
+			return err
+		}
+		if !v.handle(key) {
+			return nil
+		}
+	}
+}
+
+// viewRows and viewCols return how many cells fit on the screen at the
+// current zoom, leaving a line for the status and the walls.
+func (v *viewer) viewRows() int {
+	return max(1, int(float64(v.rows-2)*viewZooms[v.zoom].cellsTall))
+}
+
+func (v *viewer) viewCols() int {
+	return max(1, int(float64(v.cols-1)*viewZooms[v.zoom].cellsWide))
+}
+
+// fits reports whether the whole maze fits on the screen at the current
+// zoom.
+func (v *viewer) fits() bool {
+	return v.grid.RowCount <= v.viewRows() && v.grid.ColCount <= v.viewCols()
+}
+
+// handle acts on a keypress, returning false when it's time to quit.
+func (v *viewer) handle(key int) bool {
+	v.message = ""
+	if v.jump != nil {
+		switch {
+		case key == '\n' || key == '\r':
+			c, err := parseCell(*v.jump)
+			if err == nil && !v.grid.Contains(c) {
+				err = fmt.Errorf("%v is outside the maze", c)
+			}
+			if err != nil {
+				v.message = err.Error()
+			} else {
+				v.row, v.col = c.Row, c.Col
+			}
+			v.jump = nil
+		case key == 0x1b:
+			v.jump = nil
+		case key == 0x7f || key == '\b':
+			if len(*v.jump) > 0 {
+				*v.jump = (*v.jump)[:len(*v.jump)-1]
+			}
+		case key < 0x100:
+			*v.jump += string(rune(key))
+		}
+		return true
+	}
+	// Arrows pan a quarter of the screen at a time.
+	rowStep, colStep := max(1, v.viewRows()/4), max(1, v.viewCols()/4)
+	switch key {
+	case keyUp, 'k':
+		v.row -= rowStep
+	case keyDown, 'j':
+		v.row += rowStep
+	case keyLeft, 'h':
+		v.col -= colStep
+	case keyRight, 'l':
+		v.col += colStep
+	case '+', '=':
+		v.zoom = max(0, v.zoom-1)
+	case '-', '_':
+		v.zoom = min(len(viewZooms)-1, v.zoom+1)
+	case 's':
+		v.showSolution = !v.showSolution
+		if v.showSolution && v.zoom > 0 {
+			v.message = "the solution only shows zoomed all the way in"
+		}
+	case 'g':
+		v.jump = new(string)
+	default:
+		return key != 'q' && key != 3 // 3 is ctrl-c
+	}
+	v.row = min(max(v.row, 0), v.grid.RowCount-1)
+	v.col = min(max(v.col, 0), v.grid.ColCount-1)
+	return true
+}
+
+// draw redraws the screen: the part of the maze around (row, col) that
+// fits, and a status line.
+func (v *viewer) draw(w io.Writer) {
+	rows, cols := v.viewRows(), v.viewCols()
+	r0 := min(max(0, v.row-rows/2), max(0, v.grid.RowCount-rows))
+	c0 := min(max(0, v.col-cols/2), max(0, v.grid.ColCount-cols))
+	r1, c1 := min(v.grid.RowCount, r0+rows), min(v.grid.ColCount, c0+cols)
+
+	var opts maze.RenderOptions
+	if v.showSolution {
+		opts.Path = v.solution
+	}
+	var out bytes.Buffer
+	out.WriteString(ansiClear)
+	sub, opts, err := v.grid.Viewport(opts, maze.Cell{Row: r0, Col: c0}, maze.Cell{Row: r1, Col: c1})
+	if err == nil {
+		var renderer maze.Renderer
+		renderer, opts = viewZooms[v.zoom].opts(opts)
+		err = renderer.Render(&sub, &out, opts)
+	}
+	if err != nil {
+		v.message = err.Error()
+	}
+	status := fmt.Sprintf("rows %d-%d cols %d-%d of %dx%d  zoom %s  %s",
+		r0, r1-1, c0, c1-1, v.grid.RowCount, v.grid.ColCount, viewZooms[v.zoom].name, viewHelp)
+	switch {
+	case v.jump != nil:
+		status = "go to row,col: " + *v.jump
+	case v.message != "":
+		status = v.message
+	}
+	if len(status) > v.cols {
+		status = status[:v.cols]
+	}
+	out.WriteString(strings.TrimRight(status, " "))
+	w.Write(out.Bytes())
+}
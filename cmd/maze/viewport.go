@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseViewport parses a viewport given as "rowStart,colStart,rowEnd,colEnd"
+// and checks it fits in a rows x cols grid.
+func parseViewport(s string, rows, cols int) ([4]int, error) {
+	var v [4]int
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return v, fmt.Errorf("bad viewport %q, want rowStart,colStart,rowEnd,colEnd", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return v, err
+		}
+		v[i] = n
+	}
+	if v[0] < 0 || v[1] < 0 || v[2] > rows || v[3] > cols || v[0] >= v[2] || v[1] >= v[3] {
+		return v, fmt.Errorf("viewport %q is empty or outside the %dx%d grid", s, rows, cols)
+	}
+	return v, nil
+}
@@ -0,0 +1,181 @@
+package maze
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// CoinKey is the metadata key marking a cell with a coin to collect, as
+// PlaceCoins puts them.
+const CoinKey = "coin"
+
+// coinDeadEnd is how many times likelier PlaceCoins is to put a coin in a
+// dead end than in any other cell, and MaxCoins how many coins
+// CollectRoute can find the best way round.
+const (
+	coinDeadEnd = 4
+	MaxCoins    = 16
+)
+
+// PlaceCoins scatters n coins over the maze, marking their cells with
+// CoinKey, and returns their cells in order.  No coin goes on the start or
+// the finish, and dead ends, the cells worth the trip to check, are
+// coinDeadEnd times as likely to get one as the rest.
+func (g *Grid) PlaceCoins(rng *rand.Rand, n int) ([]Cell, error) {
+	coins, err := g.placeCoins(rng, n)
+	return g.cells(coins), err
+}
+
+// placeCoins is PlaceCoins with the coins' cells as CellIds.
+func (g *Grid) placeCoins(rng *rand.Rand, n int) ([]int, error) {
+	start, end := g.endpoints()
+	if n > MaxCoins || n > len(g.data)-2 {
+		return nil, fmt.Errorf("can't place %d coins in a %dx%d maze, at most %d", n, g.RowCount, g.ColCount, min(MaxCoins, len(g.data)-2))
+	}
+	// A weighted sample without replacement: each cell gets a key of a
+	// uniform random number to the power of one over its weight, and the n
+	// highest keys win.
+	type candidate struct {
+		id  int
+		key float64
+	}
+	var candidates []candidate
+	deadEnd := make([]bool, len(g.data))
+	for c := range g.DeadEnds() {
+		deadEnd[g.CellId(c.Row, c.Col)] = true
+	}
+	for id := range g.data {
+		if id == start || id == end {
+			continue
+		}
+		weight := 1.0
+		if deadEnd[id] {
+			weight = coinDeadEnd
+		}
+		candidates = append(candidates, candidate{id, math.Pow(rng.Float64(), 1/weight)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].key > candidates[j].key })
+	coins := make([]int, n)
+	for i := range coins {
+		coins[i] = candidates[i].id
+	}
+	sort.Ints(coins)
+	for _, id := range coins {
+		g.SetMeta(g.CellOf(id), CoinKey, "1")
+	}
+	return coins, nil
+}
+
+// Coins returns the cells marked with CoinKey, in order.
+func (g *Grid) Coins() []Cell {
+	var coins []int
+	for id, values := range g.meta {
+		if _, ok := values[CoinKey]; ok {
+			coins = append(coins, id)
+		}
+	}
+	sort.Ints(coins)
+	return g.cells(coins)
+}
+
+// CollectRoute returns the shortest walk from start that picks up every one
+// of coins and then goes to finish, to score a game against.  The order to
+// collect them in is found exactly, with the Held-Karp dynamic program over
+// the distances between them, so there can be at most MaxCoins.  It returns
+// an error if a coin or the finish can't be reached.
+func (g *Grid) CollectRoute(start, finish Cell, coins []Cell) ([]Cell, error) {
+	route, err := g.collectRoute(g.CellIdOf(start), g.CellIdOf(finish), g.CellIds(coins))
+	return g.PathCells(route), err
+}
+
+// collectRoute is CollectRoute with the cells as CellIds.
+func (g *Grid) collectRoute(start, finish int, coins []int) ([]int, error) {
+	if len(coins) > MaxCoins {
+		return nil, fmt.Errorf("%d coins is too many to find the best way round, at most %d", len(coins), MaxCoins)
+	}
+	// A search from the start and from each coin gives the distances
+	// between all of them and to the finish, and the paths.
+	from := append([]int{start}, coins...)
+	dists := make([][]int, len(from))
+	parents := make([][]int, len(from))
+	for i, id := range from {
+		dists[i], parents[i] = g.bfsFrom([]int{id})
+		if dists[i][finish] < 0 {
+			return nil, fmt.Errorf("there's no way from cell %d to the finish", id)
+		}
+	}
+	// best[set][i] is the shortest walk from the start collecting the coins
+	// in set, a bitmask, ending on coin i, which is in it, and prev[set][i]
+	// the coin before i on it, or -1 for the start.
+	k := len(coins)
+	best := make([][]int, 1<<k)
+	prev := make([][]int, 1<<k)
+	for set := range best {
+		best[set] = make([]int, k)
+		prev[set] = make([]int, k)
+		for i := range best[set] {
+			best[set][i] = math.MaxInt
+		}
+	}
+	// Distances of -1, one-way passages leaving no way there, are skipped.
+	for i := 0; i < k; i++ {
+		if dists[0][coins[i]] >= 0 {
+			best[1<<i][i], prev[1<<i][i] = dists[0][coins[i]], -1
+		}
+	}
+	for set := 1; set < 1<<k; set++ {
+		for i := 0; i < k; i++ {
+			if set&(1<<i) == 0 || best[set][i] == math.MaxInt {
+				continue
+			}
+			for j := 0; j < k; j++ {
+				if set&(1<<j) != 0 || dists[i+1][coins[j]] < 0 {
+					continue
+				}
+				next := set | 1<<j
+				if d := best[set][i] + dists[i+1][coins[j]]; d < best[next][j] {
+					best[next][j], prev[next][j] = d, i
+				}
+			}
+		}
+	}
+
+	// Walk the stops back from the best last coin, then join up the legs
+	// between them.
+	stops := []int{-1}
+	if k > 0 {
+		last, full := 0, 1<<k-1
+		total := func(i int) int {
+			if best[full][i] == math.MaxInt {
+				return math.MaxInt
+			}
+			return best[full][i] + dists[i+1][finish]
+		}
+		for i := 1; i < k; i++ {
+			if total(i) < total(last) {
+				last = i
+			}
+		}
+		if best[full][last] == math.MaxInt {
+			return nil, fmt.Errorf("there's no way to collect all %d coins", k)
+		}
+		var order []int
+		for set, i := full, last; i >= 0; set, i = set&^(1<<i), prev[set][i] {
+			order = append(order, i)
+		}
+		for i := len(order) - 1; i >= 0; i-- {
+			stops = append(stops, order[i])
+		}
+	}
+	route := []int{start}
+	for s := range stops {
+		to := finish
+		if s+1 < len(stops) {
+			to = coins[stops[s+1]]
+		}
+		route = append(route, walkBack(parents[stops[s]+1], to)[1:]...)
+	}
+	return route, nil
+}
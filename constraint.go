@@ -0,0 +1,76 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// maxAttempts is how many mazes the constrained generators try before giving
+// up on the constraint.
+const maxAttempts = 1000
+
+// MazifyMinSolution runs gen on g, starting over until the solution from
+// the top left corner to the bottom right is at least minLength cells long.
+func MazifyMinSolution(ctx context.Context, g *Grid, rng *rand.Rand, bias float64,
+	gen Generator, minLength int) error {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		g.Clear()
+		if err := Generate(ctx, gen, g, rng, bias); err != nil {
+			return err
+		}
+		if len(g.Solve(Cell{0, 0}, Cell{g.RowCount - 1, g.ColCount - 1})) >= minLength {
+			return nil
+		}
+	}
+	return fmt.Errorf("no solution of %d or more cells in %d attempts", minLength, maxAttempts)
+}
+
+// objectives maps the name used with --objective to the score --search
+// maximises.
+var objectives = map[string]func(g *Grid) int{
+	"difficulty": (*Grid).Difficulty,
+	"length": func(g *Grid) int {
+		start, end := g.endpoints()
+		return len(g.solveNearest([]int{start}, []int{end}))
+	},
+}
+
+// LookupObjective returns the score the objective name maximises.
+func LookupObjective(name string) (func(g *Grid) int, bool) {
+	score, ok := objectives[name]
+	return score, ok
+}
+
+// ObjectiveNames returns the --objective names, sorted.
+func ObjectiveNames() []string {
+	var names []string
+	for name := range objectives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SearchSeeds generates mazes on g with gen, each from a new seed drawn from
+// rng, until ctx is done, and returns the seed of the one scoring highest
+// (rand.New(rand.NewSource(seed)) makes it again), its score and how many
+// mazes were tried.  At least one maze is always tried.
+func SearchSeeds(ctx context.Context, g *Grid, rng *rand.Rand, bias float64,
+	gen Generator, score func(g *Grid) int) (seed int64, best, tried int, err error) {
+	best = -1
+	for ; tried == 0 || ctx.Err() == nil; tried++ {
+		s := rng.Int63()
+		g.Clear()
+		// Only the search is time-limited, not each maze.
+		if err := Generate(context.Background(), gen, g, rand.New(rand.NewSource(s)), bias); err != nil {
+			return 0, 0, tried, err
+		}
+		if n := score(g); n > best {
+			seed, best = s, n
+		}
+	}
+	g.Clear()
+	return seed, best, tried, nil
+}
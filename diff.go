@@ -0,0 +1,41 @@
+package maze
+
+import "fmt"
+
+// Wall is the wall on the Side of its Cell.
+type Wall struct {
+	Cell
+	Side Direction
+}
+
+// String returns the wall as "side wall of row,col".
+func (w Wall) String() string {
+	return fmt.Sprintf("%s wall of %v", w.Side, w.Cell)
+}
+
+// DiffWalls returns the walls between cells that a has and b doesn't, and
+// those b has and a doesn't.  Each wall is given once, as the E or S side of
+// the cell to its west or north.  The mazes must be the same size.
+func DiffWalls(a, b *Grid) (onlyA, onlyB []Wall, err error) {
+	if a.RowCount != b.RowCount || a.ColCount != b.ColCount {
+		return nil, nil, fmt.Errorf("can't compare a %dx%d maze with a %dx%d one",
+			a.RowCount, a.ColCount, b.RowCount, b.ColCount)
+	}
+	for row := 0; row < a.RowCount; row++ {
+		for col := 0; col < a.ColCount; col++ {
+			for _, d := range []Direction{E, S} {
+				if !a.inside(row+rowOffset[d], col+colOffset[d]) {
+					continue
+				}
+				inA, inB := a.HasWall(Cell{row, col}, d), b.HasWall(Cell{row, col}, d)
+				switch {
+				case inA && !inB:
+					onlyA = append(onlyA, Wall{Cell{row, col}, d})
+				case inB && !inA:
+					onlyB = append(onlyB, Wall{Cell{row, col}, d})
+				}
+			}
+		}
+	}
+	return onlyA, onlyB, nil
+}
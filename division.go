@@ -0,0 +1,124 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+)
+
+// roomSize is the most rows and columns a region can have and still be left
+// open as a room by DivisionStepper.
+const roomSize = 6
+
+// region is the rectangle of cells rows x cols with (row, col) at the top
+// left.
+type region struct {
+	row, col, rows, cols int
+}
+
+// DivisionStepper is recursive division as a Stepper.  Recursive division
+// usually starts from an empty grid and adds walls, splitting it in two with
+// a wall with one door in it and then splitting each half the same way; this
+// does the same by carving the doors and then the corridors the splitting
+// bottoms out in, so it can report what it carves like the other
+// algorithms.
+//
+// With a room probability above 0, each region no bigger than roomSize on a
+// side is, with that probability, left open as a room instead of being split
+// further, for a maze like a building's floor plan.  Rooms have no walls
+// inside, so a maze with any isn't perfect.
+type DivisionStepper struct {
+	g       *Grid
+	rng     *rand.Rand
+	bias    float64
+	rooms   float64
+	regions []region
+	carves  []edge // decided on but not carved yet
+	last    edge
+}
+
+// NewDivisionStepper returns a Stepper that carves g with recursive
+// division, splitting it with walls running east-west more often the closer
+// bias is to 1 and leaving small regions open as rooms with probability
+// rooms.
+func NewDivisionStepper(g *Grid, rng *rand.Rand, bias, rooms float64) *DivisionStepper {
+	return &DivisionStepper{
+		g:       g,
+		rng:     rng,
+		bias:    bias,
+		rooms:   rooms,
+		regions: []region{{0, 0, g.RowCount, g.ColCount}},
+	}
+}
+
+func (s *DivisionStepper) Step() bool {
+	for len(s.carves) == 0 {
+		if len(s.regions) == 0 {
+			return false
+		}
+		r := s.regions[len(s.regions)-1]
+		s.regions = s.regions[:len(s.regions)-1]
+		s.divide(r)
+	}
+	e := s.carves[len(s.carves)-1]
+	s.carves = s.carves[:len(s.carves)-1]
+	s.g.carve(e.row, e.col, e.d)
+	s.last = e
+	return true
+}
+
+// divide splits r in two, queueing the door between the halves to be carved,
+// or if r is a corridor one cell wide or is to be a room, queues every wall
+// inside it.
+func (s *DivisionStepper) divide(r region) {
+	if r.rows == 1 || r.cols == 1 ||
+		s.rooms > 0 && r.rows <= roomSize && r.cols <= roomSize && s.rng.Float64() < s.rooms {
+		for row := r.row; row < r.row+r.rows; row++ {
+			for col := r.col; col < r.col+r.cols; col++ {
+				if col < r.col+r.cols-1 {
+					s.carves = append(s.carves, edge{row, col, E})
+				}
+				if row < r.row+r.rows-1 {
+					s.carves = append(s.carves, edge{row, col, S})
+				}
+			}
+		}
+		return
+	}
+	// Tall regions are mostly cut by walls running east-west and wide ones
+	// by walls running north-south, so the halves don't end up as long
+	// corridors, and bias tips the balance.
+	across := s.bias * float64(r.rows)
+	down := (1 - s.bias) * float64(r.cols)
+	if s.rng.Float64()*(across+down) < across {
+		// A wall running east-west, with a door south from the top half.
+		k := 1 + s.rng.Intn(r.rows-1)
+		s.carves = append(s.carves, edge{r.row + k - 1, r.col + s.rng.Intn(r.cols), S})
+		s.regions = append(s.regions,
+			region{r.row, r.col, k, r.cols},
+			region{r.row + k, r.col, r.rows - k, r.cols})
+	} else {
+		k := 1 + s.rng.Intn(r.cols-1)
+		s.carves = append(s.carves, edge{r.row + s.rng.Intn(r.rows), r.col + k - 1, E})
+		s.regions = append(s.regions,
+			region{r.row, r.col, r.rows, k},
+			region{r.row, r.col + k, r.rows, r.cols - k})
+	}
+}
+
+func (s *DivisionStepper) Last() (c Cell, d Direction) {
+	return Cell{s.last.row, s.last.col}, s.last.d
+}
+
+// MazifyDivision turns the grid into a maze using recursive division,
+// leaving small regions open as rooms with probability rooms.
+func (g *Grid) MazifyDivision(rng *rand.Rand, rooms float64) {
+	runSteps(context.Background(), NewDivisionStepper(g, rng, NoBias, rooms))
+}
+
+// DivisionGenerator returns the division algorithm with the given room
+// probability as a Generator.
+func DivisionGenerator(rooms float64) Generator {
+	return mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return runSteps(ctx, NewDivisionStepper(g, rng, bias, rooms))
+	})
+}
@@ -0,0 +1,71 @@
+package maze
+
+// DisjointSet is a disjoint set union data structure over the ints [0, n),
+// using union by rank and path compression so both operations are
+// effectively constant time even on grids with many millions of cells.
+// Parents are kept as int32s, half the memory of ints, which is plenty for
+// the maxGridCells cells a grid can have.
+type DisjointSet struct {
+	parent []int32
+	rank   []uint8 // upper bound on the height of each root's tree
+}
+
+// NewDisjointSet returns a DisjointSet of n elements, each in a set by itself.
+func NewDisjointSet(n int) *DisjointSet {
+	s := &DisjointSet{make([]int32, n), make([]uint8, n)}
+	s.Reset()
+	return s
+}
+
+// resize makes s a DisjointSet of n elements, each in a set by itself,
+// reusing its memory if there's room.
+func (s *DisjointSet) resize(n int) {
+	if cap(s.parent) < n {
+		s.parent, s.rank = make([]int32, n), make([]uint8, n)
+	}
+	s.parent, s.rank = s.parent[:n], s.rank[:n]
+	s.Reset()
+}
+
+// Reset puts every element back in a set by itself.
+func (s *DisjointSet) Reset() {
+	// Parent pointers for DSU; initially each elements points to itself
+	for i := range s.parent {
+		s.parent[i] = int32(i)
+		s.rank[i] = 0
+	}
+}
+
+// Find returns the representative element of the set containing id.
+func (s *DisjointSet) Find(id int) int {
+	root := int32(id)
+	for s.parent[root] != root {
+		root = s.parent[root]
+	}
+	// path compression: point everything we walked past straight at the root
+	for i := int32(id); s.parent[i] != root; {
+		s.parent[i], i = root, s.parent[i]
+	}
+	return int(root)
+}
+
+// Union merges the sets containing idA and idB, returning false if they were
+// already the same set.
+func (s *DisjointSet) Union(idA, idB int) bool {
+	setA := s.Find(idA)
+	setB := s.Find(idB)
+	if setA == setB {
+		return false
+	}
+	// Hang the shorter tree under the taller one.
+	switch {
+	case s.rank[setA] < s.rank[setB]:
+		s.parent[setA] = int32(setB)
+	case s.rank[setA] > s.rank[setB]:
+		s.parent[setB] = int32(setA)
+	default:
+		s.parent[setB] = int32(setA)
+		s.rank[setA]++
+	}
+	return true
+}
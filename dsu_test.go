@@ -0,0 +1,156 @@
+package maze
+
+import (
+	"math/bits"
+	"testing"
+)
+
+// depth returns how many parent pointers it is from id up to its set's
+// root, without compressing anything on the way.
+func (s *DisjointSet) depth(id int) int {
+	d := 0
+	for int(s.parent[id]) != id {
+		id = int(s.parent[id])
+		d++
+	}
+	return d
+}
+
+func TestDisjointSet(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		n      int
+		unions [][2]int
+		merged []bool // what each union returns
+		same   [][2]int
+		apart  [][2]int
+	}{
+		{name: "singletons", n: 3, apart: [][2]int{{0, 1}, {1, 2}, {0, 2}}, same: [][2]int{{1, 1}}},
+		{name: "pair", n: 3, unions: [][2]int{{0, 1}}, merged: []bool{true}, same: [][2]int{{0, 1}}, apart: [][2]int{{0, 2}, {1, 2}}},
+		{name: "self", n: 2, unions: [][2]int{{1, 1}}, merged: []bool{false}, apart: [][2]int{{0, 1}}},
+		{name: "again", n: 2, unions: [][2]int{{0, 1}, {1, 0}}, merged: []bool{true, false}, same: [][2]int{{0, 1}}},
+		{
+			name:   "transitive",
+			n:      6,
+			unions: [][2]int{{0, 1}, {2, 3}, {1, 3}, {0, 2}, {4, 5}},
+			merged: []bool{true, true, true, false, true},
+			same:   [][2]int{{0, 3}, {1, 2}, {4, 5}},
+			apart:  [][2]int{{0, 4}, {3, 5}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewDisjointSet(tc.n)
+			for i, u := range tc.unions {
+				if got := s.Union(u[0], u[1]); got != tc.merged[i] {
+					t.Errorf("Union(%d, %d) = %v, want %v", u[0], u[1], got, tc.merged[i])
+				}
+			}
+			for _, p := range tc.same {
+				if s.Find(p[0]) != s.Find(p[1]) {
+					t.Errorf("%d and %d in different sets", p[0], p[1])
+				}
+			}
+			for _, p := range tc.apart {
+				if s.Find(p[0]) == s.Find(p[1]) {
+					t.Errorf("%d and %d in the same set", p[0], p[1])
+				}
+			}
+			for id := 0; id < tc.n; id++ {
+				if root := s.Find(id); s.Find(root) != root {
+					t.Errorf("Find(%d) = %d, which isn't a root", id, root)
+				}
+			}
+			s.Reset()
+			for id := 0; id < tc.n; id++ {
+				if s.Find(id) != id || s.rank[id] != 0 {
+					t.Errorf("after Reset, Find(%d) = %d with rank %d", id, s.Find(id), s.rank[id])
+				}
+			}
+		})
+	}
+}
+
+func TestDisjointSetResize(t *testing.T) {
+	var s DisjointSet
+	for _, n := range []int{4, 2, 8, 0, 3} {
+		s.resize(n)
+		if len(s.parent) != n || len(s.rank) != n {
+			t.Fatalf("resize(%d): %d parents and %d ranks", n, len(s.parent), len(s.rank))
+		}
+		for id := 0; id < n; id++ {
+			if s.Find(id) != id {
+				t.Fatalf("resize(%d): %d starts in %d's set", n, id, s.Find(id))
+			}
+		}
+		for id := 1; id < n; id++ {
+			s.Union(0, id)
+		}
+	}
+	before := cap(s.parent)
+	s.resize(2)
+	if cap(s.parent) != before {
+		t.Errorf("shrinking reallocated, cap %d to %d", before, cap(s.parent))
+	}
+}
+
+func TestDisjointSetUnionByRank(t *testing.T) {
+	s := NewDisjointSet(8)
+	// Two trees of height 1 make one of height 2 under the first root.
+	s.Union(0, 1)
+	s.Union(2, 3)
+	s.Union(0, 2)
+	root := s.Find(0)
+	if s.rank[root] != 2 {
+		t.Errorf("rank %d after joining two rank 1 trees, want 2", s.rank[root])
+	}
+	// A smaller tree goes under the bigger one whichever side it's on, and
+	// doesn't add to the height.
+	s.Union(4, 5)
+	s.Union(4, root)
+	if s.Find(4) != root || s.rank[root] != 2 {
+		t.Errorf("rank 1 tree joined to rank 2 tree: root %d rank %d, want %d rank 2", s.Find(4), s.rank[root], root)
+	}
+	s.Union(root, 6)
+	if s.Find(6) != root || s.rank[root] != 2 {
+		t.Errorf("singleton joined to rank 2 tree: root %d rank %d, want %d rank 2", s.Find(6), s.rank[root], root)
+	}
+}
+
+func TestDisjointSetLongChain(t *testing.T) {
+	const n = 1 << 20
+	// Joining a chain one element at a time, from either end, keeps every
+	// tree's height under log2(n).
+	for _, reverse := range []bool{false, true} {
+		s := NewDisjointSet(n)
+		for i := 0; i < n-1; i++ {
+			a, b := i, i+1
+			if reverse {
+				a, b = n-1-i, n-2-i
+			}
+			if !s.Union(a, b) {
+				t.Fatalf("Union(%d, %d) found them already joined", a, b)
+			}
+		}
+		limit := bits.Len(n)
+		for id := 0; id < n; id++ {
+			if d := s.depth(id); d > limit {
+				t.Fatalf("reverse %v: %d is %d deep, over %d", reverse, id, d, limit)
+			}
+		}
+	}
+
+	// A chain n long built by hand, deeper than any recursion would
+	// survive, is walked and flattened by a single Find.
+	s := NewDisjointSet(n)
+	for id := 0; id < n-1; id++ {
+		s.parent[id] = int32(id + 1)
+	}
+	if root := s.Find(0); root != n-1 {
+		t.Fatalf("Find(0) = %d, want %d", root, n-1)
+	}
+	for id := 0; id < n; id++ {
+		if int(s.parent[id]) != n-1 {
+			t.Fatalf("after Find(0), %d points at %d, not the root", id, s.parent[id])
+		}
+	}
+}
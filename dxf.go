@@ -0,0 +1,197 @@
+package maze
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// cutMargin is how far in from the edges of the material, in millimetres,
+// the dxf format keeps the maze.
+const cutMargin = 5
+
+// Cut is the material a maze is laser cut from, for the dxf format.
+type Cut struct {
+	// Width and Height are the size of the sheet, and Thickness how thick
+	// it is, all in millimetres.  The walls are cut out as wide as the
+	// sheet is thick; 0 cuts along the middle of each wall instead, for
+	// engraving.
+	Width, Height, Thickness float64
+	// Kerf is how wide the laser cuts, in millimetres: the outlines are
+	// moved out by half of it so the walls come out the size they should.
+	Kerf float64
+}
+
+// dxfInfo matches the comments renderDXF writes for RenderOptions.Info.
+var dxfInfo = regexp.MustCompile(`(?m)^999\r?\n` + infoPrefix + `([^=\r\n]+)=(.*?)\r?$`)
+
+// renderDXF draws the maze as a DXF drawing in millimetres, for laser
+// cutting: the maze as big as fits on the opts.Cut sheet, or an A4 one if
+// that's nil, inside cutMargin.  With a Thickness, it's the outline of the
+// walls, each piece one closed polyline, which is what the laser follows to
+// cut them out; without, a line down the middle of each straight run of
+// wall.  Only the walls are drawn, no solution or labels.
+func renderDXF(g *Grid, w io.Writer, opts RenderOptions) error {
+	cut := Cut{Width: 297, Height: 210}
+	if opts.Cut != nil {
+		cut = *opts.Cut
+	}
+	// size fits the cells and the thickness of the outer walls.
+	size := min((cut.Width-2*cutMargin-cut.Thickness)/float64(g.ColCount), (cut.Height-2*cutMargin-cut.Thickness)/float64(g.RowCount))
+	if size <= cut.Thickness {
+		return fmt.Errorf("a %dx%d maze doesn't fit on %gx%gmm with %gmm walls", g.RowCount, g.ColCount, cut.Width, cut.Height, cut.Thickness)
+	}
+	// The maze is centred on the sheet, with y going up as DXF has it.
+	left := (cut.Width - size*float64(g.ColCount)) / 2
+	top := cut.Height - (cut.Height-size*float64(g.RowCount))/2
+
+	bw := bufio.NewWriter(w)
+	for _, key := range sortedKeys(opts.Info) {
+		fmt.Fprintf(bw, "999\n%s%s=%s\n", infoPrefix, key, opts.Info[key])
+	}
+	// $INSUNITS 4 is millimetres.
+	fmt.Fprint(bw, "0\nSECTION\n2\nHEADER\n9\n$INSUNITS\n70\n4\n0\nENDSEC\n0\nSECTION\n2\nENTITIES\n")
+	if cut.Thickness == 0 {
+		for _, line := range g.wallRuns() {
+			fmt.Fprintf(bw, "0\nLINE\n8\nWALLS\n10\n%.3f\n20\n%.3f\n30\n0\n11\n%.3f\n21\n%.3f\n31\n0\n",
+				left+line[0]*size, top-line[1]*size, left+line[2]*size, top-line[3]*size)
+		}
+	} else {
+		t := cut.Thickness
+		// edge returns where the band i of a wallPixel grid starts: even
+		// bands are walls t thick centred on the lines between cells, odd
+		// ones the insides of cells.
+		edge := func(i int) float64 {
+			if i%2 == 0 {
+				return float64(i/2)*size - t/2
+			}
+			return float64(i/2)*size + t/2
+		}
+		for _, loop := range g.wallOutlines() {
+			fmt.Fprint(bw, "0\nPOLYLINE\n8\nWALLS\n66\n1\n70\n1\n10\n0\n20\n0\n30\n0\n")
+			for i, v := range loop {
+				// Move the corner out from the wall by half the kerf along
+				// both edges meeting there.
+				in, out := loop[(i+len(loop)-1)%len(loop)], loop[(i+1)%len(loop)]
+				nx := sgn(v[1]-in[1]) + sgn(out[1]-v[1])
+				ny := -sgn(v[0]-in[0]) - sgn(out[0]-v[0])
+				x := left + edge(v[0]) + float64(nx)*cut.Kerf/2
+				y := top - (edge(v[1]) + float64(ny)*cut.Kerf/2)
+				fmt.Fprintf(bw, "0\nVERTEX\n8\nWALLS\n10\n%.3f\n20\n%.3f\n30\n0\n", x, y)
+			}
+			fmt.Fprint(bw, "0\nSEQEND\n8\nWALLS\n")
+		}
+	}
+	fmt.Fprint(bw, "0\nENDSEC\n0\nEOF\n")
+	return bw.Flush()
+}
+
+// sgn returns -1, 0 or 1 as x is negative, zero or positive.
+func sgn(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// wallRuns returns the maze's walls joined into straight runs, each as x0,
+// y0, x1, y1 in cells from the top left corner.
+func (g *Grid) wallRuns() [][4]float64 {
+	var runs [][4]float64
+	// Horizontal runs along each line between rows, then vertical ones.
+	for row := 0; row <= g.RowCount; row++ {
+		for col := 0; col < g.ColCount; {
+			if !g.wallPixel(2*row, 2*col+1) {
+				col++
+				continue
+			}
+			end := col
+			for end < g.ColCount && g.wallPixel(2*row, 2*end+1) {
+				end++
+			}
+			runs = append(runs, [4]float64{float64(col), float64(row), float64(end), float64(row)})
+			col = end
+		}
+	}
+	for col := 0; col <= g.ColCount; col++ {
+		for row := 0; row < g.RowCount; {
+			if !g.wallPixel(2*row+1, 2*col) {
+				row++
+				continue
+			}
+			end := row
+			for end < g.RowCount && g.wallPixel(2*end+1, 2*col) {
+				end++
+			}
+			runs = append(runs, [4]float64{float64(col), float64(row), float64(col), float64(end)})
+			row = end
+		}
+	}
+	return runs
+}
+
+// wallOutlines returns the outlines of the maze's walls drawn as wallPixel
+// draws them, each a loop of the corners of the pixel grid, as x, y, going
+// round with the wall on the right, clockwise round the outside of a piece
+// of wall and the other way round the holes in it, the cells it walls in.
+func (g *Grid) wallOutlines() [][][2]int {
+	height, width := 2*g.RowCount+1, 2*g.ColCount+1
+	// next is where the outline goes from each corner.  Walls only meet at
+	// a corner pixel that's filled in, so no corner has two ways on.
+	next := map[[2]int][2]int{}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !g.wallPixel(y, x) {
+				continue
+			}
+			if !g.wallPixel(y-1, x) {
+				next[[2]int{x, y}] = [2]int{x + 1, y}
+			}
+			if !g.wallPixel(y, x+1) {
+				next[[2]int{x + 1, y}] = [2]int{x + 1, y + 1}
+			}
+			if !g.wallPixel(y+1, x) {
+				next[[2]int{x + 1, y + 1}] = [2]int{x, y + 1}
+			}
+			if !g.wallPixel(y, x-1) {
+				next[[2]int{x, y + 1}] = [2]int{x, y}
+			}
+		}
+	}
+	var loops [][][2]int
+	for y := 0; y <= height; y++ {
+		for x := 0; x <= width; x++ {
+			start := [2]int{x, y}
+			if _, ok := next[start]; !ok {
+				continue
+			}
+			var loop [][2]int
+			for v := start; ; {
+				to := next[v]
+				delete(next, v)
+				// Only the corners where it turns.
+				if from := v; len(loop) == 0 || !collinear(loop[len(loop)-1], from, to) {
+					loop = append(loop, from)
+				}
+				v = to
+				if v == start {
+					break
+				}
+			}
+			if len(loop) > 2 && collinear(loop[len(loop)-1], loop[0], loop[1]) {
+				loop = loop[1:]
+			}
+			loops = append(loops, loop)
+		}
+	}
+	return loops
+}
+
+// collinear reports whether b is on the straight line from a to c.
+func collinear(a, b, c [2]int) bool {
+	return (b[0]-a[0])*(c[1]-b[1]) == (b[1]-a[1])*(c[0]-b[0])
+}
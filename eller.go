@@ -0,0 +1,283 @@
+package maze
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// ellerRows generates a maze one row at a time using Eller's algorithm.  Only
+// the current row is kept in memory, so the maze can have any number of rows.
+type ellerRows struct {
+	rng      *rand.Rand
+	bias     float64 // chance of joining neighbours in a row
+	rowCount int
+	row      int // index of the row the next call to next generates
+	cells    []uint8
+	// sets[col] is the set the cell in col belongs to.  Set ids are
+	// renumbered every row so they always fit in [0, colCount).
+	sets    []int
+	merged  *DisjointSet
+	renamed []int
+	members [][]int // scratch: the columns in each set
+}
+
+func newEllerRows(rng *rand.Rand, rowCount, colCount int) *ellerRows {
+	return &ellerRows{
+		rng:      rng,
+		bias:     NoBias,
+		rowCount: rowCount,
+		cells:    make([]uint8, colCount),
+		sets:     make([]int, colCount),
+		merged:   NewDisjointSet(colCount),
+		renamed:  make([]int, colCount),
+		members:  make([][]int, colCount),
+	}
+}
+
+// next generates the next row and returns the openings of its cells.  The
+// slice is reused by the following call.
+func (e *ellerRows) next() []uint8 {
+	cols := len(e.cells)
+
+	// Cells opened to from the row above stay in their set; every other cell
+	// starts a new set of its own.
+	for i := range e.renamed {
+		e.renamed[i] = -1
+	}
+	nextId := 0
+	for col := range e.cells {
+		if e.row > 0 && e.cells[col]&S != 0 {
+			old := e.sets[col]
+			if e.renamed[old] < 0 {
+				e.renamed[old] = nextId
+				nextId++
+			}
+			e.sets[col] = e.renamed[old]
+			e.cells[col] = N
+		} else {
+			e.sets[col] = -1
+			e.cells[col] = 0
+		}
+	}
+	for col := range e.sets {
+		if e.sets[col] < 0 {
+			e.sets[col] = nextId
+			nextId++
+		}
+	}
+
+	// Randomly join neighbours in different sets.  The last row must join
+	// all of them or the maze would be disconnected.
+	last := e.row == e.rowCount-1
+	e.merged.Reset()
+	for col := 0; col < cols-1; col++ {
+		if e.merged.Find(e.sets[col]) != e.merged.Find(e.sets[col+1]) &&
+			(last || e.rng.Float64() < e.bias) {
+			e.cells[col] |= E
+			e.cells[col+1] |= W
+			e.merged.Union(e.sets[col], e.sets[col+1])
+		}
+	}
+	for col := range e.sets {
+		e.sets[col] = e.merged.Find(e.sets[col])
+	}
+
+	// Every set continues into the next row through at least one cell.
+	if !last {
+		for set := range e.members {
+			e.members[set] = e.members[set][:0]
+		}
+		for col, set := range e.sets {
+			e.members[set] = append(e.members[set], col)
+		}
+		for _, cols := range e.members {
+			if len(cols) == 0 {
+				continue
+			}
+			e.cells[cols[e.rng.Intn(len(cols))]] |= S
+			for _, col := range cols {
+				if e.rng.Float64() < 1-e.bias {
+					e.cells[col] |= S
+				}
+			}
+		}
+	}
+
+	e.row++
+	return e.cells
+}
+
+// MazifyEller turns the grid into a maze using Eller's algorithm.
+func (g *Grid) MazifyEller(rng *rand.Rand) {
+	g.MazifyEllerContext(context.Background(), rng)
+}
+
+// MazifyEllerContext is MazifyEller but gives up, leaving the maze partly
+// carved, and returns ctx.Err() if ctx is done before it finishes.
+func (g *Grid) MazifyEllerContext(ctx context.Context, rng *rand.Rand) error {
+	return g.mazifyEller(ctx, rng, NoBias)
+}
+
+func (g *Grid) mazifyEller(ctx context.Context, rng *rand.Rand, bias float64) error {
+	rows := newEllerRows(rng, g.RowCount, g.ColCount)
+	rows.bias = bias
+	for row := 0; row < g.RowCount; row++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cells := rows.next()
+		copy(g.data[g.CellId(row, 0):], cells)
+		if g.Observer != nil {
+			for col, cell := range cells {
+				for _, d := range []Direction{E, S} {
+					if Direction(cell)&d != 0 {
+						g.Observer.carve(g.Nodes(), row, col, d)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// StreamEller generates a rowCount x colCount maze with Eller's algorithm and
+// writes it to w as text, one row at a time.  The maze is never held in
+// memory, so rowCount can be far larger than would fit in a Grid.
+func StreamEller(w io.Writer, rng *rand.Rand, rowCount, colCount int) error {
+	return StreamEllerContext(context.Background(), w, rng, rowCount, colCount)
+}
+
+// StreamEllerContext is StreamEller but stops writing and returns ctx.Err()
+// if ctx is done before the maze is finished.
+func StreamEllerContext(ctx context.Context, w io.Writer, rng *rand.Rand, rowCount, colCount int) error {
+	bw := bufio.NewWriter(w)
+	rows := newEllerRows(rng, rowCount, colCount)
+	buf := appendTextTop(nil, colCount)
+	for row := 0; row < rowCount; row++ {
+		if err := ctx.Err(); err != nil {
+			bw.Flush()
+			return err
+		}
+		buf = appendTextRow(buf, rows.next(), nil, nil)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// EllerCheckpoint is where a StreamEllerFrom run had got to, so it can be
+// saved (as JSON, say) and the run carried on from there later, after a
+// crash or on another machine, with the same output it would have had.
+// Only the last row and the random number generator's position are needed:
+// Eller's algorithm keeps nothing else.
+type EllerCheckpoint struct {
+	Seed       int64
+	Rows, Cols int
+	// Row is the number of rows generated so far.
+	Row int
+	// Draws is how many numbers have been drawn from the seed's rand.Source.
+	Draws uint64
+	// Cells and Sets are the openings and set ids of the last row
+	// generated.
+	Cells []uint8
+	Sets  []int
+	// Written is the number of bytes of text written so far.
+	Written int64
+}
+
+// NewEllerCheckpoint returns the checkpoint at the very start of
+// generating a rowCount x colCount maze from seed.
+func NewEllerCheckpoint(seed int64, rowCount, colCount int) *EllerCheckpoint {
+	return &EllerCheckpoint{Seed: seed, Rows: rowCount, Cols: colCount}
+}
+
+// StreamEllerFrom carries on streaming the maze c was taken from, writing
+// to w what StreamEller would have written with rand.NewSource(c.Seed)
+// after the first c.Written bytes.  Every `every` rows, and when ctx is done,
+// it flushes w, updates c and calls save with it; a fresh run is
+// NewEllerCheckpoint's.  Picking up from a checkpoint replays the random
+// numbers drawn so far, which takes a few seconds per billion cells.
+func StreamEllerFrom(ctx context.Context, w io.Writer, c *EllerCheckpoint, every int, save func(*EllerCheckpoint) error) error {
+	if c.Rows < 1 || c.Cols < 1 || c.Row < 0 || c.Row > c.Rows {
+		return fmt.Errorf("bad checkpoint: row %d of %dx%d", c.Row, c.Rows, c.Cols)
+	}
+	src := &countingSource{src: rand.NewSource(c.Seed).(rand.Source64)}
+	for ; src.draws < c.Draws; src.draws++ {
+		src.src.Uint64()
+	}
+	rows := newEllerRows(rand.New(src), c.Rows, c.Cols)
+	if c.Row > 0 {
+		if len(c.Cells) != c.Cols || len(c.Sets) != c.Cols {
+			return fmt.Errorf("bad checkpoint: %d cells and %d sets in a row of %d", len(c.Cells), len(c.Sets), c.Cols)
+		}
+		copy(rows.cells, c.Cells)
+		copy(rows.sets, c.Sets)
+		rows.row = c.Row
+	}
+
+	bw := bufio.NewWriter(w)
+	checkpoint := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		c.Row, c.Draws = rows.row, src.draws
+		c.Cells = append(c.Cells[:0], rows.cells...)
+		c.Sets = append(c.Sets[:0], rows.sets...)
+		return save(c)
+	}
+	var buf []byte
+	if c.Row == 0 && c.Written == 0 {
+		buf = appendTextTop(nil, c.Cols)
+	}
+	for rows.row < c.Rows {
+		if err := ctx.Err(); err != nil {
+			if err := checkpoint(); err != nil {
+				return err
+			}
+			return err
+		}
+		buf = appendTextRow(buf, rows.next(), nil, nil)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		c.Written += int64(len(buf))
+		buf = buf[:0]
+		if every > 0 && rows.row%every == 0 && rows.row < c.Rows {
+			if err := checkpoint(); err != nil {
+				return err
+			}
+		}
+	}
+	return checkpoint()
+}
+
+// countingSource is a rand.Source64 that counts the numbers drawn from it,
+// so a run can be checkpointed and the source wound back on to the same
+// place.
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
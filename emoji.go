@@ -0,0 +1,96 @@
+package maze
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// The emoji the emoji format draws a maze with.
+const (
+	emojiWall   = '⬛'
+	emojiFloor  = '⬜'
+	emojiStart  = '🟩'
+	emojiFinish = '🏁'
+	emojiPath   = '🟨'
+)
+
+// chatLimits is how long a message each chat app the emoji format fits
+// mazes to takes, in UTF-16 code units as they count them, and chatSpoilers
+// what each wraps a spoiler in, if it can.
+var (
+	chatLimits   = map[string]int{"discord": 2000, "slack": 4000}
+	chatSpoilers = map[string]string{"discord": "||"}
+)
+
+// ChatNames returns the chat apps the emoji format knows, sorted.
+func ChatNames() []string {
+	names := make([]string, 0, len(chatLimits))
+	for name := range chatLimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderEmoji draws the maze as a grid of emoji to paste into a chat app,
+// opts.Chat, a WalkMap opts.Corridor tiles wide: ⬛ for wall and ⬜ for
+// floor, with 🟩 at the start and 🏁 at the finish.  The solution, if there
+// is one, comes after it as a spoiler, in 🟨, so it's hidden until it's
+// clicked.  It returns an error if the lot won't fit in a message.
+func renderEmoji(g *Grid, w io.Writer, opts RenderOptions) error {
+	chat := opts.Chat
+	if chat == "" {
+		chat = "discord"
+	}
+	limit, ok := chatLimits[chat]
+	if !ok {
+		return fmt.Errorf("unknown chat %q, want %s", chat, strings.Join(ChatNames(), " or "))
+	}
+	spoiler, ok := chatSpoilers[chat]
+	if len(opts.Path) > 0 && !ok {
+		return fmt.Errorf("%s has no spoilers to hide the solution in", chat)
+	}
+	m := g.WalkMap(opts.Corridor)
+	tiles := make([]rune, m.Width*m.Height)
+	for i, floor := range m.Floor {
+		tiles[i] = emojiWall
+		if floor {
+			tiles[i] = emojiFloor
+		}
+	}
+	start, end := g.endpoints()
+	mark := func(tiles []rune) {
+		for _, mark := range []struct {
+			id    int
+			emoji rune
+		}{{start, emojiStart}, {end, emojiFinish}} {
+			x, y := m.middle(g, mark.id)
+			tiles[y*m.Width+x] = mark.emoji
+		}
+	}
+	var b strings.Builder
+	grid := func(tiles []rune) {
+		for y := 0; y < m.Height; y++ {
+			b.WriteString(string(tiles[y*m.Width : (y+1)*m.Width]))
+			b.WriteByte('\n')
+		}
+	}
+	solved := append([]rune(nil), tiles...)
+	mark(tiles)
+	grid(tiles)
+	if len(opts.Path) > 0 {
+		m.pathTiles(g, g.CellIds(opts.Path), func(x, y int) { solved[y*m.Width+x] = emojiPath })
+		mark(solved)
+		b.WriteString("\nSolution:\n" + spoiler + "\n")
+		grid(solved)
+		b.WriteString(spoiler + "\n")
+	}
+	if n := len(utf16.Encode([]rune(b.String()))); n > limit {
+		return fmt.Errorf("a %dx%d maze is %d characters as emoji, over %s's limit of %d for a message", g.RowCount, g.ColCount, n, chat, limit)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,126 @@
+package maze
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ExecSolver is a Solver that's a program of its own, in any language, run
+// afresh for each maze, so solvers can be benchmarked against the built in
+// ones without linking any Go.  It's given the maze on its standard input:
+//
+//	maze ROWS COLS
+//	start ROW COL
+//	finish ROW COL
+//
+// then a line for each row of the maze with a hex digit for each cell, the
+// ways that can be walked out of it added up, 1 north, 2 east, 4 south and
+// 8 west, so one-way passages are only open at the end they lead from.
+// Rows and columns count from 0 at the top left.  It answers on its
+// standard output with the path, a line of ROW COL for each cell from the
+// start to the finish, or the single line none if there's no path.
+// Anything it writes to its standard error is passed through.
+type ExecSolver struct {
+	// Command and Args are the program and the arguments it's run with.
+	Command string
+	Args    []string
+	// Timeout, if above 0, is how long it has to answer before it's killed.
+	Timeout time.Duration
+}
+
+// Solve runs the program, returning nil if it fails, gives a path that
+// isn't one, or finds none.  Run says which.
+func (s *ExecSolver) Solve(g *Grid, start, finish Cell) []Cell {
+	path, _ := s.Run(g, start, finish)
+	return path
+}
+
+// Run runs the program on the maze and checks the path it gives with
+// CheckPath.  It returns ErrNoPath if the program says there's no path.
+func (s *ExecSolver) Run(g *Grid, start, finish Cell) ([]Cell, error) {
+	if !g.Contains(start) || !g.Contains(finish) {
+		return nil, errors.New("start or finish is outside the grid")
+	}
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	var in bytes.Buffer
+	fmt.Fprintf(&in, "maze %d %d\nstart %d %d\nfinish %d %d\n", g.RowCount, g.ColCount, start.Row, start.Col, finish.Row, finish.Col)
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			fmt.Fprintf(&in, "%x", int(g.walkable(row, col)))
+		}
+		in.WriteByte('\n')
+	}
+	cmd := exec.CommandContext(ctx, s.Command, s.Args...)
+	cmd.Stdin, cmd.Stderr = &in, os.Stderr
+	// Don't wait long on anything it started that's still holding its
+	// output open once it's killed.
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("no answer in %v", s.Timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var path []Cell
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if text == "none" && path == nil {
+			return nil, ErrNoPath
+		}
+		var c Cell
+		if _, err := fmt.Sscanf(text, "%d %d", &c.Row, &c.Col); err != nil {
+			return nil, fmt.Errorf("line %d of the answer: %q isn't ROW COL", line, text)
+		}
+		if !g.Contains(c) {
+			return nil, fmt.Errorf("line %d of the answer: %v is outside the grid", line, c)
+		}
+		path = append(path, c)
+	}
+	if err := g.CheckPath(path, start, finish); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// CheckPath returns an error unless path is a walk through the maze from
+// start to finish: each cell next to the one before with no wall between
+// them, and one-way passages only walked the way they go.
+func (g *Grid) CheckPath(path []Cell, start, finish Cell) error {
+	if len(path) == 0 {
+		return errors.New("the path is empty")
+	}
+	if path[0] != start {
+		return fmt.Errorf("the path starts at %v, not the start %v", path[0], start)
+	}
+	if path[len(path)-1] != finish {
+		return fmt.Errorf("the path ends at %v, not the finish %v", path[len(path)-1], finish)
+	}
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		if !g.Contains(to) || !g.adjacent(g.CellIdOf(from), g.CellIdOf(to)) {
+			return fmt.Errorf("step %d jumps from %v to %v", i, from, to)
+		}
+		if g.walkable(from.Row, from.Col)&g.direction(g.CellIdOf(from), g.CellIdOf(to)) == 0 {
+			return fmt.Errorf("step %d from %v to %v goes through a wall or the wrong way along a one-way passage", i, from, to)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package maze
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+)
+
+// Fingerprint returns a hex SHA-256 digest of the maze's size, walls and
+// one-way passages.  Two grids have the same fingerprint exactly when they
+// are Equal.  A maze with no one-way passages has the fingerprint it had
+// before they were hashed.
+func (g *Grid) Fingerprint() string {
+	h := sha256.New()
+	var size [16]byte
+	binary.BigEndian.PutUint64(size[:8], uint64(g.RowCount))
+	binary.BigEndian.PutUint64(size[8:], uint64(g.ColCount))
+	h.Write(size[:])
+	h.Write(g.data)
+	ids := make([]int, 0, len(g.oneWay))
+	for id, d := range g.oneWay {
+		if d != 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		var entry [9]byte
+		binary.BigEndian.PutUint64(entry[:8], uint64(id))
+		entry[8] = byte(g.oneWay[id])
+		h.Write(entry[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CanonicalFingerprint is like Fingerprint but gives the same result for a
+// maze and all of its rotations and reflections, by taking the smallest of
+// their fingerprints.
+func (g *Grid) CanonicalFingerprint() string {
+	best := ""
+	turned := g.Clone()
+	for i := 0; i < 4; i++ {
+		flipped := turned.MirrorH()
+		for _, fp := range []string{turned.Fingerprint(), flipped.Fingerprint()} {
+			if best == "" || fp < best {
+				best = fp
+			}
+		}
+		turned = turned.Rotate90()
+	}
+	return best
+}
@@ -0,0 +1,84 @@
+package maze
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// wallPixel reports whether the maze has a wall at (y, x) when it's drawn as
+// a grid of square pixels, 2*RowCount+1 high and 2*ColCount+1 wide, with a
+// pixel for each cell, each wall between cells and each corner between them.
+func (g *Grid) wallPixel(y, x int) bool {
+	if y < 0 || x < 0 || y > 2*g.RowCount || x > 2*g.ColCount {
+		return false
+	}
+	row, col := y/2, x/2
+	// hWall and vWall are as in renderUnicode.
+	hWall := func(row, col int) bool {
+		return row == 0 || row == g.RowCount || g.openings(row, col)&N == 0
+	}
+	vWall := func(row, col int) bool {
+		return col == 0 || col == g.ColCount || g.openings(row, col)&W == 0
+	}
+	switch {
+	case y%2 == 1 && x%2 == 1:
+		return false
+	case y%2 == 0 && x%2 == 1:
+		return hWall(row, col)
+	case y%2 == 1:
+		return vWall(row, col)
+	}
+	// A corner is filled in if any wall meets it.
+	return (row > 0 && vWall(row-1, col)) || (row < g.RowCount && vWall(row, col)) ||
+		(col > 0 && hWall(row, col-1)) || (col < g.ColCount && hWall(row, col))
+}
+
+// renderHalfBlock draws the maze's pixels with half block characters, two
+// to a character cell, so it takes half the lines of the text format.
+func renderHalfBlock(g *Grid, w io.Writer, opts RenderOptions) error {
+	var buf []byte
+	for y := 0; y <= 2*g.RowCount; y += 2 {
+		for x := 0; x <= 2*g.ColCount; x++ {
+			top, bottom := g.wallPixel(y, x), g.wallPixel(y+1, x)
+			switch {
+			case top && bottom:
+				buf = append(buf, "█"...)
+			case top:
+				buf = append(buf, "▀"...)
+			case bottom:
+				buf = append(buf, "▄"...)
+			default:
+				buf = append(buf, ' ')
+			}
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// brailleDots are the bits of a Braille character's dots, by row and
+// column within the character.
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// renderBraille draws the maze's pixels as Braille dots, eight to a
+// character, for the most compact output.
+func renderBraille(g *Grid, w io.Writer, opts RenderOptions) error {
+	var buf []byte
+	for y := 0; y <= 2*g.RowCount; y += 4 {
+		for x := 0; x <= 2*g.ColCount; x += 2 {
+			r := rune(0x2800)
+			for dy := range brailleDots {
+				for dx, dot := range brailleDots[dy] {
+					if g.wallPixel(y+dy, x+dx) {
+						r |= dot
+					}
+				}
+			}
+			buf = utf8.AppendRune(buf, r)
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,85 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+)
+
+// fractalBlock is how many blocks across and down the fractal algorithm
+// splits each block into.
+const fractalBlock = 3
+
+// fractalPattern is how the fractal algorithm joins up the blocks of one
+// level: a small maze with a cell for each block, and how far along the
+// edge between two blocks the door joining them is, as a fraction.
+type fractalPattern struct {
+	maze Grid
+	door float64
+}
+
+// MazifyFractal carves a self-similar maze: it splits the grid into 3x3
+// blocks joined up like the cells of a small random maze, splits each of
+// those the same way, and so on down to single cells.  Every block at a
+// level is a scaled copy of the same small maze, with its doors in the same
+// places, so the whole maze is described by a small maze a level, the
+// logarithm of its size, and is made the same way at every scale.  Blocks
+// that don't divide evenly are split as evenly as they can be.  It returns
+// ctx.Err() if ctx is done before it finishes.
+func (g *Grid) MazifyFractal(ctx context.Context, rng *rand.Rand) error {
+	// patterns are by level, then by the shape of the small maze, which is
+	// only smaller than fractalBlock where a block is too thin to split.
+	type key struct{ level, rows, cols int }
+	patterns := map[key]*fractalPattern{}
+	pattern := func(level, rows, cols int) *fractalPattern {
+		k := key{level, rows, cols}
+		if p, ok := patterns[k]; ok {
+			return p
+		}
+		p := &fractalPattern{maze: newGrid(rows, cols), door: rng.Float64()}
+		MazifyGraphKruskal(context.Background(), &p.maze, rng)
+		patterns[k] = p
+		return p
+	}
+
+	var carve func(r region, level int) error
+	carve = func(r region, level int) error {
+		if r.rows == 1 && r.cols == 1 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rowSplit, colSplit := fractalSplit(r.row, r.rows), fractalSplit(r.col, r.cols)
+		p := pattern(level, len(rowSplit)-1, len(colSplit)-1)
+		for i := 0; i < p.maze.RowCount; i++ {
+			for j := 0; j < p.maze.ColCount; j++ {
+				top, bottom := rowSplit[i], rowSplit[i+1]
+				left, right := colSplit[j], colSplit[j+1]
+				cell := p.maze.openings(i, j)
+				if cell&E != 0 {
+					g.carve(top+int(p.door*float64(bottom-top)), right-1, E)
+				}
+				if cell&S != 0 {
+					g.carve(bottom-1, left+int(p.door*float64(right-left)), S)
+				}
+				if err := carve(region{top, left, bottom - top, right - left}, level+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return carve(region{0, 0, g.RowCount, g.ColCount}, 0)
+}
+
+// fractalSplit splits the n rows or columns from start into up to
+// fractalBlock blocks as close to the same size as they can be, and returns
+// where each starts followed by where the last ends.
+func fractalSplit(start, n int) []int {
+	parts := min(n, fractalBlock)
+	bounds := make([]int, parts+1)
+	for i := range bounds {
+		bounds[i] = start + i*n/parts
+	}
+	return bounds
+}
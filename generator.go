@@ -0,0 +1,129 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// Generator turns a fresh grid into a maze.
+type Generator interface {
+	Generate(g *Grid, rng *rand.Rand) error
+}
+
+// GeneratorFunc lets an ordinary function be used as a Generator.
+type GeneratorFunc func(g *Grid, rng *rand.Rand) error
+
+func (f GeneratorFunc) Generate(g *Grid, rng *rand.Rand) error {
+	return f(g, rng)
+}
+
+// BiasedGenerator is a Generator that can also be cancelled and told to
+// prefer carving in the direction given by bias.  Generators that don't
+// implement it run to completion without bias.
+type BiasedGenerator interface {
+	Generator
+	GenerateBiased(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error
+}
+
+// Generate runs gen on g, passing ctx and bias along if gen is a
+// BiasedGenerator.
+func Generate(ctx context.Context, gen Generator, g *Grid, rng *rand.Rand, bias float64) error {
+	if b, ok := gen.(BiasedGenerator); ok {
+		return b.GenerateBiased(ctx, g, rng, bias)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return gen.Generate(g, rng)
+}
+
+// mazifyFunc is a BiasedGenerator, the form the built in algorithms take.
+type mazifyFunc func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error
+
+func (f mazifyFunc) Generate(g *Grid, rng *rand.Rand) error {
+	return f(context.Background(), g, rng, NoBias)
+}
+
+func (f mazifyFunc) GenerateBiased(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+	return f(ctx, g, rng, bias)
+}
+
+// algorithms maps the name used with --algorithm to its Generator.
+var algorithms = map[string]Generator{
+	"rec": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return runSteps(ctx, NewBiasedRecStepper(g, rng, Cell{0, 0}, bias))
+	}),
+	"kruskal": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		if bias == NoBias {
+			return g.MazifyKruskalContext(ctx, rng)
+		}
+		return g.MazifyWeightedKruskalContext(ctx, rng, BiasWeight(rng, bias))
+	}),
+	// Parallel ignores bias.
+	"parallel": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return g.MazifyParallelContext(ctx, rng, defaultTileSize)
+	}),
+	"eller": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return g.mazifyEller(ctx, rng, bias)
+	}),
+	"spiral":      SpiralGenerator(SpiralPitch),
+	"growingtree": GrowingTreeGenerator(GrowingTreeTexture),
+	"division":    DivisionGenerator(0),
+	"blobby":      BlobbyGenerator(0),
+	// Prim ignores bias.
+	"prim": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return MazifyGraphPrim(ctx, g, rng, 0)
+	}),
+	// Wilson ignores bias.
+	"wilson": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return MazifyGraphWilson(ctx, g, rng, 0)
+	}),
+	// Fractal ignores bias.
+	"fractal": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return g.MazifyFractal(ctx, rng)
+	}),
+	// Origin shift ignores bias.
+	"originshift": mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return g.MazifyOriginShift(ctx, rng)
+	}),
+	"caves": CavesGenerator(CaveFill),
+}
+
+// ignoresBias names the built in algorithms that carve the same whatever
+// bias they're given.
+var ignoresBias = map[string]bool{
+	"parallel":    true,
+	"spiral":      true,
+	"blobby":      true,
+	"prim":        true,
+	"wilson":      true,
+	"fractal":     true,
+	"originshift": true,
+	"caves":       true,
+}
+
+// algorithmNames is the order algorithms are reported in.
+var algorithmNames = []string{"rec", "kruskal", "parallel", "eller", "spiral", "growingtree", "division", "blobby", "prim", "wilson", "fractal", "originshift", "caves"}
+
+// RegisterGenerator makes gen available as the algorithm name, listed after
+// the built in ones.  It panics if the name is already taken.
+func RegisterGenerator(name string, gen Generator) {
+	if _, ok := algorithms[name]; ok {
+		panic(fmt.Sprintf("algorithm %q already registered", name))
+	}
+	algorithms[name] = gen
+	algorithmNames = append(algorithmNames, name)
+}
+
+// LookupGenerator returns the algorithm registered as name.
+func LookupGenerator(name string) (Generator, bool) {
+	gen, ok := algorithms[name]
+	return gen, ok
+}
+
+// GeneratorNames returns the algorithm names, the built in ones first.
+func GeneratorNames() []string {
+	return slices.Clone(algorithmNames)
+}
@@ -0,0 +1,75 @@
+package maze
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Glyphs are the characters the unicode format draws walls with.
+type Glyphs struct {
+	// Corners are the characters for where walls meet, indexed by which
+	// walls meet there: 1 up, 2 right, 4 down, 8 left.
+	Corners [16]rune
+	// Horizontal and Vertical are the characters walls are drawn with
+	// between the corners.
+	Horizontal, Vertical rune
+}
+
+// glyphSets are the glyphs --glyphs picks by name.
+var glyphSets = map[string]Glyphs{
+	"single": newGlyphs(" ╵╶└╷│┌├╴┘─┴┐┤┬┼─│"),
+	// Double lines have no half walls, so walls ending at a corner go
+	// right through it.
+	"double": newGlyphs(" ║═╚║║╔╠═╝═╩╗╣╦╬═║"),
+	"heavy":  newGlyphs(" ╹╺┗╻┃┏┣╸┛━┻┓┫┳╋━┃"),
+	// ascii is for terminals and fonts without box drawing characters,
+	// with a + wherever walls meet.
+	"ascii": newGlyphs(" +++++++++++++++-|"),
+}
+
+// newGlyphs returns the glyphs s spells out: the sixteen Corners then
+// Horizontal and Vertical.  It panics if s isn't eighteen characters long.
+func newGlyphs(s string) Glyphs {
+	g, err := parseGlyphs(s)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// parseGlyphs returns the glyphs s spells out, as newGlyphs, or an error if
+// s isn't eighteen characters long.
+func parseGlyphs(s string) (Glyphs, error) {
+	chars := []rune(s)
+	var g Glyphs
+	if len(chars) != len(g.Corners)+2 {
+		return g, fmt.Errorf("glyphs %q are %d characters, not the %d corners then the horizontal and vertical walls", s, len(chars), len(g.Corners))
+	}
+	copy(g.Corners[:], chars)
+	g.Horizontal, g.Vertical = chars[16], chars[17]
+	return g, nil
+}
+
+// LookupGlyphs returns the glyph set named name, or if there's none,
+// the glyphs name spells out as parseGlyphs reads them.
+func LookupGlyphs(name string) (Glyphs, error) {
+	if g, ok := glyphSets[name]; ok {
+		return g, nil
+	}
+	g, err := parseGlyphs(name)
+	if err != nil {
+		return g, fmt.Errorf("unknown glyphs %q: want %s, or 18 characters", name, strings.Join(GlyphNames(), ", "))
+	}
+	return g, nil
+}
+
+// GlyphNames returns the names of the glyph sets, sorted.
+func GlyphNames() []string {
+	var names []string
+	for name := range glyphSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
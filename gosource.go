@@ -0,0 +1,69 @@
+package maze
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+)
+
+// renderGo writes the maze as a Go source file, for go:generate: the cells
+// as a string constant, its size, start and finish as constants, and
+// functions to read it, all named after opts.GoName in package
+// opts.GoPackage.  The file needs nothing but itself, so a game can compile
+// levels it's checked into its binary.
+func renderGo(g *Grid, w io.Writer, opts RenderOptions) error {
+	pkg, name := opts.GoPackage, opts.GoName
+	if pkg == "" {
+		pkg = "main"
+	}
+	if name == "" {
+		name = "Maze"
+	}
+	if !token.IsIdentifier(pkg) || !token.IsIdentifier(name) {
+		return fmt.Errorf("bad Go package %q or name %q", pkg, name)
+	}
+	start, finish := g.Endpoints()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by maze --format go; DO NOT EDIT.\n")
+	if opts.Info["rows"] != "" {
+		fmt.Fprintf(&b, "//\n// Made with: %s\n", RegenerateCommand(opts.Info))
+	}
+	fmt.Fprintf(&b, "\npackage %s\n\n", pkg)
+	fmt.Fprintf(&b, "// %[1]sRows and %[1]sCols are the size of %[1]s, and the Start and Finish\n", name)
+	fmt.Fprintf(&b, "// constants the cells it starts and finishes in.\n")
+	fmt.Fprintf(&b, "const (\n%sRows, %sCols = %d, %d\n", name, name, g.RowCount, g.ColCount)
+	fmt.Fprintf(&b, "%sStartRow, %sStartCol = %d, %d\n", name, name, start.Row, start.Col)
+	fmt.Fprintf(&b, "%sFinishRow, %sFinishCol = %d, %d\n)\n\n", name, name, finish.Row, finish.Col)
+	fmt.Fprintf(&b, "// %s is the maze's cells, a byte each, row by row.  Bits 1, 2, 4 and 8\n", name)
+	fmt.Fprintf(&b, "// are set for the cell's passages north, east, south and west.\n")
+	fmt.Fprintf(&b, "const %s = \"\" +\n", name)
+	for row := 0; row < g.RowCount; row++ {
+		b.WriteByte('"')
+		for _, cell := range g.data[g.CellId(row, 0):g.CellId(row+1, 0)] {
+			fmt.Fprintf(&b, "\\x%02x", cell)
+		}
+		b.WriteByte('"')
+		if row < g.RowCount-1 {
+			b.WriteString(" +")
+		}
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "\n// %[1]sOpen reports whether cell (row, col) of %[1]s has a passage in\n", name)
+	fmt.Fprintf(&b, "// direction dir: 1 for north, 2 east, 4 south and 8 west.\n")
+	fmt.Fprintf(&b, "func %[1]sOpen(row, col int, dir byte) bool {\nreturn %[1]s[row*%[1]sCols+col]&dir != 0\n}\n\n", name)
+	fmt.Fprintf(&b, "// Load%[1]s returns a copy of %[1]s's cells as a slice of rows.\n", name)
+	fmt.Fprintf(&b, "func Load%[1]s() [][]byte {\n", name)
+	fmt.Fprintf(&b, "cells := make([][]byte, %sRows)\n", name)
+	fmt.Fprintf(&b, "for row := range cells {\ncells[row] = []byte(%[1]s[row*%[1]sCols : (row+1)*%[1]sCols])\n}\n", name)
+	fmt.Fprintf(&b, "return cells\n}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
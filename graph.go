@@ -0,0 +1,271 @@
+package maze
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"time"
+)
+
+// Graph is anything a maze can be carved in: nodes numbered 0 to Nodes()-1,
+// each with up to Degree() sides, and the walls between neighbouring nodes
+// that can be carved away.  The MazifyGraph functions and the Kruskal and
+// backtracker Steppers run on any Graph, so a new shape of maze (hexagonal,
+// circular, 3D or masked) only has to say which cells are next to which.
+// Grid is a Graph with CellIds as its nodes and N, E, S and W as sides 0 to
+// 3.
+type Graph interface {
+	// Nodes returns the number of nodes.
+	Nodes() int
+	// Degree returns how many sides each node has.
+	Degree() int
+	// Neighbour returns the node on the given side of node, from 0 to
+	// Degree()-1, walled off or not, or -1 if there's none.
+	Neighbour(node, side int) int
+	// Connect carves away the wall between neighbouring nodes a and b.
+	Connect(a, b int)
+}
+
+// adjacentNodes appends the nodes next to node in gr, walled off or not,
+// to buf in the order of their sides, and returns it.
+func adjacentNodes(gr Graph, node int, buf []int) []int {
+	for side := 0; side < gr.Degree(); side++ {
+		if next := gr.Neighbour(node, side); next >= 0 {
+			buf = append(buf, next)
+		}
+	}
+	return buf
+}
+
+// gridSides are the directions of a grid cell's sides as a Graph node.
+var gridSides = [...]Direction{N, E, S, W}
+
+// gridWalls is a Graph laid out on a Grid, which can say where the wall on
+// a side of a node is, for a Stepper's Last.
+type gridWalls interface {
+	wall(node, side int) edge
+}
+
+// lastWall returns the wall on the given side of node as Last does, or
+// zeros if gr isn't laid out on a Grid.
+func lastWall(gr Graph, node, side int) (c Cell, d Direction) {
+	if w, ok := gr.(gridWalls); ok {
+		e := w.wall(node, side)
+		return Cell{e.row, e.col}, e.d
+	}
+	return Cell{}, 0
+}
+
+// Nodes returns the number of cells in the grid.
+func (g *Grid) Nodes() int {
+	return g.RowCount * g.ColCount
+}
+
+// Degree returns 4, for the N, E, S and W sides of a cell.
+func (g *Grid) Degree() int {
+	return len(gridSides)
+}
+
+// Neighbour returns the CellId of the cell on side gridSides[side] of the
+// cell id, or -1 at the edge of the grid.
+func (g *Grid) Neighbour(id, side int) int {
+	return gridNeighbour(id, side, g.RowCount, g.ColCount)
+}
+
+// gridNeighbour is Grid.Neighbour for a grid rows x cols.
+func gridNeighbour(id, side, rows, cols int) int {
+	switch side {
+	case 0:
+		if id >= cols {
+			return id - cols
+		}
+	case 1:
+		if id%cols != cols-1 {
+			return id + 1
+		}
+	case 2:
+		if id < (rows-1)*cols {
+			return id + cols
+		}
+	case 3:
+		if id%cols != 0 {
+			return id - 1
+		}
+	}
+	return -1
+}
+
+// Adjacent appends the CellIds of the cells next to the cell id, walled off
+// or not, to buf in N, E, S, W order.
+func (g *Grid) Adjacent(id int, buf []int) []int {
+	return adjacentNodes(g, id, buf)
+}
+
+// Connect removes the wall between the neighbouring cells a and b.
+func (g *Grid) Connect(a, b int) {
+	row, col := a/g.ColCount, a%g.ColCount
+	switch b - a {
+	case -g.ColCount:
+		g.carve(row, col, N)
+	case g.ColCount:
+		g.carve(row, col, S)
+	case 1:
+		g.carve(row, col, E)
+	case -1:
+		g.carve(row, col, W)
+	}
+}
+
+func (g *Grid) wall(id, side int) edge {
+	return edge{id / g.ColCount, id % g.ColCount, gridSides[side]}
+}
+
+// gridRegion is the cells of a grid in rows [rowStart, rowStart+rows) and
+// cols [colStart, colStart+cols) as a Graph, numbered row by row within
+// it, with the walls that leave it left out.
+type gridRegion struct {
+	g                  *Grid
+	rowStart, colStart int
+	rows, cols         int
+}
+
+func (r *gridRegion) Nodes() int  { return r.rows * r.cols }
+func (r *gridRegion) Degree() int { return len(gridSides) }
+
+func (r *gridRegion) Neighbour(node, side int) int {
+	return gridNeighbour(node, side, r.rows, r.cols)
+}
+
+func (r *gridRegion) Connect(a, b int) {
+	r.g.Connect(r.cellId(a), r.cellId(b))
+}
+
+// cellId returns the CellId in the whole grid of node.
+func (r *gridRegion) cellId(node int) int {
+	return r.g.CellId(r.rowStart+node/r.cols, r.colStart+node%r.cols)
+}
+
+func (r *gridRegion) wall(node, side int) edge {
+	return edge{r.rowStart + node/r.cols, r.colStart + node%r.cols, gridSides[side]}
+}
+
+func (r *gridRegion) debugLog() *slog.Logger {
+	return r.g.debugLog()
+}
+
+// graphEdge is the wall between nodes a and b of a Graph.
+type graphEdge struct {
+	a, b int
+}
+
+// MazifyGraphKruskal carves a maze in gr with Kruskal's algorithm, as a
+// KruskalStepper.  It gives up, leaving the maze partly carved, and returns
+// ctx.Err() if ctx is done before it finishes.
+func MazifyGraphKruskal(ctx context.Context, gr Graph, rng *rand.Rand) error {
+	s, err := newKruskalStepper(ctx, gr, rng, false)
+	if err != nil {
+		return err
+	}
+	return runSteps(ctx, s)
+}
+
+// MazifyGraphRec carves a maze in gr with recursive backtracking from the
+// node start, as a RecStepper, returning ctx.Err() like MazifyGraphKruskal.
+// It picks a neighbour at each step, as it did before it was a RecStepper,
+// so a seed carves the same shaped, surface, ragged and hybrid mazes.
+func MazifyGraphRec(ctx context.Context, gr Graph, rng *rand.Rand, start int) error {
+	return runSteps(ctx, newRecStepper(gr, rng, start, NoBias, true))
+}
+
+// MazifyGraphPrim carves a maze in gr with (randomised) Prim's algorithm,
+// growing it out from the node start by carving a random wall between the
+// maze so far and a node not in it yet, over and over.  It returns ctx.Err()
+// like MazifyGraphKruskal.
+func MazifyGraphPrim(ctx context.Context, gr Graph, rng *rand.Rand, start int) error {
+	inMaze := make([]bool, gr.Nodes())
+	var frontier []graphEdge
+	var adjacent []int
+	add := func(node int) {
+		inMaze[node] = true
+		adjacent = adjacentNodes(gr, node, adjacent[:0])
+		for _, next := range adjacent {
+			if !inMaze[next] {
+				frontier = append(frontier, graphEdge{node, next})
+			}
+		}
+	}
+	add(start)
+	log := graphLog(gr)
+	started, widest, stale := time.Now(), 0, 0
+	for i := 1; len(frontier) > 0; i++ {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		widest = max(widest, len(frontier))
+		k := rng.Intn(len(frontier))
+		e := frontier[k]
+		frontier[k] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+		if inMaze[e.b] {
+			stale++
+			continue
+		}
+		if log != nil {
+			log.Debug("prim: edge", "from", e.a, "to", e.b, "frontier", len(frontier))
+		}
+		gr.Connect(e.a, e.b)
+		add(e.b)
+	}
+	if log != nil {
+		// Stale edges led to nodes added since they joined the frontier.
+		log.Debug("prim: done", "widest-frontier", widest, "stale-edges", stale, "took", time.Since(started))
+	}
+	return nil
+}
+
+// MazifyGraphWilson carves a maze in gr with Wilson's algorithm: starting
+// with just the node start in the maze, it walks at random from a node not
+// in it yet until it hits the maze, erasing any loops the walk makes, and
+// carves the walk into the maze, over and over.  Unlike the others it picks
+// every possible maze (spanning tree) with equal probability.  It returns
+// ctx.Err() like MazifyGraphKruskal.
+func MazifyGraphWilson(ctx context.Context, gr Graph, rng *rand.Rand, start int) error {
+	inMaze := make([]bool, gr.Nodes())
+	inMaze[start] = true
+	// next is where the walk last left each node, so following it from
+	// where the walk began skips the loops.
+	next := make([]int, gr.Nodes())
+	var adjacent []int
+	steps := 0
+	log := graphLog(gr)
+	started, walks := time.Now(), 0
+	for _, from := range rng.Perm(gr.Nodes()) {
+		walked := steps
+		for node := from; !inMaze[node]; node = next[node] {
+			if steps++; steps%checkEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			adjacent = adjacentNodes(gr, node, adjacent[:0])
+			next[node] = adjacent[rng.Intn(len(adjacent))]
+		}
+		added := 0
+		for node := from; !inMaze[node]; node = next[node] {
+			gr.Connect(node, next[node])
+			inMaze[node] = true
+			added++
+		}
+		if log != nil && added > 0 {
+			// The steps walked that aren't added were loops erased.
+			walks++
+			log.Debug("wilson: walk", "from", from, "steps", steps-walked, "added", added)
+		}
+	}
+	if log != nil {
+		log.Debug("wilson: done", "walks", walks, "steps", steps, "took", time.Since(started))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package maze
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Grow adds rows to the bottom of the maze and cols to its right, and carves
+// the new cells Kruskal style, joining them up with each other and the old
+// maze without touching any of its walls.  Exactly one passage joins each
+// new part to what was already connected, so growing a perfect maze gives a
+// perfect maze.  Cells keep their metadata and the Entrances and Exits stay
+// where they were.
+func (g *Grid) Grow(rng *rand.Rand, rows, cols int) error {
+	if rows < 0 || cols < 0 {
+		return fmt.Errorf("can't grow a maze by %d rows and %d columns", rows, cols)
+	}
+	grown, err := NewGrid(g.RowCount+rows, g.ColCount+cols)
+	if err != nil {
+		return err
+	}
+	old := *g
+	*g = grown
+	g.Observer, g.History = old.Observer, old.History
+	// CellIds change with the number of columns.
+	moved := func(id int) int { return g.CellId(id/old.ColCount, id%old.ColCount) }
+	for id, cell := range old.data {
+		g.data[moved(id)] = cell
+	}
+	g.Entrances, g.Exits = old.Entrances, old.Exits
+	for id, values := range old.meta {
+		if g.meta == nil {
+			g.meta = map[int]map[string]string{}
+		}
+		g.meta[moved(id)] = values
+	}
+
+	isNew := func(row, col int) bool { return row >= old.RowCount || col >= old.ColCount }
+	sets := NewDisjointSet(len(g.data))
+	var edges []edge
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			for _, d := range []Direction{E, S} {
+				otherRow, otherCol := row+rowOffset[d], col+colOffset[d]
+				if !g.inside(otherRow, otherCol) {
+					continue
+				}
+				switch {
+				case g.openings(row, col)&d != 0:
+					sets.Union(g.CellId(row, col), g.CellId(otherRow, otherCol))
+				case isNew(row, col) || isNew(otherRow, otherCol):
+					edges = append(edges, edge{row, col, d})
+				}
+			}
+		}
+	}
+	rng.Shuffle(len(edges), func(i, j int) {
+		edges[i], edges[j] = edges[j], edges[i]
+	})
+	for _, e := range edges {
+		if sets.Union(g.CellId(e.row, e.col), g.CellId(e.row+rowOffset[e.d], e.col+colOffset[e.d])) {
+			g.carve(e.row, e.col, e.d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+)
+
+// GrowingTreeStepper is the growing tree algorithm as a Stepper.  It keeps a
+// list of cells that may still have uncarved neighbours, and each step
+// carves from one of them into a neighbour it hasn't visited, adding that to
+// the list, or drops it from the list if it has none.
+//
+// Which cell it carves from sets the feel of the maze: always the newest
+// makes it the recursive backtracker, with long winding passages, and always
+// a random one makes it like Prim's algorithm, with short bushy dead ends.
+// texture, from 0 to 1, is the chance of picking a random cell rather than
+// the newest, blending the two.  Like the backtracker's, its carving can
+// also lean north-south or east-west with a bias.
+type GrowingTreeStepper struct {
+	g       *Grid
+	rng     *rand.Rand
+	texture float64
+	bias    float64
+	// cells holds every cell ever added, in the order they were added, and
+	// listed counts which of them are still on the list, so the newest
+	// cell and a random one can both be found quickly.
+	cells  []int
+	listed fenwick
+	count  int // cells still on the list
+	last   edge
+}
+
+// GrowingTreeTexture is the texture the growingtree algorithm uses without
+// --texture, half way between the backtracker and Prim's.
+const GrowingTreeTexture = 0.5
+
+// GrowingTreeGenerator returns the growing tree algorithm with the given
+// texture, for --texture.
+func GrowingTreeGenerator(texture float64) Generator {
+	return mazifyFunc(func(ctx context.Context, g *Grid, rng *rand.Rand, bias float64) error {
+		return runSteps(ctx, NewBiasedGrowingTreeStepper(g, rng, Cell{0, 0}, texture, bias))
+	})
+}
+
+// NewGrowingTreeStepper returns a Stepper that carves g with the growing
+// tree algorithm starting from start, with the given texture.
+func NewGrowingTreeStepper(g *Grid, rng *rand.Rand, start Cell, texture float64) *GrowingTreeStepper {
+	return NewBiasedGrowingTreeStepper(g, rng, start, texture, NoBias)
+}
+
+// NewBiasedGrowingTreeStepper is NewGrowingTreeStepper with a preference
+// for carving in the direction given by bias.
+func NewBiasedGrowingTreeStepper(g *Grid, rng *rand.Rand, start Cell, texture, bias float64) *GrowingTreeStepper {
+	n := g.RowCount * g.ColCount
+	s := &GrowingTreeStepper{
+		g:       g,
+		rng:     rng,
+		texture: texture,
+		bias:    bias,
+		cells:   make([]int, 0, n),
+		listed:  make(fenwick, n+1),
+	}
+	s.add(g.CellIdOf(start))
+	return s
+}
+
+func (s *GrowingTreeStepper) add(id int) {
+	s.listed.add(len(s.cells), 1)
+	s.cells = append(s.cells, id)
+	s.count++
+}
+
+func (s *GrowingTreeStepper) Step() bool {
+	g := s.g
+	for s.count > 0 {
+		k := s.count - 1
+		if s.texture > 0 && s.rng.Float64() < s.texture {
+			k = s.rng.Intn(s.count)
+		}
+		i := s.listed.find(k)
+		row, col := s.cells[i]/g.ColCount, s.cells[i]%g.ColCount
+		dirs := [4]Direction{N, E, S, W}
+		if s.bias == NoBias {
+			s.rng.Shuffle(len(dirs), func(i, j int) { dirs[i], dirs[j] = dirs[j], dirs[i] })
+		} else {
+			shuffleBiased(s.rng, dirs[:], s.bias)
+		}
+		for _, d := range dirs {
+			nextRow, nextCol := row+rowOffset[d], col+colOffset[d]
+			if g.inside(nextRow, nextCol) && g.openings(nextRow, nextCol) == 0 {
+				g.carve(row, col, d)
+				s.last = edge{row, col, d}
+				s.add(g.CellId(nextRow, nextCol))
+				return true
+			}
+		}
+		s.listed.add(i, -1)
+		s.count--
+	}
+	return false
+}
+
+func (s *GrowingTreeStepper) Last() (c Cell, d Direction) {
+	return Cell{s.last.row, s.last.col}, s.last.d
+}
+
+// MazifyGrowingTree carves g with the growing tree algorithm, from long
+// rivers at texture 0 to short bushy passages at 1 (see GrowingTreeStepper).
+func (g *Grid) MazifyGrowingTree(rng *rand.Rand, texture float64) {
+	runSteps(context.Background(), NewGrowingTreeStepper(g, rng, Cell{0, 0}, texture))
+}
+
+// fenwick is a Fenwick tree of counts, where f[i+1] covers a range of
+// counts ending at i.
+type fenwick []int
+
+// add adds delta to count i.
+func (f fenwick) add(i, delta int) {
+	for i++; i < len(f); i += i & -i {
+		f[i] += delta
+	}
+}
+
+// find returns the index of the count that the kth unit, counting from 0,
+// falls in.
+func (f fenwick) find(k int) int {
+	step := 1
+	for step*2 < len(f) {
+		step *= 2
+	}
+	i := 0
+	for ; step > 0; step /= 2 {
+		if i+step < len(f) && f[i+step] <= k {
+			i += step
+			k -= f[i]
+		}
+	}
+	return i
+}
@@ -0,0 +1,53 @@
+package maze
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// renderHeatmap draws the maze as a greyscale PNG of how far each cell is
+// from the start, a CellSize square of pixels per cell: white at the start
+// fading to black at the furthest cell.  Cells that can't be reached are
+// black too.  Walls aren't drawn; the shading shows the passages.  A
+// Palette with a Heat gradient shades along that instead.
+func renderHeatmap(g *Grid, w io.Writer, opts RenderOptions) error {
+	size := opts.style().CellSize
+	start, _ := g.endpoints()
+	dist, _ := g.bfsFrom([]int{start})
+	furthest := 0
+	for _, d := range dist {
+		if d > furthest {
+			furthest = d
+		}
+	}
+	var img draw.Image = image.NewGray(image.Rect(0, 0, g.ColCount*size, g.RowCount*size))
+	shade := func(d int) color.Color {
+		switch {
+		case d >= 0 && furthest > 0:
+			return color.Gray{uint8(255 - 255*d/furthest)}
+		case d == 0:
+			return color.Gray{255}
+		}
+		return color.Gray{0}
+	}
+	if p := opts.Palette; p != nil && len(p.Heat) > 0 {
+		img = image.NewRGBA(img.Bounds())
+		shade = func(d int) color.Color {
+			switch {
+			case d >= 0 && furthest > 0:
+				return p.heat(float64(d) / float64(furthest))
+			case d == 0:
+				return p.heat(0)
+			}
+			return p.heat(1)
+		}
+	}
+	for id, d := range dist {
+		x, y := id%g.ColCount*size, id/g.ColCount*size
+		draw.Draw(img, image.Rect(x, y, x+size, y+size), image.NewUniform(shade(d)), image.Point{}, draw.Src)
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,147 @@
+package maze
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// History records the changes Link, Unlink, Braid, AddLoops and Straighten
+// make to a grid so they can be undone and redone.  Set Grid.History to start recording; the
+// generators don't record anything.
+type History struct {
+	undo, redo [][]linkOp
+	group      []linkOp // ops in the Batch being recorded, if any
+	batching   bool
+}
+
+// linkOp is one wall being removed (link) or put back (!link).
+type linkOp struct {
+	row, col int
+	d        Direction
+	link     bool
+}
+
+// record notes that op was applied.
+func (h *History) record(op linkOp) {
+	if h.batching {
+		h.group = append(h.group, op)
+		return
+	}
+	h.undo = append(h.undo, []linkOp{op})
+	h.redo = nil
+}
+
+// apply makes the change op describes without recording it.
+func (g *Grid) apply(op linkOp) {
+	if op.link {
+		g.carve(op.row, op.col, op.d)
+	} else {
+		g.data[g.CellId(op.row, op.col)] &^= uint8(op.d)
+		g.data[g.CellId(op.row+rowOffset[op.d], op.col+colOffset[op.d])] &^= uint8(opposite[op.d])
+	}
+}
+
+// Batch runs fn, recording everything it changes as a single step in the
+// grid's History, if it has one.
+func (g *Grid) Batch(fn func()) {
+	h := g.History
+	if h == nil || h.batching {
+		fn()
+		return
+	}
+	h.batching = true
+	fn()
+	h.batching = false
+	if len(h.group) > 0 {
+		h.undo = append(h.undo, h.group)
+		h.redo = nil
+	}
+	h.group = nil
+}
+
+// Undo reverts the most recent step in the grid's History, returning false if
+// there is nothing to undo.
+func (g *Grid) Undo() bool {
+	h := g.History
+	if h == nil || len(h.undo) == 0 {
+		return false
+	}
+	step := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	for i := len(step) - 1; i >= 0; i-- {
+		op := step[i]
+		op.link = !op.link
+		g.apply(op)
+	}
+	h.redo = append(h.redo, step)
+	return true
+}
+
+// Redo reapplies the most recently undone step, returning false if there is
+// nothing to redo.
+func (g *Grid) Redo() bool {
+	h := g.History
+	if h == nil || len(h.redo) == 0 {
+		return false
+	}
+	step := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	for _, op := range step {
+		g.apply(op)
+	}
+	h.undo = append(h.undo, step)
+	return true
+}
+
+// Braid removes dead ends to make loops: each dead end, with probability p,
+// gets a passage knocked through to one of its walled-off neighbours,
+// preferring neighbours that are dead ends too.  It's recorded as a single
+// History step.
+func (g *Grid) Braid(rng *rand.Rand, p float64) {
+	ends := slices.Collect(g.DeadEnds())
+	rng.Shuffle(len(ends), func(i, j int) { ends[i], ends[j] = ends[j], ends[i] })
+	g.Batch(func() {
+		for _, end := range ends {
+			row, col := end.Row, end.Col
+			// An earlier link may have already fixed this one.
+			if len(g.LinkedNeighbors(Cell{row, col})) != 1 || rng.Float64() >= p {
+				continue
+			}
+			var walled, deadEnds []Direction
+			for _, d := range []Direction{N, E, S, W} {
+				nextRow, nextCol := row+rowOffset[d], col+colOffset[d]
+				if !g.inside(nextRow, nextCol) || !g.HasWall(Cell{row, col}, d) {
+					continue
+				}
+				walled = append(walled, d)
+				if len(g.LinkedNeighbors(Cell{nextRow, nextCol})) == 1 {
+					deadEnds = append(deadEnds, d)
+				}
+			}
+			if len(deadEnds) > 0 {
+				walled = deadEnds
+			}
+			if len(walled) > 0 {
+				g.Link(Cell{row, col}, walled[rng.Intn(len(walled))])
+			}
+		}
+	})
+}
+
+// AddLoops knocks down each wall left between two cells with probability
+// p, making loops wherever they are rather than only at dead ends as Braid
+// does, so there are other ways round, for flanking in a game.  It's
+// recorded as a single History step.
+func (g *Grid) AddLoops(rng *rand.Rand, p float64) {
+	g.Batch(func() {
+		for row := 0; row < g.RowCount; row++ {
+			for col := 0; col < g.ColCount; col++ {
+				for _, d := range []Direction{E, S} {
+					if g.inside(row+rowOffset[d], col+colOffset[d]) && g.HasWall(Cell{row, col}, d) && rng.Float64() < p {
+						g.Link(Cell{row, col}, d)
+					}
+				}
+			}
+		}
+	})
+}
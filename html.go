@@ -0,0 +1,137 @@
+package maze
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlPage is a self-contained page that draws the maze on a canvas and lets
+// the player walk it with the arrow keys, or by clicking the cell next to
+// them, with a button to show the solution.
+var htmlPage = template.Must(template.New("maze").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Maze</title>
+<style>
+body { font-family: sans-serif; text-align: center; }
+canvas { margin: 1em; }
+.swatch { display: inline-block; width: 1em; height: 1em; vertical-align: middle; margin: 0 0.3em 0 1em; }
+</style>
+</head>
+<body>
+<div>
+<button id="solution">Show solution</button>
+<span id="status">Use the arrow keys or click to walk to the red square.</span>
+</div>
+<canvas id="maze"></canvas>
+{{if .Paths}}<div>{{range .Paths}}<span class="swatch" style="background: {{.Color}}"></span>{{.Label}}{{end}}</div>
+{{end}}<script>
+(function() {
+	var rows = {{.Rows}}, cols = {{.Cols}}, cells = {{.Cells}};
+	var start = {{.Start}}, end = {{.End}}, solution = {{.Solution}}, spine = {{.Spine}};
+	var paths = {{.Paths}};
+	var N = 1, E = 2, S = 4, W = 8;
+	var size = Math.max(4, Math.min(32, Math.floor(Math.min((window.innerWidth - 40) / cols, (window.innerHeight - 100) / rows))));
+	var canvas = document.getElementById("maze"), ctx = canvas.getContext("2d");
+	canvas.width = cols * size + 2;
+	canvas.height = rows * size + 2;
+	var at = start, trail = [start], showSolution = false;
+
+	function centre(id) {
+		return [1 + (id % cols + 0.5) * size, 1 + (Math.floor(id / cols) + 0.5) * size];
+	}
+	function fill(id, colour) {
+		ctx.fillStyle = colour;
+		ctx.fillRect(1 + id % cols * size, 1 + Math.floor(id / cols) * size, size, size);
+	}
+	function path(ids, colour, width) {
+		ctx.strokeStyle = colour;
+		ctx.lineWidth = Math.max(1, width || size / 4);
+		ctx.beginPath();
+		ids.forEach(function(id, i) {
+			var p = centre(id);
+			if (i == 0) ctx.moveTo(p[0], p[1]); else ctx.lineTo(p[0], p[1]);
+		});
+		ctx.stroke();
+	}
+	function draw() {
+		ctx.fillStyle = "white";
+		ctx.fillRect(0, 0, canvas.width, canvas.height);
+		fill(end, "#f88");
+		if (spine) path(spine, "#fb6");
+		(paths || []).forEach(function(p, i) { path(p.cells, p.color, size / 2 * (paths.length - i) / paths.length); });
+		if (showSolution) path(solution, "#8c8");
+		path(trail, "#88f");
+		fill(at, "#33c");
+		ctx.strokeStyle = "black";
+		ctx.lineWidth = 2;
+		ctx.beginPath();
+		ctx.moveTo(1, 1); ctx.lineTo(1 + cols * size, 1);
+		ctx.moveTo(1, 1); ctx.lineTo(1, 1 + rows * size);
+		for (var id = 0; id < rows * cols; id++) {
+			var x = 1 + id % cols * size, y = 1 + Math.floor(id / cols) * size;
+			if (!(cells[id] & S)) { ctx.moveTo(x, y + size); ctx.lineTo(x + size, y + size); }
+			if (!(cells[id] & E)) { ctx.moveTo(x + size, y); ctx.lineTo(x + size, y + size); }
+		}
+		ctx.stroke();
+	}
+	function move(d) {
+		if (!(cells[at] & d) || at == end) return;
+		at += {1: -cols, 2: 1, 4: cols, 8: -1}[d];
+		// Walking back the way we came rubs out the trail.
+		if (trail.length > 1 && trail[trail.length - 2] == at) trail.pop(); else trail.push(at);
+		if (at == end) document.getElementById("status").textContent = "Solved in " + (trail.length - 1) + " steps!";
+		draw();
+	}
+
+	document.addEventListener("keydown", function(e) {
+		var d = {ArrowUp: N, ArrowRight: E, ArrowDown: S, ArrowLeft: W}[e.key];
+		if (d) { e.preventDefault(); move(d); }
+	});
+	canvas.addEventListener("click", function(e) {
+		var r = canvas.getBoundingClientRect();
+		var col = Math.floor((e.clientX - r.left - 1) / size), row = Math.floor((e.clientY - r.top - 1) / size);
+		var id = row * cols + col;
+		if (id == at - cols) move(N);
+		else if (id == at + 1 && col > 0) move(E);
+		else if (id == at + cols) move(S);
+		else if (id == at - 1 && col < cols - 1) move(W);
+	});
+	document.getElementById("solution").addEventListener("click", function() {
+		showSolution = !showSolution;
+		this.textContent = showSolution ? "Hide solution" : "Show solution";
+		draw();
+	});
+	draw();
+})();
+</script>
+</body>
+</html>
+`))
+
+// renderHTML writes the maze as a web page it can be solved in.  It runs
+// from the grid's first entrance to its first exit, or corner to corner.  The
+// spine and the paths in opts, if any, are always shown.
+func renderHTML(g *Grid, w io.Writer, opts RenderOptions) error {
+	cells := make([]int, len(g.data))
+	for i, cell := range g.data {
+		cells[i] = int(cell)
+	}
+	type htmlPath struct {
+		Label string `json:"label"`
+		Cells []int  `json:"cells"`
+		Color string `json:"color"`
+	}
+	var paths []htmlPath
+	for i, p := range opts.Paths {
+		paths = append(paths, htmlPath{p.Label, g.CellIds(p.Cells), hexColor(opts.PathColor(i))})
+	}
+	start, end := g.endpoints()
+	return htmlPage.Execute(w, struct {
+		Rows, Cols, Start, End int
+		Cells, Solution, Spine []int
+		Paths                  []htmlPath
+	}{g.RowCount, g.ColCount, start, end, cells,
+		g.solvePath(g.CellOf(start), g.CellOf(end)), g.CellIds(opts.Spine), paths})
+}
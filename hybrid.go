@@ -0,0 +1,192 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+)
+
+// hybridSplits are the ways --hybrid-split divides a rows x cols grid into
+// n regions, each returning the region of every cell by CellId.
+var hybridSplits = map[string]func(rng *rand.Rand, rows, cols, n int) []int{
+	// columns splits it into n strips side by side, left to right.
+	"columns": func(rng *rand.Rand, rows, cols, n int) []int {
+		region := make([]int, rows*cols)
+		for id := range region {
+			region[id] = id % cols * n / cols
+		}
+		return region
+	},
+	// rows splits it into n strips one above another, top to bottom.
+	"rows": func(rng *rand.Rand, rows, cols, n int) []int {
+		region := make([]int, rows*cols)
+		for id := range region {
+			region[id] = id / cols * n / rows
+		}
+		return region
+	},
+	// random floods out from n cells picked at random, each cell joining
+	// the region of the one nearest it.
+	"random": func(rng *rand.Rand, rows, cols, n int) []int {
+		g := newGrid(rows, cols)
+		region := make([]int, rows*cols)
+		for id := range region {
+			region[id] = -1
+		}
+		queue := rng.Perm(len(region))[:min(n, len(region))]
+		for i, id := range queue {
+			region[id] = i
+		}
+		var adjacent []int
+		for i := 0; i < len(queue); i++ {
+			adjacent = g.Adjacent(queue[i], adjacent[:0])
+			for _, next := range adjacent {
+				if region[next] < 0 {
+					region[next] = region[queue[i]]
+					queue = append(queue, next)
+				}
+			}
+		}
+		return region
+	},
+}
+
+// HybridSplitNames returns the names of the hybridSplits, sorted.
+func HybridSplitNames() []string {
+	names := make([]string, 0, len(hybridSplits))
+	for name := range hybridSplits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HybridRegions divides a rows x cols grid into n regions the way split
+// says: one of hybridSplits, or else the name of a file drawing the regions
+// as lines of digits, 0 for the first region, 1 for the second and so on,
+// stretched to cover the grid.  It returns the region of every cell by
+// CellId.
+func HybridRegions(split string, rng *rand.Rand, rows, cols, n int) ([]int, error) {
+	if s, ok := hybridSplits[split]; ok {
+		return s(rng, rows, cols, n), nil
+	}
+	data, err := os.ReadFile(split)
+	if err != nil {
+		return nil, fmt.Errorf("--hybrid-split %q is neither %s nor a file: %v", split, strings.Join(HybridSplitNames(), ", "), err)
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	for i, line := range lines {
+		if len(line) != len(lines[0]) || len(line) == 0 {
+			return nil, fmt.Errorf("%s: line %d isn't as long as the first", split, i+1)
+		}
+		for _, c := range line {
+			if c < '0' || int(c-'0') >= n {
+				return nil, fmt.Errorf("%s: line %d has %q, not the digit of one of the %d regions", split, i+1, c, n)
+			}
+		}
+	}
+	region := make([]int, rows*cols)
+	for id := range region {
+		row, col := id/cols, id%cols
+		region[id] = int(lines[row*len(lines)/rows][col*len(lines[0])/cols] - '0')
+	}
+	return region, nil
+}
+
+// MazifyHybrid carves a different maze in each region of the grid, region
+// giving the region of every cell by CellId, with the algorithms named,
+// from shapeAlgorithms, one per region in order: so one part of a maze can
+// have Kruskal's short twisty passages and another the long rivers of
+// rec.  A region in more than one piece is carved a piece at a time.  The
+// pieces are then joined up through the walls between them with Kruskal's
+// algorithm, leaving one perfect maze.  It returns ctx.Err() if ctx is done
+// before it finishes.
+func (g *Grid) MazifyHybrid(ctx context.Context, rng *rand.Rand, region []int, algorithms []string) error {
+	carvers := make([]func(ctx context.Context, gr Graph, rng *rand.Rand, start int) error, len(algorithms))
+	for i, name := range algorithms {
+		carve, ok := shapeAlgorithms[name]
+		if !ok {
+			return fmt.Errorf("algorithm %q can't carve a region, only kruskal, prim, rec and wilson can", name)
+		}
+		carvers[i] = carve
+	}
+	if len(region) != len(g.data) {
+		return fmt.Errorf("%d regions for %d cells", len(region), len(g.data))
+	}
+	for _, r := range region {
+		if r < 0 || r >= len(algorithms) {
+			return fmt.Errorf("region %d has no algorithm, only %d are given", r, len(algorithms))
+		}
+	}
+
+	// Find the pieces: the cells of a region joined up within it.
+	piece := make([]int, len(g.data))
+	for id := range piece {
+		piece[id] = -1
+	}
+	var pieces [][]int
+	var adjacent []int
+	for id := range piece {
+		if piece[id] >= 0 {
+			continue
+		}
+		piece[id] = len(pieces)
+		cells := []int{id}
+		for i := 0; i < len(cells); i++ {
+			adjacent = g.Adjacent(cells[i], adjacent[:0])
+			for _, next := range adjacent {
+				if piece[next] < 0 && region[next] == region[id] {
+					piece[next] = len(pieces)
+					cells = append(cells, next)
+				}
+			}
+		}
+		pieces = append(pieces, cells)
+	}
+
+	// Carve each piece, as a maskGraph of just its cells.
+	nodes := make([]int, len(g.data))
+	for id := range nodes {
+		nodes[id] = -1
+	}
+	for _, cells := range pieces {
+		for node, id := range cells {
+			nodes[id] = node
+		}
+		mg := &maskGraph{grid: g, cells: cells, nodes: nodes}
+		if err := carvers[region[cells[0]]](ctx, mg, rng, 0); err != nil {
+			return err
+		}
+		for _, id := range cells {
+			nodes[id] = -1
+		}
+	}
+
+	// Each piece is a tree now, so joining them through one wall at a time
+	// where they're not yet joined leaves a tree.
+	sets := NewDisjointSet(len(pieces))
+	var walls []graphEdge
+	for id := range g.data {
+		adjacent = g.Adjacent(id, adjacent[:0])
+		for _, next := range adjacent {
+			if next > id && piece[next] != piece[id] {
+				walls = append(walls, graphEdge{id, next})
+			}
+		}
+	}
+	rng.Shuffle(len(walls), func(i, j int) { walls[i], walls[j] = walls[j], walls[i] })
+	for i, w := range walls {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if sets.Union(piece[w.a], piece[w.b]) {
+			g.Connect(w.a, w.b)
+		}
+	}
+	return nil
+}
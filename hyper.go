@@ -0,0 +1,263 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// hyperDimNames are the names the hyper command gives the dimensions past
+// rows and columns, in order.
+const hyperDimNames = "zwvutsrq"
+
+// HyperGrid is a maze in any number of dimensions from 2 up: rows and
+// columns, as in a Grid, then as many more as hyperDimNames has names for.
+// It's a Graph, so the MazifyGraph functions carve it, with a cell's node
+// counting columns fastest, then rows, then each dimension after in turn,
+// so each 2D slice of it is a block of nodes laid out like a Grid's
+// CellIds.
+type HyperGrid struct {
+	// Dims is the size of each dimension: rows, columns, then the rest.
+	Dims []int
+	// strides is how far apart, in nodes, neighbours along each dimension
+	// are.
+	strides []int
+	// data is the openings of each node: N, E, S and W within its slice,
+	// then for each further dimension k hyperUp(k) and hyperDown(k).
+	data []uint32
+}
+
+// hyperUp and hyperDown are the bits of a HyperGrid cell open to the next
+// and previous slices along dimension k, from 2 up.
+func hyperUp(k int) uint32   { return 1 << (2 * k) }
+func hyperDown(k int) uint32 { return 1 << (2*k + 1) }
+
+// NewHyperGrid returns a maze the size of dims, rows and columns first,
+// with every wall up.
+func NewHyperGrid(dims []int) (*HyperGrid, error) {
+	if len(dims) < 2 || len(dims) > 2+len(hyperDimNames) {
+		return nil, fmt.Errorf("a hypermaze needs 2 to %d dimensions, not %d", 2+len(hyperDimNames), len(dims))
+	}
+	h := &HyperGrid{Dims: dims, strides: make([]int, len(dims))}
+	cells := 1
+	for _, n := range dims {
+		if n < 1 {
+			return nil, fmt.Errorf("bad size %d: every dimension needs at least one cell", n)
+		}
+		cells *= n
+		if cells > maxGridCells {
+			return nil, fmt.Errorf("a hypermaze with over %d cells is too big", maxGridCells)
+		}
+	}
+	h.strides[0], h.strides[1] = dims[1], 1
+	stride := dims[0] * dims[1]
+	for k := 2; k < len(dims); k++ {
+		h.strides[k] = stride
+		stride *= dims[k]
+	}
+	h.data = make([]uint32, cells)
+	return h, nil
+}
+
+// coord returns where node is along dimension k.
+func (h *HyperGrid) coord(node, k int) int {
+	return node / h.strides[k] % h.Dims[k]
+}
+
+// step returns the bit of a node open along dimension k in direction dir,
+// 1 or -1.
+func (h *HyperGrid) step(k, dir int) uint32 {
+	switch {
+	case k == 0 && dir < 0:
+		return uint32(N)
+	case k == 0:
+		return uint32(S)
+	case k == 1 && dir < 0:
+		return uint32(W)
+	case k == 1:
+		return uint32(E)
+	case dir < 0:
+		return hyperDown(k)
+	}
+	return hyperUp(k)
+}
+
+func (h *HyperGrid) Nodes() int {
+	return len(h.data)
+}
+
+// Degree returns two sides for each dimension, down and up it.
+func (h *HyperGrid) Degree() int {
+	return 2 * len(h.Dims)
+}
+
+// Neighbour returns the node down dimension side/2 from node if side is
+// even and up it if it's odd, or -1 at the edge of the grid.
+func (h *HyperGrid) Neighbour(node, side int) int {
+	k, c := side/2, h.coord(node, side/2)
+	switch {
+	case side%2 == 0 && c > 0:
+		return node - h.strides[k]
+	case side%2 == 1 && c < h.Dims[k]-1:
+		return node + h.strides[k]
+	}
+	return -1
+}
+
+// Connect removes the wall between the neighbouring nodes a and b.
+func (h *HyperGrid) Connect(a, b int) {
+	if a > b {
+		a, b = b, a
+	}
+	for k, stride := range h.strides {
+		if b-a == stride && h.coord(a, k) < h.Dims[k]-1 {
+			h.data[a] |= h.step(k, 1)
+			h.data[b] |= h.step(k, -1)
+			return
+		}
+	}
+}
+
+// Mazify carves a maze through every dimension with the named algorithm,
+// one of shapeAlgorithms.  It returns ctx.Err() if ctx is done before it
+// finishes.
+func (h *HyperGrid) Mazify(ctx context.Context, rng *rand.Rand, algorithm string) error {
+	carve, ok := shapeAlgorithms[algorithm]
+	if !ok {
+		return fmt.Errorf("algorithm %q can't carve hypermazes, only kruskal, prim, rec and wilson can", algorithm)
+	}
+	return carve(ctx, h, rng, 0)
+}
+
+// Solve returns the shortest path from the first node, the corner where
+// every coordinate is 0, to the last, the opposite corner, as nodes.  It's
+// nil if there's no way.
+func (h *HyperGrid) Solve() []int {
+	parent := make([]int, len(h.data))
+	for i := range parent {
+		parent[i] = -1
+	}
+	end := len(h.data) - 1
+	parent[0] = 0
+	queue := []int{0}
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		if node == end {
+			return walkBack(parent, end)
+		}
+		for k, stride := range h.strides {
+			for _, dir := range [...]int{-1, 1} {
+				if h.data[node]&h.step(k, dir) == 0 {
+					continue
+				}
+				if next := node + dir*stride; parent[next] < 0 {
+					parent[next] = node
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Fprint writes the maze to w as text: each 2D slice drawn like Fprint
+// draws a Grid, the slices along z side by side and along the other
+// dimensions one under another, each under its coordinates.  Each cell has
+// a character for each dimension past the second, in order, showing its
+// passages to the other slices: + to the next, - to the previous and * to
+// both; otherwise it's the cell's south wall as usual, or . for a cell on
+// path.
+func (h *HyperGrid) Fprint(w io.Writer, path []int) error {
+	rows, cols := h.Dims[0], h.Dims[1]
+	extra := len(h.Dims) - 2
+	width := max(extra, 1)
+	onPath := make([]bool, len(h.data))
+	for _, node := range path {
+		onPath[node] = true
+	}
+	slice := rows * cols
+	across := 1
+	if extra > 0 {
+		across = h.Dims[2]
+	}
+	lineWidth := 1 + cols*(width+1)
+	// Slices are spaced out by three spaces, or more if their coordinates
+	// are wider than they are.
+	labelWidth := -1
+	for k := 2; k < len(h.Dims); k++ {
+		labelWidth += len(" z=") + len(strconv.Itoa(h.Dims[k]-1))
+	}
+	gap := strings.Repeat(" ", 3+max(labelWidth-lineWidth, 0))
+
+	var buf strings.Builder
+	for first := 0; first < len(h.data); first += slice * across {
+		// The coordinates of each slice, then its top wall, then its rows.
+		for s := 0; s < across && extra > 0; s++ {
+			var label []string
+			for k := 2; k < len(h.Dims); k++ {
+				label = append(label, fmt.Sprintf("%c=%d", hyperDimNames[k-2], h.coord(first+s*slice, k)))
+			}
+			text := strings.Join(label, " ")
+			buf.WriteString(text + strings.Repeat(" ", lineWidth+len(gap)-len(text)))
+			if s == across-1 {
+				buf.WriteByte('\n')
+			}
+		}
+		for s := 0; s < across; s++ {
+			buf.WriteString(" " + strings.Repeat("_", lineWidth-2) + " " + gap)
+		}
+		buf.WriteByte('\n')
+		for row := 0; row < rows; row++ {
+			for s := 0; s < across; s++ {
+				start := first + s*slice + row*cols
+				buf.WriteByte('|')
+				for col := 0; col < cols; col++ {
+					node := start + col
+					cell := h.data[node]
+					floor := byte('_')
+					if cell&uint32(S) != 0 {
+						floor = ' '
+					}
+					for k := 2; k < 2+width; k++ {
+						up, down := k < len(h.Dims) && cell&hyperUp(k) != 0, k < len(h.Dims) && cell&hyperDown(k) != 0
+						switch {
+						case up && down:
+							buf.WriteByte('*')
+						case up:
+							buf.WriteByte('+')
+						case down:
+							buf.WriteByte('-')
+						case onPath[node]:
+							buf.WriteByte('.')
+						default:
+							buf.WriteByte(floor)
+						}
+					}
+					// The east wall, joined up with the floors either side
+					// as Fprint does.
+					switch {
+					case cell&uint32(E) == 0:
+						buf.WriteByte('|')
+					case (cell|h.data[node+1])&uint32(S) != 0:
+						buf.WriteByte(' ')
+					default:
+						buf.WriteByte('_')
+					}
+				}
+				buf.WriteString(gap)
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+	}
+	// Take off the gaps after the last slices and the blank line at the end.
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
@@ -0,0 +1,124 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// slide moves from cell (row, col) in direction d the way a player on ice
+// does: on until a wall stops them.  It returns the cells passed through,
+// as CellIds, not including the one slid from, and stops early on
+// reaching the cell finish, which ends the game.  The slide is empty if
+// there's a wall on the d side of the cell.
+func (g *Grid) slide(row, col int, d Direction, finish int, buf []int) []int {
+	for g.walkable(row, col)&d != 0 {
+		row, col = row+rowOffset[d], col+colOffset[d]
+		buf = append(buf, g.CellId(row, col))
+		if buf[len(buf)-1] == finish {
+			break
+		}
+	}
+	return buf
+}
+
+// Slide returns the cells a player on ice passes through sliding from c in
+// direction d until a wall stops them, not including c.  It stops early on
+// reaching finish, which ends the game, and is empty if there's a wall on
+// the d side of c.
+func (g *Grid) Slide(c Cell, d Direction, finish Cell) []Cell {
+	return g.PathCells(g.slide(c.Row, c.Col, d, g.CellIdOf(finish), nil))
+}
+
+// SlideMoves returns the fewest slides it takes to get from start to finish
+// on ice, where each move slides the player until a wall stops them and
+// they win by sliding onto or over the finish, as the cells each slide
+// stops at, the start first and the finish last.  It's nil if the finish
+// can't be reached.
+func (g *Grid) SlideMoves(start, finish Cell) []Cell {
+	stops, _ := g.slideMoves(context.Background(), start, finish)
+	return g.PathCells(stops)
+}
+
+// slideMoves is SlideMoves, returning the stops as CellIds and giving up
+// with ctx.Err() if ctx is done before it finishes.
+func (g *Grid) slideMoves(ctx context.Context, start, finish Cell) ([]int, error) {
+	from, end := g.CellIdOf(start), g.CellIdOf(finish)
+	parent := make([]int, len(g.data))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[from] = from
+	queue := []int{from}
+	var buf []int
+	for i := 0; i < len(queue); i++ {
+		if i%checkEvery == checkEvery-1 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		id := queue[i]
+		if g.Observer != nil {
+			g.Observer.visit(g, id)
+		}
+		if id == end {
+			return walkBack(parent, end), nil
+		}
+		for _, d := range [...]Direction{N, E, S, W} {
+			buf = g.slide(id/g.ColCount, id%g.ColCount, d, end, buf[:0])
+			if len(buf) == 0 {
+				continue
+			}
+			if stop := buf[len(buf)-1]; parent[stop] < 0 {
+				parent[stop] = id
+				queue = append(queue, stop)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// SolveSliding finds the way from start to finish with the fewest slides
+// on ice, as SlideMoves does, and returns every cell it slides through, so
+// it can be drawn like any other path.  It's nil if there's no way.
+func (g *Grid) SolveSliding(start, finish Cell) []Cell {
+	path, _ := g.solveSliding(context.Background(), start, finish)
+	return g.PathCells(path)
+}
+
+// solveSliding is SolveSliding, returning the path as CellIds and giving
+// up with ctx.Err() if ctx is done before it finishes.
+func (g *Grid) solveSliding(ctx context.Context, start, finish Cell) ([]int, error) {
+	stops, err := g.slideMoves(ctx, start, finish)
+	if stops == nil {
+		return nil, err
+	}
+	path := stops[:1:1]
+	end := g.CellIdOf(finish)
+	for _, stop := range stops[1:] {
+		from := path[len(path)-1]
+		for _, d := range [...]Direction{N, E, S, W} {
+			cells := g.slide(from/g.ColCount, from%g.ColCount, d, end, nil)
+			if len(cells) > 0 && cells[len(cells)-1] == stop {
+				path = append(path, cells...)
+				break
+			}
+		}
+	}
+	return path, nil
+}
+
+// MazifySliding runs gen on g, starting over until the maze can be solved
+// on ice, from its start to its finish, in at least minSlides slides.
+func MazifySliding(ctx context.Context, g *Grid, rng *rand.Rand, bias float64, gen Generator, minSlides int) error {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		g.Clear()
+		if err := Generate(ctx, gen, g, rng, bias); err != nil {
+			return err
+		}
+		if stops := g.SlideMoves(g.Endpoints()); stops != nil && len(stops)-1 >= minSlides {
+			return nil
+		}
+	}
+	return fmt.Errorf("no maze that can be solved on ice in %d or more slides in %d attempts", minSlides, maxAttempts)
+}
@@ -0,0 +1,207 @@
+package maze
+
+import (
+	"container/heap"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+)
+
+// SolveImage solves a picture of a maze: dark walls on a light background,
+// entered and left through the two biggest gaps in its outer wall.  It works
+// on the pixels directly, so the maze doesn't need to be on a grid, and
+// returns a copy of img with the solution drawn over it in red.
+func SolveImage(img image.Image) (*image.RGBA, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	wall := thresholdImage(img)
+
+	// Crop to the walls, so the border of the crop is the outer wall.
+	x0, y0, x1, y1 := w, h, -1, -1
+	for i, dark := range wall {
+		if x, y := i%w, i/w; dark {
+			if x < x0 {
+				x0 = x
+			}
+			if x > x1 {
+				x1 = x
+			}
+			if y < y0 {
+				y0 = y
+			}
+			if y > y1 {
+				y1 = y
+			}
+		}
+	}
+	if x1 < 0 {
+		return nil, errors.New("no maze walls found")
+	}
+
+	// Walk the border clockwise and find the gaps in it.
+	var border []int
+	for x := x0; x < x1; x++ {
+		border = append(border, y0*w+x)
+	}
+	for y := y0; y < y1; y++ {
+		border = append(border, y*w+x1)
+	}
+	for x := x1; x > x0; x-- {
+		border = append(border, y1*w+x)
+	}
+	for y := y1; y > y0; y-- {
+		border = append(border, y*w+x0)
+	}
+	first := -1
+	for i, p := range border {
+		if wall[p] {
+			first = i
+			break
+		}
+	}
+	var gaps [][]int
+	for i := 0; i < len(border); i++ {
+		p := border[(first+i)%len(border)]
+		switch {
+		case wall[p]:
+		case i > 0 && !wall[border[(first+i-1)%len(border)]]:
+			gaps[len(gaps)-1] = append(gaps[len(gaps)-1], p)
+		default:
+			gaps = append(gaps, []int{p})
+		}
+	}
+	if len(gaps) < 2 {
+		return nil, errors.New("couldn't find an entrance and an exit in the outer wall")
+	}
+	sort.SliceStable(gaps, func(i, j int) bool { return len(gaps[i]) > len(gaps[j]) })
+
+	clear := clearance(wall, w, x0, y0, x1, y1)
+	path := cheapestPixelPath(wall, clear, w, x0, y0, x1, y1, gaps[0][len(gaps[0])/2], gaps[1])
+	if path == nil {
+		return nil, errors.New("no path from the entrance to the exit")
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	red := image.NewUniform(color.RGBA{220, 20, 20, 255})
+	// Draw it about half as thick as the corridors, so it's easy to see.
+	widths := make([]int, len(path))
+	for i, p := range path {
+		widths[i] = clear[p]
+	}
+	sort.Ints(widths)
+	r := widths[len(widths)/2] / 2
+	for _, p := range path {
+		x, y := p%w, p/w
+		draw.Draw(out, image.Rect(x-r, y-r, x+r+1, y+r+1), red, image.Point{}, draw.Src)
+	}
+	return out, nil
+}
+
+// thresholdImage returns which pixels of img are dark, in row order, using
+// Otsu's method to pick the level between dark and light.
+func thresholdImage(img image.Image) []bool {
+	b := img.Bounds()
+	gray := make([]uint8, 0, b.Dx()*b.Dy())
+	var hist [256]int
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			v := color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+			gray = append(gray, v)
+			hist[v]++
+		}
+	}
+	total, sum := len(gray), 0
+	for v, n := range hist {
+		sum += v * n
+	}
+	best, level := -1.0, 127
+	darkCount, darkSum := 0, 0
+	for v, n := range hist {
+		darkCount += n
+		darkSum += v * n
+		if darkCount == 0 || darkCount == total {
+			continue
+		}
+		lightCount := total - darkCount
+		darkMean := float64(darkSum) / float64(darkCount)
+		lightMean := float64(sum-darkSum) / float64(lightCount)
+		if between := float64(darkCount) * float64(lightCount) * (darkMean - lightMean) * (darkMean - lightMean); between > best {
+			best, level = between, v
+		}
+	}
+	wall := make([]bool, total)
+	for i, v := range gray {
+		wall[i] = int(v) <= level
+	}
+	return wall
+}
+
+// clearance returns the number of steps from each light pixel in the box
+// from (x0, y0) to (x1, y1) to the nearest wall.
+func clearance(wall []bool, w, x0, y0, x1, y1 int) []int {
+	clear := make([]int, len(wall))
+	var queue []int
+	for i := range clear {
+		clear[i] = -1
+		if x, y := i%w, i/w; wall[i] && x >= x0 && x <= x1 && y >= y0 && y <= y1 {
+			clear[i] = 0
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, q := range []int{p - w, p + 1, p + w, p - 1} {
+			if x, y := q%w, q/w; q >= 0 && q < len(wall) && x >= x0 && x <= x1 && y >= y0 && y <= y1 && clear[q] < 0 && abs(q%w-p%w) <= 1 {
+				clear[q] = clear[p] + 1
+				queue = append(queue, q)
+			}
+		}
+	}
+	return clear
+}
+
+// cheapestPixelPath finds a path through the light pixels in the box from
+// (x0, y0) to (x1, y1), from start to any of the pixels in ends, with
+// Dijkstra's algorithm.  Pixels close to walls cost more, which keeps the
+// path in the middle of the corridors.
+func cheapestPixelPath(wall []bool, clear []int, w, x0, y0, x1, y1, start int, ends []int) []int {
+	isEnd := make([]bool, len(wall))
+	for _, p := range ends {
+		isEnd[p] = true
+	}
+	dist := make([]float64, len(wall))
+	parent := make([]int, len(wall))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[start] = start
+	queue := &costQueue{{start, 0}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(costItem)
+		p := item.id
+		if item.cost > dist[p] {
+			continue // stale
+		}
+		if isEnd[p] {
+			return walkBack(parent, p)
+		}
+		for _, q := range []int{p - w, p + 1, p + w, p - 1} {
+			x, y := q%w, q/w
+			if q < 0 || q >= len(wall) || x < x0 || x > x1 || y < y0 || y > y1 || wall[q] || abs(x-p%w) > 1 {
+				continue
+			}
+			c := float64(clear[q])
+			cost := item.cost + 1 + 16/(c*c)
+			if parent[q] < 0 || cost < dist[q] {
+				dist[q] = cost
+				parent[q] = p
+				heap.Push(queue, costItem{q, cost})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package maze
+
+import (
+	"bytes"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// svgInfo matches the comments renderSVG writes for RenderOptions.Info, and
+// pdfInfo the entries pdfDoc writes in the document information.
+var (
+	svgInfo = regexp.MustCompile(`<!-- ` + infoPrefix + `([^=]+)=(.*) -->`)
+	pdfInfo = regexp.MustCompile(`/` + infoPrefix + `(\S+) \(((?:[^\\)]|\\.)*)\)`)
+)
+
+// ReadInfo returns the RenderOptions.Info embedded in a rendered SVG, PNG,
+// PDF or DXF file.
+func ReadInfo(b []byte) (map[string]string, error) {
+	info := map[string]string{}
+	if bytes.HasPrefix(b, []byte("\x89PNG")) {
+		text, err := readPNGText(b)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range text {
+			if strings.HasPrefix(key, infoPrefix) {
+				info[strings.TrimPrefix(key, infoPrefix)] = value
+			}
+		}
+		return info, nil
+	}
+	if bytes.HasPrefix(b, []byte("%PDF")) {
+		unquote := regexp.MustCompile(`\\(.)`)
+		for _, m := range pdfInfo.FindAllSubmatch(b, -1) {
+			info[string(m[1])] = string(unquote.ReplaceAll(m[2], []byte("$1")))
+		}
+		return info, nil
+	}
+	if bytes.HasPrefix(b, []byte("999")) {
+		for _, m := range dxfInfo.FindAllSubmatch(b, -1) {
+			info[string(m[1])] = string(m[2])
+		}
+		return info, nil
+	}
+	for _, m := range svgInfo.FindAllSubmatch(b, -1) {
+		info[string(m[1])] = string(m[2])
+	}
+	return info, nil
+}
+
+// RegenerateCommand returns the command that makes the maze with info
+// again.
+func RegenerateCommand(info map[string]string) string {
+	cmd := []string{"maze"}
+	if info["command"] != "" {
+		cmd = append(cmd, info["command"])
+	}
+	if _, ok := info["generator-version"]; !ok && info["seed"] != "" {
+		// Mazes from before it was recorded were made by version 1.
+		info = maps.Clone(info)
+		info["generator-version"] = "1"
+	}
+	for _, key := range sortedKeys(info) {
+		switch key {
+		case "rows", "cols", "command":
+		case "waypoints":
+			for _, waypoint := range strings.Fields(info[key]) {
+				cmd = append(cmd, "--waypoint="+waypoint)
+			}
+		default:
+			cmd = append(cmd, shellQuote("--"+key+"="+info[key]))
+		}
+	}
+	switch info["command"] {
+	case "book":
+		cmd = append(cmd, "book.pdf")
+	case "poster":
+		cmd = append(cmd, "--rows="+info["rows"], "--cols="+info["cols"], "poster.pdf")
+	default:
+		cmd = append(cmd, info["rows"], info["cols"])
+	}
+	return strings.Join(cmd, " ")
+}
+
+// shellQuote quotes s for a shell if it needs it, e.g. for --text with
+// spaces in.
+func shellQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\n'\"\\$`!*?&;|<>()[]{}#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,153 @@
+// Package protowire is just enough of the protocol buffer wire format for
+// the messages in maze.proto, so the gRPC server and Grid.MarshalProto
+// don't need any dependencies.
+package protowire
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Wire types.
+const (
+	Varint  = 0
+	Fixed64 = 1
+	Bytes   = 2
+	Fixed32 = 5
+)
+
+var ErrMalformed = errors.New("malformed protocol buffer")
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field, wire int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// AppendInt appends an integer field, leaving it out if it's 0 as proto3
+// does.
+func AppendInt(b []byte, field int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendVarint(appendTag(b, field, Varint), uint64(v))
+}
+
+// AppendBytes appends a bytes, string or message field, leaving it out if
+// it's empty.
+func AppendBytes(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = appendVarint(appendTag(b, field, Bytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendInts appends a packed repeated integer field.
+func AppendInts(b []byte, field int, vs []int) []byte {
+	var packed []byte
+	for _, v := range vs {
+		packed = appendVarint(packed, uint64(v))
+	}
+	return AppendBytes(b, field, packed)
+}
+
+// Field is one field of an encoded message.  Varint and fixed fields
+// have their value in V, bytes fields in Data.
+type Field struct {
+	Num, Wire int
+	V         uint64
+	Data      []byte
+}
+
+// readVarint decodes the varint at the start of b, returning it and its
+// length, or a length of 0 if it isn't a valid varint.
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// Read calls fn with each field of the encoded message b, in order.
+func Read(b []byte, fn func(f Field) error) error {
+	for len(b) > 0 {
+		tag, n := readVarint(b)
+		if n == 0 || tag>>3 == 0 {
+			return ErrMalformed
+		}
+		b = b[n:]
+		f := Field{Num: int(tag >> 3), Wire: int(tag & 7)}
+		switch f.Wire {
+		case Varint:
+			if f.V, n = readVarint(b); n == 0 {
+				return ErrMalformed
+			}
+		case Fixed64:
+			if n = 8; len(b) < n {
+				return ErrMalformed
+			}
+			f.V = binary.LittleEndian.Uint64(b)
+		case Fixed32:
+			if n = 4; len(b) < n {
+				return ErrMalformed
+			}
+			f.V = uint64(binary.LittleEndian.Uint32(b))
+		case Bytes:
+			size, m := readVarint(b)
+			if m == 0 || size > uint64(len(b)-m) {
+				return ErrMalformed
+			}
+			f.Data = b[m : m+int(size)]
+			n = m + int(size)
+		default:
+			return ErrMalformed
+		}
+		b = b[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Int32 returns the value of an int32 field.
+func (f Field) Int32() int {
+	return int(int32(f.V))
+}
+
+// Double returns the value of a double field.
+func (f Field) Double() float64 {
+	return math.Float64frombits(f.V)
+}
+
+// AppendInt32s appends the values of a repeated int32 field to vs.  The
+// values may be packed into one field or each in their own.
+func (f Field) AppendInt32s(vs []int) ([]int, error) {
+	if f.Wire == Varint {
+		return append(vs, f.Int32()), nil
+	}
+	if f.Wire != Bytes {
+		return nil, ErrMalformed
+	}
+	for b := f.Data; len(b) > 0; {
+		v, n := readVarint(b)
+		if n == 0 {
+			return nil, ErrMalformed
+		}
+		vs = append(vs, int(int32(v)))
+		b = b[n:]
+	}
+	return vs, nil
+}
@@ -0,0 +1,139 @@
+package maze
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// The text formats other maze tools use, so mazes can be passed back and
+// forth.  ParseMaze reads both.
+
+// renderMazelib draws the maze the way mazelib, the Python maze library,
+// prints one with tostring(entrances=True): a grid 2*rows+1 by 2*cols+1 of
+// # for walls and spaces for passages, with every corner a wall and S and E
+// in the outer wall beside the start and finish.  A start or finish away
+// from the edge is marked in its cell.
+func renderMazelib(g *Grid, w io.Writer, opts RenderOptions) error {
+	height, width := 2*g.RowCount+1, 2*g.ColCount+1
+	lines := make([][]byte, height)
+	for y := range lines {
+		lines[y] = bytes.Repeat([]byte("#"), width)
+	}
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			y, x := 2*row+1, 2*col+1
+			lines[y][x] = ' '
+			if col < g.ColCount-1 && g.openings(row, col)&E != 0 {
+				lines[y][x+1] = ' '
+			}
+			if row < g.RowCount-1 && g.openings(row, col)&S != 0 {
+				lines[y+1][x] = ' '
+			}
+		}
+	}
+	start, end := g.endpoints()
+	for _, m := range []struct {
+		id     int
+		marker byte
+	}{{start, 'S'}, {end, 'E'}} {
+		row, col := m.id/g.ColCount, m.id%g.ColCount
+		y, x := 2*row+1, 2*col+1
+		switch {
+		case row == 0:
+			y = 0
+		case col == 0:
+			x = 0
+		case row == g.RowCount-1:
+			y = height - 1
+		case col == g.ColCount-1:
+			x = width - 1
+		}
+		lines[y][x] = m.marker
+	}
+	_, err := w.Write(append(bytes.Join(lines, []byte("\n")), '\n'))
+	return err
+}
+
+// renderMFP draws the maze in the text format of Jamis Buck's Mazes for
+// Programmers: + for corners, --- and | for walls, and cells three
+// characters wide with labels in the middle.
+func renderMFP(g *Grid, w io.Writer, opts RenderOptions) error {
+	if opts.Path != nil {
+		marked := g.Clone()
+		marked.markPath(g.CellIds(opts.Path), opts.Arrows)
+		g = &marked
+	}
+	buf := []byte("+")
+	for col := 0; col < g.ColCount; col++ {
+		buf = append(buf, "---+"...)
+	}
+	buf = append(buf, '\n')
+	for row := 0; row < g.RowCount; row++ {
+		buf = append(buf, '|')
+		for col := 0; col < g.ColCount; col++ {
+			label := " "
+			if s, ok := g.Meta(Cell{row, col}, LabelKey); ok && s != "" {
+				r, _ := utf8.DecodeRuneInString(s)
+				label = string(r)
+			}
+			buf = append(buf, ' ')
+			buf = append(buf, label...)
+			buf = append(buf, ' ')
+			if col < g.ColCount-1 && g.openings(row, col)&E != 0 {
+				buf = append(buf, ' ')
+			} else {
+				buf = append(buf, '|')
+			}
+		}
+		buf = append(buf, "\n+"...)
+		for col := 0; col < g.ColCount; col++ {
+			if row < g.RowCount-1 && g.openings(row, col)&S != 0 {
+				buf = append(buf, "   +"...)
+			} else {
+				buf = append(buf, "---+"...)
+			}
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// parseMFP parses the format renderMFP writes.  S, F and E in a cell mark
+// the start and finish.
+func parseMFP(lines [][]rune) (*Grid, error) {
+	rows, cols := (len(lines)-1)/2, (len(lines[0])-1)/4
+	if rows < 1 || cols < 1 {
+		return nil, errors.New("maze is too small")
+	}
+	g, err := NewGrid(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	at := func(y, x int) rune {
+		if x < len(lines[y]) {
+			return lines[y][x]
+		}
+		return ' '
+	}
+	for row := 0; row < rows; row++ {
+		y := 2*row + 1
+		if at(y, 0) != '|' {
+			return nil, fmt.Errorf("line %d doesn't start with a wall", y+1)
+		}
+		for col := 0; col < cols; col++ {
+			x := 4*col + 1
+			g.marker(at(y, x+1), g.CellId(row, col))
+			if col < cols-1 && at(y, x+3) == ' ' {
+				g.carve(row, col, E)
+			}
+			if row < rows-1 && at(y+1, x) == ' ' {
+				g.carve(row, col, S)
+			}
+		}
+	}
+	return &g, nil
+}
@@ -0,0 +1,133 @@
+package maze
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// isoWallHeight and isoWallThickness are the size of the isometric format's
+// walls, in cells.
+const (
+	isoWallHeight    = 0.5
+	isoWallThickness = 0.15
+)
+
+// isoBox is a wall as the isometric format draws it: a box from (x0, y0) to
+// (x1, y1) on the floor, in cells, isoWallHeight tall.
+type isoBox struct {
+	x0, y0, x1, y1 float64
+}
+
+// renderIsometric draws the maze as an SVG image seen from above at an
+// angle, in isometric projection, with the walls standing up off the floor
+// as solid blocks, for a video game look.  The floor shows the regions,
+// start, finish and paths as the svg format does, and the walls in front
+// hide what's behind them.
+func renderIsometric(g *Grid, w io.Writer, opts RenderOptions) error {
+	style := opts.style()
+	size := float64(style.CellSize)
+	// The floor's x (columns) runs down to the right and y (rows) down to
+	// the left, so the bottom right corner of the maze is nearest.
+	dx, dy := size*math.Sqrt(3)/2, size/2
+	margin := size / 2
+	left := float64(g.RowCount)*dx + margin
+	top := isoWallHeight*size + margin
+	width := left + float64(g.ColCount)*dx + margin
+	height := top + float64(g.RowCount+g.ColCount)*dy + margin
+	point := func(x, y, z float64) string {
+		return fmt.Sprintf("%.1f,%.1f", left+(x-y)*dx, top+(x+y)*dy-z*size)
+	}
+	polygon := func(bw *bufio.Writer, c color.RGBA, points ...string) {
+		fmt.Fprintf(bw, "<polygon points=\"%s\" fill=\"%s\"/>\n", strings.Join(points, " "), hexColor(c))
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\">\n",
+		width, height, width, height)
+	for _, key := range sortedKeys(opts.Info) {
+		fmt.Fprintf(bw, "<!-- %s%s=%s -->\n", infoPrefix, key, strings.ReplaceAll(opts.Info[key], "--", "- -"))
+	}
+	fmt.Fprintf(bw, "<rect width=\"%.0f\" height=\"%.0f\" fill=\"%s\"/>\n", width, height, hexColor(style.Background))
+
+	// The floor.
+	rows, cols := float64(g.RowCount), float64(g.ColCount)
+	polygon(bw, mixColor(style.Background, style.Wall, 0.08), point(0, 0, 0), point(cols, 0, 0), point(cols, rows, 0), point(0, rows, 0))
+	fillCell := func(id int, c color.RGBA) {
+		x, y := float64(id%g.ColCount), float64(id/g.ColCount)
+		polygon(bw, c, point(x, y, 0), point(x+1, y, 0), point(x+1, y+1, 0), point(x, y+1, 0))
+	}
+	if opts.Regions != nil {
+		for id, r := range opts.Regions {
+			if r >= 0 {
+				fillCell(id, opts.regionColor(r))
+			}
+		}
+	}
+	start, end := g.endpoints()
+	if style.Start.A != 0 {
+		fillCell(start, style.Start)
+	}
+	if style.Finish.A != 0 {
+		fillCell(end, style.Finish)
+	}
+	drawPath := func(path []int, c color.RGBA, width float64) {
+		for _, run := range g.pathRuns(path) {
+			points := make([]string, len(run))
+			for i, id := range run {
+				points[i] = point(float64(id%g.ColCount)+0.5, float64(id/g.ColCount)+0.5, 0)
+			}
+			fmt.Fprintf(bw, "<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%.1f\" stroke-linejoin=\"round\"/>\n",
+				strings.Join(points, " "), hexColor(c), width)
+		}
+	}
+	drawPath(g.CellIds(opts.Spine), style.Spine, size/4+1)
+	for i, p := range opts.Paths {
+		drawPath(g.CellIds(p.Cells), opts.PathColor(i), opts.pathWidth(i, size)+1)
+	}
+	drawPath(g.CellIds(opts.Path), style.Solution, size/4+1)
+
+	// The walls, a cell long each so they sort back to front.
+	t := isoWallThickness / 2
+	var boxes []isoBox
+	for col := 0; col < g.ColCount; col++ {
+		boxes = append(boxes, isoBox{float64(col) - t, -t, float64(col+1) + t, t})
+	}
+	for row := 0; row < g.RowCount; row++ {
+		boxes = append(boxes, isoBox{-t, float64(row) - t, t, float64(row+1) + t})
+		for col := 0; col < g.ColCount; col++ {
+			x, y := float64(col), float64(row)
+			cell := g.openings(row, col)
+			if cell&S == 0 {
+				boxes = append(boxes, isoBox{x - t, y + 1 - t, x + 1 + t, y + 1 + t})
+			}
+			if cell&E == 0 {
+				boxes = append(boxes, isoBox{x + 1 - t, y - t, x + 1 + t, y + 1 + t})
+			}
+		}
+	}
+	sort.SliceStable(boxes, func(i, j int) bool {
+		a, b := boxes[i], boxes[j]
+		return a.x0+a.x1+a.y0+a.y1 < b.x0+b.x1+b.y0+b.y1
+	})
+	// Only the top and the two faces towards the viewer can be seen.
+	topFace, xFace, yFace := mixColor(style.Wall, style.Background, 0.55), mixColor(style.Wall, style.Background, 0.25), style.Wall
+	z := isoWallHeight
+	for _, b := range boxes {
+		polygon(bw, yFace, point(b.x0, b.y1, 0), point(b.x1, b.y1, 0), point(b.x1, b.y1, z), point(b.x0, b.y1, z))
+		polygon(bw, xFace, point(b.x1, b.y0, 0), point(b.x1, b.y1, 0), point(b.x1, b.y1, z), point(b.x1, b.y0, z))
+		polygon(bw, topFace, point(b.x0, b.y0, z), point(b.x1, b.y0, z), point(b.x1, b.y1, z), point(b.x0, b.y1, z))
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// mixColor returns c mixed a fraction f of the way towards to.
+func mixColor(c, to color.RGBA, f float64) color.RGBA {
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + f*(float64(b)-float64(a)) + 0.5) }
+	return color.RGBA{mix(c.R, to.R), mix(c.G, to.G), mix(c.B, to.B), 255}
+}
@@ -0,0 +1,37 @@
+package maze
+
+import "iter"
+
+// Cells returns an iterator over every cell in the grid, row by row:
+//
+//	for c := range g.Cells() {
+//		fmt.Println(c, len(g.LinkedNeighbors(c)))
+//	}
+func (g *Grid) Cells() iter.Seq[Cell] {
+	return func(yield func(Cell) bool) {
+		for id := range g.data {
+			if !yield(g.CellOf(id)) {
+				return
+			}
+		}
+	}
+}
+
+// Links returns an iterator over every passage in the grid, once each: the
+// cell on its north or west side and the direction it leads from there, E
+// or S, in CellId order.
+func (g *Grid) Links() iter.Seq2[Cell, Direction] {
+	return func(yield func(Cell, Direction) bool) {
+		for id, cell := range g.data {
+			c := g.CellOf(id)
+			for _, d := range [...]Direction{E, S} {
+				if Direction(cell)&d == 0 || !g.inside(c.Row+rowOffset[d], c.Col+colOffset[d]) {
+					continue
+				}
+				if !yield(c, d) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package maze
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/overthink/maze-go/internal/font"
+)
+
+// TextMask draws text in font, as big as will fit in the middle of a
+// rows x cols grid with at least a cell to spare all round, and returns
+// which cells, by CellId, are ink.  Letters are scaled up by whole cells;
+// lines are separated by newlines.  Lower case letters are drawn as capitals.
+func TextMask(text string, rows, cols int) ([]bool, error) {
+	lines := strings.Split(strings.ToUpper(text), "\n")
+	// The text's size in font pixels, with a pixel between letters and
+	// two between lines.
+	width := 0
+	for _, line := range lines {
+		n := len([]rune(line))
+		if w := n*(font.Width+1) - 1; w > width {
+			width = w
+		}
+		for _, r := range line {
+			if _, ok := font.Glyphs[r]; !ok {
+				return nil, fmt.Errorf("can't draw %q", r)
+			}
+		}
+	}
+	height := len(lines)*(font.Height+2) - 2
+	if width <= 0 {
+		return nil, fmt.Errorf("no text to draw")
+	}
+	scale := (cols - 2) / width
+	if s := (rows - 2) / height; s < scale {
+		scale = s
+	}
+	if scale < 1 {
+		return nil, fmt.Errorf("%q needs a grid of at least %dx%d", text, height+2, width+2)
+	}
+
+	mask := make([]bool, rows*cols)
+	top := (rows - height*scale) / 2
+	for i, line := range lines {
+		lineWidth := len([]rune(line))*(font.Width+1) - 1
+		left := (cols - lineWidth*scale) / 2
+		for j, r := range []rune(line) {
+			for y, pixels := range font.Glyphs[r] {
+				for x, pixel := range pixels {
+					if pixel != '#' {
+						continue
+					}
+					row := top + (i*(font.Height+2)+y)*scale
+					col := left + (j*(font.Width+1)+x)*scale
+					for dy := 0; dy < scale; dy++ {
+						for dx := 0; dx < scale; dx++ {
+							mask[(row+dy)*cols+col+dx] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return mask, nil
+}
+
+// TextWeight returns edge weights for weighted Kruskal that carve the cells
+// of mask, by CellId in a grid cols wide, and the rest of the grid as
+// separate mazes first, then join them with as few passages as it takes.
+// The ink is left walled off in its own shape with a door or two, so it
+// reads as text.
+func TextWeight(mask []bool, cols int) EdgeWeight {
+	return func(row, col int, d Direction) float64 {
+		a := mask[row*cols+col]
+		b := mask[(row+rowOffset[d])*cols+col+colOffset[d]]
+		if a != b {
+			return 1
+		}
+		return 0
+	}
+}
+
+// MazifyText turns the grid into a maze with text written in it, as
+// TextMask draws it, by passages walled off in the shape of the letters.
+func (g *Grid) MazifyText(rng *rand.Rand, text string) error {
+	mask, err := TextMask(text, g.RowCount, g.ColCount)
+	if err != nil {
+		return err
+	}
+	g.MazifyWeightedKruskal(rng, TextWeight(mask, g.ColCount))
+	return nil
+}
+
+// TextRegions returns mask as regions for RenderOptions.Regions: the ink is
+// region 0 and the rest isn't in one.
+func TextRegions(mask []bool) []int {
+	regions := make([]int, len(mask))
+	for id, ink := range mask {
+		regions[id] = -1
+		if ink {
+			regions[id] = 0
+		}
+	}
+	return regions
+}
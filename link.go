@@ -0,0 +1,107 @@
+package maze
+
+import "fmt"
+
+func (d Direction) String() string {
+	switch d {
+	case N:
+		return "N"
+	case E:
+		return "E"
+	case S:
+		return "S"
+	case W:
+		return "W"
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
+
+// neighbourSteps are the directions with their offsets, for loops too hot to
+// look them up in rowOffset and colOffset.
+var neighbourSteps = [...]struct {
+	d        Direction
+	row, col int
+}{{N, -1, 0}, {E, 0, 1}, {S, 1, 0}, {W, 0, -1}}
+
+// inside reports whether (row, col) is a cell of the grid.
+func (g *Grid) inside(row, col int) bool {
+	return row >= 0 && row < g.RowCount && col >= 0 && col < g.ColCount
+}
+
+// HasWall reports whether cell c has a wall on its d side.  The outside edge
+// of the grid is always walled, and so are cells outside it.
+func (g *Grid) HasWall(c Cell, d Direction) bool {
+	if !g.Contains(c) {
+		return true
+	}
+	return g.openings(c.Row, c.Col)&d == 0
+}
+
+// Link removes the wall on the d side of cell c, from both cells it
+// separates.  It fails if that side is the outside edge of the grid.
+func (g *Grid) Link(c Cell, d Direction) error {
+	if err := g.checkNeighbour(c.Row, c.Col, d); err != nil {
+		return err
+	}
+	op := linkOp{c.Row, c.Col, d, true}
+	if g.History != nil && g.HasWall(c, d) {
+		g.History.record(op)
+	}
+	g.apply(op)
+	return nil
+}
+
+// Unlink puts back the wall on the d side of cell c, in both cells it
+// separates.  It fails if that side is the outside edge of the grid.
+func (g *Grid) Unlink(c Cell, d Direction) error {
+	if err := g.checkNeighbour(c.Row, c.Col, d); err != nil {
+		return err
+	}
+	op := linkOp{c.Row, c.Col, d, false}
+	if g.History != nil && !g.HasWall(c, d) {
+		g.History.record(op)
+	}
+	g.apply(op)
+	return nil
+}
+
+func (g *Grid) checkNeighbour(row, col int, d Direction) error {
+	if _, ok := opposite[d]; !ok {
+		return fmt.Errorf("%v is not a direction", d)
+	}
+	if !g.inside(row, col) || !g.inside(row+rowOffset[d], col+colOffset[d]) {
+		return fmt.Errorf("no cell on the %v side of %d,%d in a %dx%d grid",
+			d, row, col, g.RowCount, g.ColCount)
+	}
+	return nil
+}
+
+// Neighbors returns the cells next to c, walled off or not, in N, E, S, W
+// order.
+func (g *Grid) Neighbors(c Cell) []Cell {
+	var cells []Cell
+	for _, d := range []Direction{N, E, S, W} {
+		if next := c.Step(d); g.Contains(next) {
+			cells = append(cells, next)
+		}
+	}
+	return cells
+}
+
+// LinkedNeighbors returns the cells c has an opening to, in N, E, S, W
+// order.
+func (g *Grid) LinkedNeighbors(c Cell) []Cell {
+	return g.cells(g.linkedNeighbors(g.CellIdOf(c)))
+}
+
+// linkedNeighbors is LinkedNeighbors with the cells as CellIds.
+func (g *Grid) linkedNeighbors(id int) []int {
+	var ids []int
+	row, col := id/g.ColCount, id%g.ColCount
+	for _, d := range []Direction{N, E, S, W} {
+		if g.openings(row, col)&d != 0 {
+			ids = append(ids, g.CellId(row+rowOffset[d], col+colOffset[d]))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,25 @@
+package maze
+
+import (
+	"context"
+	"log/slog"
+)
+
+// debugLog returns the logger g's Observer sends the generators' debug
+// records to, or nil if there's none or it would drop them, so they can
+// skip the work of making records in their inner loops.
+func (g *Grid) debugLog() *slog.Logger {
+	if g.Observer == nil || g.Observer.Log == nil || !g.Observer.Log.Enabled(context.Background(), slog.LevelDebug) {
+		return nil
+	}
+	return g.Observer.Log
+}
+
+// graphLog is debugLog for the generators that work on any Graph, for the
+// ones that have a logger.
+func graphLog(gr Graph) *slog.Logger {
+	if g, ok := gr.(interface{ debugLog() *slog.Logger }); ok {
+		return g.debugLog()
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package maze
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// markerGreen is the colour of the dot RenderOptions.Markers puts on the
+// start in the graphical formats.
+var markerGreen = color.RGBA{40, 160, 60, 255}
+
+// markerDot is the radius of the start's dot and markerFlag how wide the
+// finish's chequered square is, as fractions of a cell, with markerChecks
+// squares each way.
+const (
+	markerDot    = 0.3
+	markerFlag   = 0.75
+	markerChecks = 3
+)
+
+// borderGap is a gap RenderOptions.Openings leaves in the outer wall, on
+// side d of cell id: the way in at the start, or out at the finish.
+type borderGap struct {
+	id int
+	d  Direction
+	in bool
+}
+
+// borderGaps returns the gaps in the outer wall by the start and the
+// finish, the start's in the top wall if it's on it, or else the left,
+// right or bottom one, and the finish's in the bottom, right, left or top
+// one.  One that isn't on the edge of the grid has no gap.
+func (g *Grid) borderGaps() []borderGap {
+	start, end := g.endpoints()
+	var gaps []borderGap
+	for _, e := range []struct {
+		id    int
+		in    bool
+		sides []Direction
+	}{{start, true, []Direction{N, W, E, S}}, {end, false, []Direction{S, E, W, N}}} {
+		row, col := e.id/g.ColCount, e.id%g.ColCount
+		for _, d := range e.sides {
+			if !g.inside(row+rowOffset[d], col+colOffset[d]) {
+				gaps = append(gaps, borderGap{e.id, d, e.in})
+				break
+			}
+		}
+	}
+	return gaps
+}
+
+// isGap reports whether one of gaps is on side d of cell (row, col).
+func (g *Grid) isGap(gaps []borderGap, row, col int, d Direction) bool {
+	for _, gap := range gaps {
+		if gap.id == g.CellId(row, col) && gap.d == d {
+			return true
+		}
+	}
+	return false
+}
+
+// gapArrow returns the corners of the arrowhead drawn a quarter of a cell
+// out past gap, in a grid of cells size wide, pointing into the maze at
+// the start and out of it at the finish.  Like oneWayArrow, it's relative
+// to the top left corner of the grid, but not rounded, for PDF.
+func (g *Grid) gapArrow(gap borderGap, size float64) [3][2]float64 {
+	row, col := gap.id/g.ColCount, gap.id%g.ColCount
+	fx, fy := float64(colOffset[gap.d]), float64(rowOffset[gap.d])
+	x := (float64(col) + 0.5 + fx*3/4) * size
+	y := (float64(row) + 0.5 + fy*3/4) * size
+	if gap.in {
+		fx, fy = -fx, -fy
+	}
+	length, width := size*0.2, size*0.2
+	return [3][2]float64{
+		{x + fx*length, y + fy*length},
+		{x - fx*length - fy*width, y - fy*length + fx*width},
+		{x - fx*length + fy*width, y - fy*length - fx*width},
+	}
+}
+
+// gapTriangle is gapArrow rounded to pixels, for fillTriangle.
+func (g *Grid) gapTriangle(gap borderGap, size float64) [3]image.Point {
+	var t [3]image.Point
+	for i, p := range g.gapArrow(gap, size) {
+		t[i] = image.Pt(int(math.Round(p[0])), int(math.Round(p[1])))
+	}
+	return t
+}
+
+// markText labels the start S and the finish F, over any labels they had,
+// for the text formats to draw.
+func (g *Grid) markText() {
+	start, end := g.endpoints()
+	g.SetMeta(g.CellOf(start), LabelKey, "S")
+	g.SetMeta(g.CellOf(end), LabelKey, "F")
+}
+
+// textGaps blanks out gaps in text, the maze as appendText draws it.
+func (g *Grid) textGaps(text []byte, gaps []borderGap) []byte {
+	lines := strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+	chars := make([][]rune, len(lines))
+	for i, line := range lines {
+		chars[i] = []rune(line)
+	}
+	// blank clears character j of line i, if the line reaches it and it's
+	// a wall rather than a label.
+	blank := func(i, j int) {
+		if i < len(chars) && j < len(chars[i]) && (chars[i][j] == '_' || chars[i][j] == '|') {
+			chars[i][j] = ' '
+		}
+	}
+	for _, gap := range gaps {
+		row, col := gap.id/g.ColCount, gap.id%g.ColCount
+		switch gap.d {
+		case N:
+			blank(0, 2*col+1)
+		case S:
+			blank(row+1, 2*col+1)
+		case W:
+			blank(row+1, 0)
+		case E:
+			blank(row+1, 2*col+2)
+		}
+	}
+	var out []byte
+	for _, line := range chars {
+		out = append(append(out, string(line)...), '\n')
+	}
+	return out
+}
+
+// fillCircle fills the circle of radius r centred on (cx, cy) in c.
+func fillCircle(img *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			if dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy; dx*dx+dy*dy <= r*r {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
@@ -1,17 +1,22 @@
-package main
+// Package maze generates, solves, analyses and renders mazes.  A Grid is
+// carved into a maze by a Generator, found by name with LookupGenerator,
+// solved by a Solver and drawn by a Renderer in one of the output formats.
+package maze
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"maps"
 	"math/rand"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
-	"time"
+	"unicode/utf8"
 )
 
 // Direction flags are used to indicate which grid walls have openings.  e.g.
-// if grid[r][c] == 4 then the South wall in cell (r,c) has been removed.
+// if g.openings(r, c) == S then the South wall in cell (r,c) has been removed.
 type Direction int
 
 const (
@@ -29,173 +34,294 @@ var rowOffset = map[Direction]int{N: -1, E: 0, S: 1, W: 0}
 var colOffset = map[Direction]int{N: 0, E: 1, S: 0, W: -1}
 
 // Prefer NewGrid to create instances of this struct.
+//
+// Any number of goroutines can read a grid at once (solving, rendering,
+// analysing it), but nothing may read it while something changes it.  To
+// render a grid that's still being edited, take a Snapshot.
 type Grid struct {
 	RowCount int
 	ColCount int
-	data     [][]int
+	// Only the low 4 bits of each cell are used, so a byte per cell is plenty.
+	// Cells are stored row by row in a single slice indexed by CellId.  Go
+	// through openings and carve rather than touching this directly.
+	data []uint8
+	// Entrances and Exits are the cells where the maze starts and finishes.
+	// Either may be empty or list several cells.
+	Entrances []Cell
+	Exits     []Cell
+	// meta holds per-cell metadata keyed by CellId; see SetMeta.
+	meta map[int]map[string]string
+	// oneWay holds the directions out of each cell, keyed by CellId, that
+	// are one-way passages leading in; see SetOneWay.
+	oneWay map[int]Direction
+	// Observer, if not nil, is told about each wall the generators carve.
+	Observer *Observer
+	// History, if not nil, records edits so they can be undone.
+	History *History
+	// GeneratorVersion is which version of the generators to carve the
+	// maze with, so a seed can make the maze it made in older versions: 1
+	// has Kruskal's algorithm and those built on it shuffle the walls in the
+	// order they did before version 2.  0 means LatestGeneratorVersion.
+	GeneratorVersion int
 }
 
-func NewGrid(rowCount, colCount int) Grid {
-	data := make([][]int, rowCount)
-	for i := range data {
-		data[i] = make([]int, colCount)
+// LatestGeneratorVersion is the version of the generators a Grid carves
+// with unless its GeneratorVersion says otherwise.
+const LatestGeneratorVersion = 2
+
+// maxGridCells is the most cells NewGrid makes a grid of.  Past a quarter of
+// a billion a maze is more likely a mistake than a puzzle, and the solvers'
+// bookkeeping for it alone would take gigabytes.
+const maxGridCells = 1 << 28
+
+// NewGrid returns a rowCount x colCount grid with every wall up.  It returns
+// an error, rather than a grid nothing can be done with, if either count
+// isn't positive or there would be more than maxGridCells cells.
+func NewGrid(rowCount, colCount int) (Grid, error) {
+	if err := CheckGridSize(rowCount, colCount); err != nil {
+		return Grid{}, err
 	}
-	return Grid{rowCount, colCount, data}
+	return newGrid(rowCount, colCount), nil
 }
 
-func (g *Grid) CellId(row, col int) int {
-	return row*g.ColCount + col
+// CheckGridSize returns the error NewGrid would for a rowCount x colCount
+// grid, without making one.
+func CheckGridSize(rowCount, colCount int) error {
+	if rowCount < 1 || colCount < 1 {
+		return fmt.Errorf("bad grid size %dx%d: need at least one row and column", rowCount, colCount)
+	}
+	if rowCount > maxGridCells/colCount {
+		return fmt.Errorf("bad grid size %dx%d: more than %d cells", rowCount, colCount, maxGridCells)
+	}
+	return nil
 }
 
-// MazifyRec turns the grid into a maze using recursive backtracking.
-func (g *Grid) MazifyRec(row, col int) {
-	dirs := []Direction{N, E, S, W}
-	rand.Shuffle(len(dirs), func(i, j int) { dirs[i], dirs[j] = dirs[j], dirs[i] })
-	for _, d := range dirs {
-		nextRow := row + rowOffset[d]
-		nextCol := col + colOffset[d]
-		// Carve through the wall in direction d if it's available and we
-		// haven't already been there.
-		if nextRow >= 0 && nextRow < g.RowCount &&
-			nextCol >= 0 && nextCol < g.ColCount &&
-			g.data[nextRow][nextCol] == 0 {
-			g.data[row][col] |= int(d)
-			g.data[nextRow][nextCol] |= int(opposite[d])
-			g.MazifyRec(nextRow, nextCol)
-		}
-	}
+// newGrid is NewGrid for sizes known to be good, such as a copy's.
+func newGrid(rowCount, colCount int) Grid {
+	return Grid{RowCount: rowCount, ColCount: colCount, data: make([]uint8, rowCount*colCount)}
 }
 
-// For Kruskal impl
-type edge struct {
-	row int
-	col int
-	d   Direction // other end of edge is in this direction
+// Clone returns a deep copy of the grid's walls, entrances, exits and
+// metadata.  The copy has no Observer.
+func (g *Grid) Clone() Grid {
+	data := make([]uint8, len(g.data))
+	copy(data, g.data)
+	return Grid{
+		RowCount:  g.RowCount,
+		ColCount:  g.ColCount,
+		data:      data,
+		Entrances: slices.Clone(g.Entrances),
+		Exits:     slices.Clone(g.Exits),
+		meta:      g.cloneMeta(),
+		oneWay:    maps.Clone(g.oneWay),
+	}
 }
 
-// MazifyKruskal turns grid into a maze using Kruskal's algorithm.
-func (g *Grid) MazifyKruskal() {
-	// 1. Generate all the possible edges in the grid graph.
-	//   - our representation of an edge will be (row, col, direction)
-	//     e.g. (3, 4, N) means an edge between cell (3, 4) and (2, 4), since
-	//     (2, 4) is North of (3, 4)
-	// 2. Shuffle the set of edges.
-	// 3. Execute Kruskal's algorithm on the set of shuffled edges.
-	//    - use a disjoint set union data structure
-	//    - each edge starts in a disjoint subset all by itself
-	//    - for each edge (u, v), if u and v are not in the same disjoint
-	//      subset
-	//      - update the grid allowing a path between u and v
-	//      - union the representative sets for u and v
-
-	dirs := []Direction{N, E, S, W}
-	var edges []edge
-	for row := 0; row < g.RowCount; row++ {
-		for col := 0; col < g.ColCount; col++ {
-			for _, d := range dirs {
-				// If (row, col, d) is a valid edge, add it to our list.
-				otherRow := row + rowOffset[d]
-				otherCol := col + colOffset[d]
-				if otherRow >= 0 && otherRow < g.RowCount &&
-					otherCol >= 0 && otherCol < g.ColCount {
-					edges = append(edges, edge{row, col, d})
-				}
-			}
+// Equal reports whether g and other are the same size and have exactly the
+// same walls and one-way passages.  Metadata isn't compared.
+func (g *Grid) Equal(other *Grid) bool {
+	if g.RowCount != other.RowCount || g.ColCount != other.ColCount {
+		return false
+	}
+	for i, cell := range g.data {
+		if cell != other.data[i] {
+			return false
 		}
 	}
-
-	rand.Shuffle(len(edges), func(i, j int) {
-		edges[i], edges[j] = edges[j], edges[i]
-	})
-
-	// Parent pointers for DSU; initially each elements points to itself
-	parent := make([]int, g.RowCount*g.ColCount)
-	for i := range parent {
-		parent[i] = i
+	for id, d := range g.oneWay {
+		if other.oneWay[id] != d {
+			return false
+		}
 	}
-
-	var find func(int) int
-	find = func(id int) int {
-		if parent[id] == id {
-			return id
+	for id, d := range other.oneWay {
+		if g.oneWay[id] != d {
+			return false
 		}
-		// path compression
-		parent[id] = find(parent[id])
-		return parent[id]
 	}
+	return true
+}
 
-	union := func(idA, idB int) {
-		setA := find(idA)
-		setB := find(idB)
-		if setA != setB {
-			parent[setB] = setA
-		}
+// Clear puts back every wall in the grid, to carve it again.
+func (g *Grid) Clear() {
+	for i := range g.data {
+		g.data[i] = 0
 	}
+	if g.Observer != nil {
+		g.Observer.event("clear")
+	}
+}
 
-	for _, edge := range edges {
-		otherRow := edge.row + rowOffset[edge.d]
-		otherCol := edge.col + colOffset[edge.d]
-		setA := find(g.CellId(edge.row, edge.col))
-		setB := find(g.CellId(otherRow, otherCol))
-		if setA != setB {
-			g.data[edge.row][edge.col] |= int(edge.d)
-			g.data[otherRow][otherCol] |= int(opposite[edge.d])
-			union(setA, setB)
-		}
+// openings returns the Direction flags for the walls of cell (row, col) that
+// have been removed.
+func (g *Grid) openings(row, col int) Direction {
+	return Direction(g.data[g.CellId(row, col)])
+}
+
+// carve removes the wall between cell (row, col) and its neighbour in
+// direction d, on both sides.  The neighbour must be inside the grid.
+func (g *Grid) carve(row, col int, d Direction) {
+	g.data[g.CellId(row, col)] |= uint8(d)
+	g.data[g.CellId(row+rowOffset[d], col+colOffset[d])] |= uint8(opposite[d])
+	if g.Observer != nil {
+		g.Observer.carve(g.Nodes(), row, col, d)
 	}
 }
 
+func (g *Grid) CellId(row, col int) int {
+	return row*g.ColCount + col
+}
+
+// MazifyRec turns the grid into a maze using recursive backtracking from
+// start.
+func (g *Grid) MazifyRec(rng *rand.Rand, start Cell) {
+	g.MazifyRecContext(context.Background(), rng, start)
+}
+
+// MazifyRecContext is MazifyRec but gives up, leaving the maze partly carved,
+// and returns ctx.Err() if ctx is done before it finishes.
+func (g *Grid) MazifyRecContext(ctx context.Context, rng *rand.Rand, start Cell) error {
+	return runSteps(ctx, NewRecStepper(g, rng, start))
+}
+
+// MazifyKruskal turns grid into a maze using Kruskal's algorithm.
+func (g *Grid) MazifyKruskal(rng *rand.Rand) {
+	g.MazifyKruskalContext(context.Background(), rng)
+}
+
+// MazifyKruskalContext is MazifyKruskal but gives up, leaving the maze partly
+// carved, and returns ctx.Err() if ctx is done before it finishes.
+func (g *Grid) MazifyKruskalContext(ctx context.Context, rng *rand.Rand) error {
+	return g.mazifyKruskalRegion(ctx, rng, 0, 0, g.RowCount, g.ColCount)
+}
+
+// mazifyKruskalRegion runs Kruskal's algorithm on the cells in rows
+// [rowStart, rowEnd) and cols [colStart, colEnd), ignoring any edges that
+// leave the region.
+func (g *Grid) mazifyKruskalRegion(ctx context.Context, rng *rand.Rand,
+	rowStart, colStart, rowEnd, colEnd int) error {
+	region := &gridRegion{g, rowStart, colStart, rowEnd - rowStart, colEnd - colStart}
+	s, err := newKruskalStepper(ctx, region, rng, g.GeneratorVersion == 1)
+	if err != nil {
+		return err
+	}
+	return runSteps(ctx, s)
+}
+
+// Print writes the maze to stdout.
 func (g *Grid) Print() {
-	// print top border
-	fmt.Printf(" ")
-	fmt.Println(strings.Repeat("_", g.ColCount*2-1))
+	g.Fprint(os.Stdout)
+}
+
+// Fprint writes the maze to w.
+func (g *Grid) Fprint(w io.Writer) {
+	w.Write(g.appendText(nil, nil))
+}
+
+// AppendText appends the maze to buf as Fprint draws it, with the cells on
+// path that have no metadata marked with dots, as the text format marks a
+// solution.
+func (g *Grid) AppendText(buf []byte, path []Cell) []byte {
+	return g.appendText(buf, g.CellIds(path))
+}
+
+// AppendTextTop appends the top border of the maze as Fprint draws it.
+func (g *Grid) AppendTextTop(buf []byte) []byte {
+	return appendTextTop(buf, g.ColCount)
+}
+
+// AppendTextRow appends the given row of the maze as Fprint draws it, to
+// follow AppendTextTop and the rows above.  If labels isn't empty, cells
+// with a non-zero label show it in place of their south wall.  If colors
+// isn't empty, each cell is drawn in the ANSI colour given by its escape
+// sequence, or uncoloured if it's "".  Both are indexed by column.
+func (g *Grid) AppendTextRow(buf []byte, row int, labels []rune, colors []string) []byte {
+	return appendTextRow(buf, g.data[g.CellId(row, 0):g.CellId(row+1, 0)], labels, colors)
+}
+
+// appendText appends the maze to buf as Fprint draws it, with the cells on
+// path that have no metadata marked as markPath would, without having to
+// copy the grid to mark them.
+func (g *Grid) appendText(buf []byte, path []int) []byte {
+	if buf == nil {
+		buf = make([]byte, 0, (g.RowCount+1)*(2*g.ColCount+2))
+	}
+	buf = g.AppendTextTop(buf)
+	var onPath []bool
+	if len(path) > 0 {
+		onPath = make([]bool, len(g.data))
+		for _, id := range path {
+			onPath[id] = true
+		}
+	}
+	var labels []rune
 	for row := 0; row < g.RowCount; row++ {
-		// print far left border
-		fmt.Printf("|")
-		for col := 0; col < g.ColCount; col++ {
-			// print south wall if not open
-			if g.data[row][col]&S != 0 {
-				fmt.Printf(" ")
-			} else {
-				fmt.Printf("_")
-			}
-			// handle east wall
-			if g.data[row][col]&E != 0 {
-				// Checking the east neighbour's southern opening is just done
-				// to make the output prettier -- it's not for correctness.
-				if (g.data[row][col]|g.data[row][col+1])&S != 0 {
-					fmt.Printf(" ")
-				} else {
-					fmt.Printf("_")
+		labels = labels[:0]
+		if g.meta != nil || onPath != nil {
+			for col := 0; col < g.ColCount; col++ {
+				var label rune
+				id := g.CellId(row, col)
+				if value, ok := g.Meta(Cell{row, col}, LabelKey); ok && value != "" {
+					label = []rune(value)[0]
+				} else if onPath != nil && onPath[id] && len(g.meta[id]) == 0 {
+					label = '.'
 				}
-			} else {
-				fmt.Printf("|")
+				labels = append(labels, label)
 			}
 		}
-		fmt.Println()
+		buf = g.AppendTextRow(buf, row, labels, nil)
 	}
+	return buf
+}
+
+// ansiReset turns off any ANSI colours.
+const ansiReset = "\x1b[0m"
+
+// appendTextTop appends the top border of a maze colCount cells wide.
+func appendTextTop(buf []byte, colCount int) []byte {
+	buf = append(buf, ' ')
+	buf = append(buf, strings.Repeat("_", colCount*2-1)...)
+	return append(buf, '\n')
 }
 
-func main() {
-	rand.Seed(time.Now().UnixNano())
-	var rows int = 10
-	var cols int = 10
-	var err error
-	if len(os.Args) > 1 {
-		rows, err = strconv.Atoi(os.Args[1])
-		if err != nil {
-			log.Fatal(err)
+// appendTextRow appends one row of the maze, given the openings of each cell
+// in the row.  If labels isn't empty, cells with a non-zero label show it in
+// place of their south wall.  If colors isn't empty, each cell is drawn in
+// the ANSI colour given by its escape sequence, or uncoloured if it's "".
+func appendTextRow(buf []byte, cells []uint8, labels []rune, colors []string) []byte {
+	// print far left border
+	buf = append(buf, '|')
+	color := ""
+	for col, cell := range cells {
+		if len(colors) > 0 && colors[col] != color {
+			if color != "" {
+				buf = append(buf, ansiReset...)
+			}
+			color = colors[col]
+			buf = append(buf, color...)
 		}
-	}
-	if len(os.Args) > 2 {
-		cols, err = strconv.Atoi(os.Args[2])
-		if err != nil {
-			log.Fatal(err)
+		// print south wall if not open
+		if len(labels) > 0 && labels[col] != 0 {
+			buf = utf8.AppendRune(buf, labels[col])
+		} else if cell&S != 0 {
+			buf = append(buf, ' ')
+		} else {
+			buf = append(buf, '_')
+		}
+		// handle east wall
+		if cell&E != 0 {
+			// Checking the east neighbour's southern opening is just done
+			// to make the output prettier -- it's not for correctness.
+			if (cell|cells[col+1])&S != 0 {
+				buf = append(buf, ' ')
+			} else {
+				buf = append(buf, '_')
+			}
+		} else {
+			buf = append(buf, '|')
 		}
 	}
-
-	grid := NewGrid(rows, cols)
-	// grid.MazifyRec(0, 0)
-	grid.MazifyKruskal()
-	grid.Print()
+	if color != "" {
+		buf = append(buf, ansiReset...)
+	}
+	return append(buf, '\n')
 }
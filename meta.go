@@ -0,0 +1,160 @@
+package maze
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// LabelKey is the metadata key the text renderer looks at: the first
+// character of a cell's label is drawn in the cell.
+const LabelKey = "label"
+
+// SetMeta attaches the metadata value to cell c under key, e.g. a label, a
+// colour or an item marker.
+func (g *Grid) SetMeta(c Cell, key, value string) {
+	if g.meta == nil {
+		g.meta = map[int]map[string]string{}
+	}
+	id := g.CellIdOf(c)
+	if g.meta[id] == nil {
+		g.meta[id] = map[string]string{}
+	}
+	g.meta[id][key] = value
+}
+
+// Meta returns the metadata stored under key for cell c, if any.
+func (g *Grid) Meta(c Cell, key string) (string, bool) {
+	value, ok := g.meta[g.CellIdOf(c)][key]
+	return value, ok
+}
+
+// DeleteMeta removes the metadata stored under key for cell c.
+func (g *Grid) DeleteMeta(c Cell, key string) {
+	id := g.CellIdOf(c)
+	delete(g.meta[id], key)
+	if len(g.meta[id]) == 0 {
+		delete(g.meta, id)
+	}
+}
+
+// MetaKeys returns the sorted metadata keys set on cell c.
+func (g *Grid) MetaKeys(c Cell) []string {
+	var keys []string
+	for key := range g.meta[g.CellIdOf(c)] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cloneMeta returns a deep copy of the grid's metadata.
+func (g *Grid) cloneMeta() map[int]map[string]string {
+	if g.meta == nil {
+		return nil
+	}
+	out := make(map[int]map[string]string, len(g.meta))
+	for id, values := range g.meta {
+		out[id] = make(map[string]string, len(values))
+		for key, value := range values {
+			out[id][key] = value
+		}
+	}
+	return out
+}
+
+// gridJSON is how a Grid looks as JSON.  Cells holds the Direction flags of
+// every cell in CellId order (base64 encoded, as encoding/json does for
+// bytes), and Meta and OneWay are keyed by CellId.
+type gridJSON struct {
+	Rows      int                       `json:"rows"`
+	Cols      int                       `json:"cols"`
+	Cells     []uint8                   `json:"cells"`
+	Entrances []int                     `json:"entrances,omitempty"`
+	Exits     []int                     `json:"exits,omitempty"`
+	Meta      map[int]map[string]string `json:"meta,omitempty"`
+	OneWay    map[int]Direction         `json:"oneway,omitempty"`
+}
+
+func (g Grid) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gridJSON{g.RowCount, g.ColCount, g.data, g.CellIds(g.Entrances), g.CellIds(g.Exits), g.meta, g.oneWay})
+}
+
+func (g *Grid) UnmarshalJSON(b []byte) error {
+	var j gridJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	grid, err := j.grid()
+	if err != nil {
+		return err
+	}
+	*g = grid
+	return nil
+}
+
+// GobEncode and GobDecode let encoding/gob store a Grid, in the form
+// MarshalProto writes.  Like JSON, this leaves out the Observer and History.
+func (g Grid) GobEncode() ([]byte, error) {
+	return g.MarshalProto(), nil
+}
+
+func (g *Grid) GobDecode(b []byte) error {
+	return g.UnmarshalProto(b)
+}
+
+// grid checks that j is a consistent maze and returns it as a Grid.  It's
+// how JSON, protobuf and gob are all decoded, so whatever they come from, a
+// maze that gets as far as the solvers and renderers is safe to work on.
+func (j gridJSON) grid() (Grid, error) {
+	// A maze needs a cell to start and finish in, and Rows*Cols mustn't
+	// overflow and come out as a small grid.
+	if j.Rows < 1 || j.Cols < 1 || j.Cols > maxGridCells/j.Rows {
+		return Grid{}, fmt.Errorf("bad grid size %dx%d", j.Rows, j.Cols)
+	}
+	if len(j.Cells) != j.Rows*j.Cols {
+		return Grid{}, fmt.Errorf("%d cells given for a %dx%d grid", len(j.Cells), j.Rows, j.Cols)
+	}
+	for _, id := range append(append([]int(nil), j.Entrances...), j.Exits...) {
+		if id < 0 || id >= len(j.Cells) {
+			return Grid{}, fmt.Errorf("entrance or exit %d is outside the grid", id)
+		}
+	}
+	g := Grid{
+		RowCount: j.Rows,
+		ColCount: j.Cols,
+		data:     j.Cells,
+		meta:     j.Meta,
+		oneWay:   j.OneWay,
+	}
+	g.Entrances, g.Exits = g.cells(j.Entrances), g.cells(j.Exits)
+	if err := g.checkEdges(); err != nil {
+		return Grid{}, err
+	}
+	// A one-way passage has to be one, from a cell in the grid.
+	for id, d := range j.OneWay {
+		if id < 0 || id >= len(j.Cells) {
+			return Grid{}, fmt.Errorf("one-way passage from cell %d outside the grid", id)
+		}
+		if d&^Direction(j.Cells[id]) != 0 {
+			return Grid{}, fmt.Errorf("cell %d has one-way passages %#x where it isn't open", id, int(d))
+		}
+	}
+	return g, nil
+}
+
+// checkEdges checks that every cell is only open in the four directions,
+// and none to the outside of the grid, which would send the solvers off it.
+func (g *Grid) checkEdges() error {
+	for id, cell := range g.data {
+		if cell&^uint8(N|E|S|W) != 0 {
+			return fmt.Errorf("cell %d has openings %#x that aren't directions", id, cell)
+		}
+		for _, d := range []Direction{N, E, S, W} {
+			if cell&uint8(d) != 0 && !g.inside(id/g.ColCount+rowOffset[d], id%g.ColCount+colOffset[d]) {
+				return fmt.Errorf("cell %d is open to the outside", id)
+			}
+		}
+	}
+	return nil
+}
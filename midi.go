@@ -0,0 +1,81 @@
+package maze
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// The MIDI format turns the walk through a maze into a tune: a note for each
+// cell along the path, pitched by how far up the maze it is and panned by
+// how far across, so the solution can be heard climbing, falling and
+// wandering from side to side.
+
+const (
+	midiTicks    = 480 // per quarter note
+	midiNoteLen  = midiTicks / 2
+	midiVelocity = 80
+	midiAccent   = 112 // for junctions, where the path had a choice
+)
+
+// midiScale is a major pentatonic scale, in semitones above its root, so
+// any run of notes sounds reasonable.
+var midiScale = [...]int{0, 2, 4, 7, 9}
+
+// renderMIDI writes the path through the maze as a Standard MIDI File: the
+// opts.Path if there is one (what maze solve draws), otherwise the shortest
+// way from the start to the finish.  Each cell is an eighth note at 120
+// beats a minute on a pentatonic scale three octaves wide, the top row
+// highest, panned left to right with the column, and louder at junctions.
+func renderMIDI(g *Grid, w io.Writer, opts RenderOptions) error {
+	path := g.CellIds(opts.Path)
+	if path == nil {
+		path = g.solvePath(g.Endpoints())
+	}
+	steps := 3 * len(midiScale)
+	var track []byte
+	// 500000 microseconds a quarter note is 120 beats a minute.
+	track = append(track, 0, 0xff, 0x51, 3, 0x07, 0xa1, 0x20)
+	for _, id := range path {
+		row, col := id/g.ColCount, id%g.ColCount
+		step := steps - 1
+		if g.RowCount > 1 {
+			step = (g.RowCount - 1 - row) * (steps - 1) / (g.RowCount - 1)
+		}
+		note := byte(48 + 12*(step/len(midiScale)) + midiScale[step%len(midiScale)])
+		pan := byte(64)
+		if g.ColCount > 1 {
+			pan = byte(col * 127 / (g.ColCount - 1))
+		}
+		velocity := byte(midiVelocity)
+		if len(g.LinkedNeighbors(Cell{row, col})) > 2 {
+			velocity = midiAccent
+		}
+		track = append(track, 0, 0xb0, 10, pan)
+		track = append(track, 0, 0x90, note, velocity)
+		track = appendMIDIVarint(track, midiNoteLen)
+		track = append(track, 0x80, note, 0)
+	}
+	track = append(track, 0, 0xff, 0x2f, 0)
+
+	// A format 0 file: one track.
+	file := make([]byte, 22, 22+len(track))
+	copy(file, "MThd\x00\x00\x00\x06\x00\x00\x00\x01")
+	binary.BigEndian.PutUint16(file[12:], midiTicks)
+	copy(file[14:], "MTrk")
+	binary.BigEndian.PutUint32(file[18:], uint32(len(track)))
+	_, err := w.Write(append(file, track...))
+	return err
+}
+
+// appendMIDIVarint appends the variable length quantity MIDI uses for
+// times: like a protobuf varint, but most significant 7 bits first.
+func appendMIDIVarint(b []byte, v uint32) []byte {
+	var buf [5]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7f) | 0x80
+	}
+	return append(b, buf[i:]...)
+}
@@ -0,0 +1,82 @@
+package maze
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Observer receives events from the generators and solvers as they run,
+// e.g. to drive a progress bar or an animation.  Set Grid.Observer before
+// generating or solving; any of the callbacks may be nil.
+//
+// Callbacks are never called concurrently, even by MazifyParallel, but they
+// may be called from goroutines other than the one that started generation.
+type Observer struct {
+	// Carve is called each time the wall between c and its neighbour in
+	// direction d is removed.
+	Carve func(c Cell, d Direction)
+	// Event is called when an algorithm reaches a named phase, e.g.
+	// MazifyParallel sends "stitch" once every tile is done, and "clear" is
+	// sent when a maze is cleared to start over, e.g. for another attempt
+	// at meeting a constraint.
+	Event func(name string)
+	// Progress is called with the percentage of the maze carved each time it
+	// goes up by at least one.
+	Progress func(percent int)
+	// Visit is called each time a solver visits c: when a search takes it
+	// off its queue, or a walker steps into it.  Cells can be visited more
+	// than once.
+	Visit func(c Cell)
+	// Log, if not nil, is sent debug records of what the algorithms do
+	// inside, e.g. each edge Kruskal's considers and whether it merged two
+	// sets, the backtracker's backtracks and Wilson's walks.  Unlike the
+	// callbacks it may be called concurrently.
+	Log *slog.Logger
+
+	mu      sync.Mutex
+	carved  int
+	percent int
+}
+
+func (o *Observer) carve(cells, row, col int, d Direction) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.Carve != nil {
+		o.Carve(Cell{row, col}, d)
+	}
+	if o.Progress == nil {
+		return
+	}
+	// A perfect maze removes exactly one wall fewer than it has cells.
+	o.carved++
+	total := cells - 1
+	percent := o.carved * 100 / total
+	if percent > 100 {
+		// Mazes with rooms or loops remove more.
+		percent = 100
+	}
+	if percent > o.percent {
+		o.percent = percent
+		o.Progress(percent)
+	}
+}
+
+func (o *Observer) visit(g *Grid, id int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.Visit != nil {
+		o.Visit(g.CellOf(id))
+	}
+}
+
+func (o *Observer) event(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if name == "clear" {
+		// The maze is carved again from scratch, so is its progress.
+		o.carved, o.percent = 0, 0
+	}
+	if o.Event != nil {
+		o.Event(name)
+	}
+}
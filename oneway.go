@@ -0,0 +1,135 @@
+package maze
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// SetOneWay makes the passage on the d side of cell c one-way: it can be
+// walked from c but not back.  It fails if there's a wall there instead of
+// a passage.
+func (g *Grid) SetOneWay(c Cell, d Direction) error {
+	if err := g.checkNeighbour(c.Row, c.Col, d); err != nil {
+		return err
+	}
+	if g.HasWall(c, d) {
+		return fmt.Errorf("no passage on the %v side of %v to make one-way", d, c)
+	}
+	if g.oneWay == nil {
+		g.oneWay = map[int]Direction{}
+	}
+	id, next := g.CellIdOf(c), g.CellId(c.Row+rowOffset[d], c.Col+colOffset[d])
+	if g.oneWay[id] &^= d; g.oneWay[id] == 0 {
+		delete(g.oneWay, id)
+	}
+	g.oneWay[next] |= opposite[d]
+	return nil
+}
+
+// walkable returns the directions that can be walked out of cell (row,
+// col): its openings, less any one-way passages that only lead in.
+func (g *Grid) walkable(row, col int) Direction {
+	return g.openings(row, col) &^ g.oneWay[g.CellId(row, col)]
+}
+
+// oneWays returns the maze's one-way passages, each as the cell it can be
+// walked from and the direction it leads, in CellId order.
+func (g *Grid) oneWays() []edge {
+	ids := make([]int, 0, len(g.oneWay))
+	for id := range g.oneWay {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	var out []edge
+	for _, id := range ids {
+		row, col := id/g.ColCount, id%g.ColCount
+		for _, d := range []Direction{N, E, S, W} {
+			if g.oneWay[id]&d != 0 && g.openings(row, col)&d != 0 {
+				out = append(out, edge{row + rowOffset[d], col + colOffset[d], opposite[d]})
+			}
+		}
+	}
+	return out
+}
+
+// HasOneWays reports whether the maze has any one-way passages.
+func (g *Grid) HasOneWays() bool {
+	return len(g.oneWays()) > 0
+}
+
+// WayBack returns the shortest path from finish back to start, as Solve
+// does, for checking whether a maze with one-way passages can be walked
+// both ways.  It's nil if the maze can only be solved one way (or not at
+// all).
+func (g *Grid) WayBack(start, finish Cell) []Cell {
+	return g.Solve(finish, start)
+}
+
+// AddOneWays makes about fraction of the maze's passages one-way, without
+// leaving any cell that can't get to the finish: every one-way passage
+// leads the way a shortest route to the finish goes, so the ones on the
+// solution point along it and the rest lead back to it.  The maze shouldn't
+// have any one-way passages already.
+func (g *Grid) AddOneWays(rng *rand.Rand, fraction float64) {
+	_, end := g.endpoints()
+	_, parent := g.bfsFrom([]int{end})
+	for id, p := range parent {
+		if p < 0 || p == id || rng.Float64() >= fraction {
+			continue
+		}
+		row, col := id/g.ColCount, id%g.ColCount
+		for _, d := range []Direction{N, E, S, W} {
+			if g.inside(row+rowOffset[d], col+colOffset[d]) && g.CellId(row+rowOffset[d], col+colOffset[d]) == p {
+				g.SetOneWay(Cell{row, col}, d)
+			}
+		}
+	}
+}
+
+// oneWayArrow returns the corners of the arrowhead the graphical formats
+// draw on a one-way passage from cell (row, col) in direction d, with cells
+// size across: a triangle on the wall between the cells pointing the way
+// the passage goes.
+func oneWayArrow(row, col int, d Direction, size float64) [3]image.Point {
+	// The middle of the wall between the cells, and which way is forwards.
+	fx, fy := float64(colOffset[d]), float64(rowOffset[d])
+	x := (float64(col) + 0.5 + fx/2) * size
+	y := (float64(row) + 0.5 + fy/2) * size
+	length, width := size*0.2, size*0.2
+	pt := func(x, y float64) image.Point {
+		return image.Pt(int(math.Round(x)), int(math.Round(y)))
+	}
+	return [3]image.Point{
+		pt(x+fx*length, y+fy*length),
+		pt(x-fx*length-fy*width, y-fy*length+fx*width),
+		pt(x-fx*length+fy*width, y-fy*length-fx*width),
+	}
+}
+
+// fillTriangle fills the triangle with corners t, offset by (dx, dy), in c.
+func fillTriangle(img *image.RGBA, t [3]image.Point, dx, dy int, c color.RGBA) {
+	for i := range t {
+		t[i] = t[i].Add(image.Pt(dx, dy))
+	}
+	bounds := image.Rectangle{t[0], t[0].Add(image.Pt(1, 1))}
+	for _, p := range t[1:] {
+		bounds = bounds.Union(image.Rectangle{p, p.Add(image.Pt(1, 1))})
+	}
+	// side is which side of the line from a to b the point p is on.
+	side := func(a, b, p image.Point) int {
+		return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+			s0, s1, s2 := side(t[0], t[1], p), side(t[1], t[2], p), side(t[2], t[0], p)
+			if (s0 >= 0 && s1 >= 0 && s2 >= 0) || (s0 <= 0 && s1 <= 0 && s2 <= 0) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+)
+
+// originShiftSteps is how many times per cell MazifyOriginShift moves the
+// origin, enough for the comb it starts from to be shuffled out of sight.
+const originShiftSteps = 10
+
+// originShift moves the origin of the maze in next, a tree where each cell,
+// by CellId, points to its neighbour on the way to the origin, which points
+// to -1, to a random neighbour of origin: the old origin points to it and
+// it points nowhere, so the tree is still a perfect maze.  It returns the
+// new origin, and where it used to point, whose wall goes back up.
+// The grid must have more than one cell.
+func originShift(g *Grid, rng *rand.Rand, next []int, origin int) (newOrigin, oldNext int) {
+	row, col := origin/g.ColCount, origin%g.ColCount
+	for {
+		// Trying directions until one's inside picks each neighbour evenly.
+		n := neighbourSteps[rng.Intn(len(neighbourSteps))]
+		if g.inside(row+n.row, col+n.col) {
+			newOrigin = g.CellId(row+n.row, col+n.col)
+			break
+		}
+	}
+	oldNext = next[newOrigin]
+	next[origin], next[newOrigin] = newOrigin, -1
+	return newOrigin, oldNext
+}
+
+// originShiftComb returns the maze MazifyOriginShift starts from as a tree
+// for originShift: every row leads east to the last column, which leads
+// south to the bottom right corner, the origin.
+func originShiftComb(g *Grid) []int {
+	next := make([]int, len(g.data))
+	for id := range next {
+		switch col := id % g.ColCount; {
+		case col < g.ColCount-1:
+			next[id] = id + 1
+		case id < len(next)-1:
+			next[id] = id + g.ColCount
+		default:
+			next[id] = -1
+		}
+	}
+	return next
+}
+
+// MazifyOriginShift turns the grid into a maze with the origin shift
+// algorithm: starting from a comb of passages, all leading to an origin in
+// the bottom right corner, it moves the origin to a random neighbour over
+// and over, turning the passage out of the neighbour round to lead into it,
+// ten times per cell, then carves the result.  Every step leaves a perfect
+// maze, so the same steps morph a maze that's already been made, as
+// OriginShift does.  It returns ctx.Err() if ctx is done before it
+// finishes.
+func (g *Grid) MazifyOriginShift(ctx context.Context, rng *rand.Rand) error {
+	next := originShiftComb(g)
+	origin := len(next) - 1
+	if origin == 0 {
+		return nil
+	}
+	for i := 1; i <= originShiftSteps*len(next); i++ {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		origin, _ = originShift(g, rng, next, origin)
+	}
+	for id, n := range next {
+		if n >= 0 {
+			g.Connect(id, n)
+		}
+	}
+	return nil
+}
+
+// OriginShift morphs a perfect maze a step at a time, for mazes that change
+// as they're played: each Shift moves one wall, and leaves a perfect maze.
+type OriginShift struct {
+	grid *Grid
+	rng  *rand.Rand
+	// next is the cell each cell's passage towards the origin leads to, or
+	// -1 for the origin.
+	next   []int
+	origin int
+}
+
+// NewOriginShift returns an OriginShift morphing g's maze, which must be
+// perfect, keeping it rooted at the cell origin.
+func NewOriginShift(g *Grid, rng *rand.Rand, origin Cell) *OriginShift {
+	return newOriginShift(g, rng, g.CellIdOf(origin))
+}
+
+// newOriginShift is NewOriginShift with the origin as a CellId.
+func newOriginShift(g *Grid, rng *rand.Rand, origin int) *OriginShift {
+	_, next := g.bfsFrom([]int{origin})
+	next[origin] = -1
+	return &OriginShift{grid: g, rng: rng, next: next, origin: origin}
+}
+
+// Shift moves the origin to a random neighbour, carving the wall between
+// them and putting back the wall that led out of the neighbour before.
+func (o *OriginShift) Shift() {
+	g, from := o.grid, o.origin
+	if len(o.next) == 1 {
+		return
+	}
+	var oldNext int
+	o.origin, oldNext = originShift(g, o.rng, o.next, from)
+	if oldNext == from {
+		// It led into the old origin, so the wall is already down.
+		return
+	}
+	g.Connect(from, o.origin)
+	g.apply(linkOp{o.origin / g.ColCount, o.origin % g.ColCount, g.direction(o.origin, oldNext), false})
+}
@@ -0,0 +1,118 @@
+package maze
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// Palette overrides the colours the renderers use for things told apart by
+// colour alone (regions, compared paths, the solution against the spine and
+// the heatmap's shading), e.g. with colours that stay distinct under colour
+// blindness.  Empty fields keep the usual colours.
+type Palette struct {
+	// Categories colour RenderOptions.Regions and Paths, in order.
+	Categories []color.RGBA
+	// Solution and Spine, unless fully transparent, replace the Style's.
+	Solution, Spine color.RGBA
+	// Heat is the heatmap's gradient, spread evenly from the start to the
+	// furthest cell, instead of white to black.
+	Heat []color.RGBA
+}
+
+// viridis and cividis are perceptually even gradients, light to dark, that
+// read the same under the common kinds of colour blindness; cividis is made
+// for them.
+var (
+	viridis = []color.RGBA{{253, 231, 37, 255}, {94, 201, 98, 255}, {33, 145, 140, 255}, {59, 82, 139, 255}, {68, 1, 84, 255}}
+	cividis = []color.RGBA{{255, 234, 70, 255}, {188, 175, 111, 255}, {124, 123, 120, 255}, {65, 77, 107, 255}, {0, 32, 77, 255}}
+)
+
+// palettes are the Palettes available with --palette.  The colours the
+// solution and spine take come last in Categories, so they only clash with
+// regions when there are lots of them.
+var palettes = map[string]Palette{
+	"default": {},
+	// okabe-ito is the Okabe and Ito palette, chosen to be told apart with
+	// any of the common colour vision deficiencies.
+	"okabe-ito": {
+		Categories: []color.RGBA{
+			{230, 159, 0, 255}, {86, 180, 233, 255}, {0, 158, 115, 255}, {240, 228, 66, 255},
+			{0, 114, 178, 255}, {213, 94, 0, 255}, {204, 121, 167, 255},
+		},
+		Solution: color.RGBA{213, 94, 0, 255},
+		Spine:    color.RGBA{204, 121, 167, 255},
+		Heat:     viridis,
+	},
+	// tol is Paul Tol's bright scheme, also safe for colour blindness and a
+	// little more saturated.
+	"tol": {
+		Categories: []color.RGBA{
+			{68, 119, 170, 255}, {34, 136, 51, 255}, {204, 187, 68, 255}, {102, 204, 238, 255},
+			{187, 187, 187, 255}, {238, 102, 119, 255}, {170, 51, 119, 255},
+		},
+		Solution: color.RGBA{238, 102, 119, 255},
+		Spine:    color.RGBA{170, 51, 119, 255},
+		Heat:     cividis,
+	},
+}
+
+// LookupPalette returns the palette named name.
+func LookupPalette(name string) (Palette, bool) {
+	p, ok := palettes[name]
+	return p, ok
+}
+
+// PaletteNames returns the palette names, sorted.
+func PaletteNames() []string {
+	var names []string
+	for name := range palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PathColor returns the colour of path i of opts.Paths.
+func (opts RenderOptions) PathColor(i int) color.RGBA {
+	if opts.Palette != nil && len(opts.Palette.Categories) > 0 {
+		return opts.Palette.Categories[i%len(opts.Palette.Categories)]
+	}
+	return pathPalette[i%len(pathPalette)]
+}
+
+// regionColor returns the colour of region r of opts.Regions.
+func (opts RenderOptions) regionColor(r int) color.RGBA {
+	if opts.Palette != nil && len(opts.Palette.Categories) > 0 {
+		return opts.Palette.Categories[r%len(opts.Palette.Categories)]
+	}
+	return regionPalette[r%len(regionPalette)]
+}
+
+// RegionANSI returns the ANSI background colours for the text format's
+// regions: regionColors, or the palette's colours as 24-bit escapes.
+func (opts RenderOptions) RegionANSI() []string {
+	if opts.Palette == nil || len(opts.Palette.Categories) == 0 {
+		return regionColors
+	}
+	var codes []string
+	for _, c := range opts.Palette.Categories {
+		codes = append(codes, fmt.Sprintf("\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B))
+	}
+	return codes
+}
+
+// heat returns the colour of a cell a fraction t of the way (0 to 1) along
+// p.Heat, which must not be empty.
+func (p *Palette) heat(t float64) color.RGBA {
+	stops := p.Heat
+	pos := t * float64(len(stops)-1)
+	i := int(pos)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	f := pos - float64(i)
+	a, b := stops[i], stops[i+1]
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + f*(float64(y)-float64(x)) + 0.5) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
@@ -0,0 +1,130 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+)
+
+// defaultTileSize is the tile edge length used by the "parallel" algorithm.
+const defaultTileSize = 256
+
+// MazifyParallel turns the grid into a maze by splitting it into tiles of at
+// most tileSize x tileSize cells, running Kruskal's algorithm on every tile
+// in its own goroutine, and then stitching the tiles together.
+//
+// Each finished tile is a spanning tree of its own cells, so stitching is
+// just Kruskal's algorithm again, this time over the edges that cross tile
+// boundaries with one DSU element per tile.
+//
+// The maze depends only on rng and tileSize, not on GOMAXPROCS or how the
+// goroutines are scheduled, and an Observer sees the same carves in the
+// same order every time too.
+func (g *Grid) MazifyParallel(rng *rand.Rand, tileSize int) {
+	g.MazifyParallelContext(context.Background(), rng, tileSize)
+}
+
+// MazifyParallelContext is MazifyParallel but gives up, leaving the maze
+// partly carved, and returns ctx.Err() if ctx is done before it finishes.
+func (g *Grid) MazifyParallelContext(ctx context.Context, rng *rand.Rand, tileSize int) error {
+	if tileSize <= 0 {
+		tileSize = defaultTileSize
+	}
+	tileRows := (g.RowCount + tileSize - 1) / tileSize
+	tileCols := (g.ColCount + tileSize - 1) / tileSize
+
+	// Tiles cover disjoint cells so they can be carved concurrently.  Each
+	// one gets its own rand.Rand, split off in order, since they aren't
+	// safe for concurrent use.
+	type tile struct {
+		rng                                *rand.Rand
+		rowStart, colStart, rowEnd, colEnd int
+		carves                             []edge
+		err                                error
+	}
+	tiles := make([]tile, 0, tileRows*tileCols)
+	for tr := 0; tr < tileRows; tr++ {
+		for tc := 0; tc < tileCols; tc++ {
+			t := tile{rowStart: tr * tileSize, colStart: tc * tileSize}
+			t.rowEnd, t.colEnd = t.rowStart+tileSize, t.colStart+tileSize
+			if t.rowEnd > g.RowCount {
+				t.rowEnd = g.RowCount
+			}
+			if t.colEnd > g.ColCount {
+				t.colEnd = g.ColCount
+			}
+			t.rng, _ = splitRand(rng)
+			tiles = append(tiles, t)
+		}
+	}
+	// The order tiles carve in depends on the scheduler, so when the
+	// Observer wants every carve they're recorded and passed on a tile at
+	// a time, in tile order.  Progress alone only counts them, which comes
+	// out the same in any order.
+	record := g.Observer != nil && g.Observer.Carve != nil
+	done := make(chan int)
+	go forEach(len(tiles), runtime.GOMAXPROCS(0), func(i int) {
+		t := &tiles[i]
+		region := *g
+		if record {
+			region.Observer = &Observer{Carve: func(c Cell, d Direction) {
+				t.carves = append(t.carves, edge{c.Row, c.Col, d})
+			}}
+		}
+		t.err = region.mazifyKruskalRegion(ctx, t.rng, t.rowStart, t.colStart, t.rowEnd, t.colEnd)
+		done <- i
+	})
+	finished := make([]bool, len(tiles))
+	next := 0
+	for range tiles {
+		finished[<-done] = true
+		for ; next < len(tiles) && finished[next]; next++ {
+			for _, c := range tiles[next].carves {
+				g.Observer.carve(g.Nodes(), c.row, c.col, c.d)
+			}
+			tiles[next].carves = nil
+		}
+	}
+	for _, t := range tiles {
+		if t.err != nil {
+			return t.err
+		}
+	}
+	if g.Observer != nil {
+		g.Observer.event("stitch")
+	}
+
+	// Collect the edges between horizontally and vertically adjacent tiles.
+	var edges []edge
+	for row := 0; row < g.RowCount; row++ {
+		for col := tileSize - 1; col < g.ColCount-1; col += tileSize {
+			edges = append(edges, edge{row, col, E})
+		}
+	}
+	for row := tileSize - 1; row < g.RowCount-1; row += tileSize {
+		for col := 0; col < g.ColCount; col++ {
+			edges = append(edges, edge{row, col, S})
+		}
+	}
+	rng.Shuffle(len(edges), func(i, j int) {
+		edges[i], edges[j] = edges[j], edges[i]
+	})
+
+	tileId := func(row, col int) int {
+		return (row/tileSize)*tileCols + col/tileSize
+	}
+	sets := NewDisjointSet(tileRows * tileCols)
+	for i, edge := range edges {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		otherRow := edge.row + rowOffset[edge.d]
+		otherCol := edge.col + colOffset[edge.d]
+		if sets.Union(tileId(edge.row, edge.col), tileId(otherRow, otherCol)) {
+			g.carve(edge.row, edge.col, edge.d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/overthink/maze-go/internal/protowire"
+)
+
+// ansiEscape matches the colour escapes the text renderers can add.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// ParseMaze reads a maze in any of the forms it's commonly found in: the
+// JSON or protocol buffer saveGrid writes, the text format Fprint writes, the
+// +---+ format of Mazes for Programmers, or a block maze drawn with a
+// character for each cell, wall and corner where walls are anything but
+// spaces -- "#", "█" and the like, as mazelib prints.  In the text forms an "S"
+// marks the start and an "F" or "E" the finish; they become the grid's
+// Entrances and Exits.
+func ParseMaze(b []byte) (*Grid, error) {
+	// A Grid message starts with its rows field, which text never does.
+	if len(b) > 0 && b[0] == 1<<3|protowire.Varint {
+		var g Grid
+		if err := g.UnmarshalProto(b); err != nil {
+			return nil, err
+		}
+		return &g, nil
+	}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		var g Grid
+		if err := json.Unmarshal(trimmed, &g); err != nil {
+			return nil, err
+		}
+		return &g, nil
+	}
+	var lines [][]rune
+	for _, line := range strings.Split(ansiEscape.ReplaceAllString(string(b), ""), "\n") {
+		if line = strings.TrimRight(line, " \r"); line != "" {
+			lines = append(lines, []rune(line))
+		}
+	}
+	if len(lines) < 2 {
+		return nil, errors.New("no maze found")
+	}
+	if strings.HasPrefix(string(lines[0]), " _") {
+		return parseText(lines)
+	}
+	if strings.HasPrefix(string(lines[0]), "+-") {
+		return parseMFP(lines)
+	}
+	return parseBlocks(lines)
+}
+
+// marker records a start or finish marker at cell id of g.
+func (g *Grid) marker(r rune, id int) {
+	switch r {
+	case 'S':
+		g.Entrances = append(g.Entrances, g.CellOf(id))
+	case 'F', 'E':
+		g.Exits = append(g.Exits, g.CellOf(id))
+	}
+}
+
+// parseText parses the text format Fprint writes.
+func parseText(lines [][]rune) (*Grid, error) {
+	rows, cols := len(lines)-1, len(lines[0])/2
+	g, err := NewGrid(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	var hidden []int // cells whose south wall a label hides
+	at := func(row, i int) rune {
+		if line := lines[row+1]; i < len(line) {
+			return line[i]
+		}
+		return ' '
+	}
+	for row := 0; row < rows; row++ {
+		if at(row, 0) != '|' {
+			return nil, fmt.Errorf("line %d doesn't start with a wall", row+2)
+		}
+		for col := 0; col < cols; col++ {
+			south, east := at(row, 1+2*col), at(row, 2+2*col)
+			if col < cols-1 && east != '|' {
+				g.carve(row, col, E)
+			}
+			if row == rows-1 {
+				g.marker(south, g.CellId(row, col))
+				continue
+			}
+			switch south {
+			case ' ':
+				g.carve(row, col, S)
+			case '_':
+			default:
+				// A label hides the south wall.  An underscore beside it
+				// in an open east or west wall means it's there; a space
+				// there with a wall under the neighbour means it isn't.
+				g.marker(south, g.CellId(row, col))
+				left := at(row, 2*col)
+				switch {
+				case east == '_' || (col > 0 && left == '_'):
+				case (east == ' ' && at(row, 3+2*col) == '_') || (col > 0 && left == ' ' && at(row, 2*col-1) == '_'):
+					g.carve(row, col, S)
+				default:
+					hidden = append(hidden, g.CellId(row, col))
+				}
+			}
+		}
+	}
+	// Otherwise guess the wall is there, unless that leaves the cell cut
+	// off from the one below it: mazes are usually connected.
+	if len(hidden) > 0 {
+		sets := NewDisjointSet(len(g.data))
+		for id := range g.data {
+			for _, d := range []Direction{E, S} {
+				if g.openings(id/cols, id%cols)&d != 0 {
+					sets.Union(id, g.CellId(id/cols+rowOffset[d], id%cols+colOffset[d]))
+				}
+			}
+		}
+		for _, id := range hidden {
+			if sets.Union(id, id+cols) {
+				g.carve(id/cols, id%cols, S)
+			}
+		}
+	}
+	return &g, nil
+}
+
+// parseBlocks parses a block maze, 2*rows+1 characters high and 2*cols+1
+// wide.
+func parseBlocks(lines [][]rune) (*Grid, error) {
+	height, width := len(lines), 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	if height < 3 || width < 3 {
+		return nil, errors.New("maze is too small")
+	}
+	at := func(y, x int) rune {
+		if x < len(lines[y]) {
+			return lines[y][x]
+		}
+		return ' '
+	}
+	open := func(y, x int) bool {
+		r := at(y, x)
+		return r == ' ' || r == '.' || r == 'S' || r == 'F' || r == 'E'
+	}
+	g, err := NewGrid((height-1)/2, (width-1)/2)
+	if err != nil {
+		return nil, err
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Markers can sit in a gap in the outer wall, so clamp them
+			// to the nearest cell.
+			row, col := (y-1)/2, (x-1)/2
+			if row >= g.RowCount {
+				row = g.RowCount - 1
+			}
+			if col >= g.ColCount {
+				col = g.ColCount - 1
+			}
+			g.marker(at(y, x), g.CellId(row, col))
+		}
+	}
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			y, x := 2*row+1, 2*col+1
+			if col < g.ColCount-1 && open(y, x+1) {
+				g.carve(row, col, E)
+			}
+			if row < g.RowCount-1 && open(y+1, x) {
+				g.carve(row, col, S)
+			}
+		}
+	}
+	return &g, nil
+}
@@ -0,0 +1,106 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// roundTripMaze is a maze with everything the formats can carry: entrances
+// and exits, metadata and a one-way passage.
+func roundTripMaze(t *testing.T) Grid {
+	g := newGrid(6, 7)
+	g.MazifyKruskal(rand.New(rand.NewSource(1)))
+	g.Entrances, g.Exits = []Cell{{0, 0}}, []Cell{{5, 6}}
+	g.SetMeta(Cell{2, 3}, LabelKey, "x")
+	for _, d := range []Direction{N, E, S, W} {
+		if !g.HasWall(Cell{2, 3}, d) {
+			if err := g.SetOneWay(Cell{2, 3}, d); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+	return g
+}
+
+func TestParseMazeRoundTrip(t *testing.T) {
+	g := roundTripMaze(t)
+	js, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		in   []byte
+	}{
+		{"json", js},
+		{"proto", g.MarshalProto()},
+	} {
+		got, err := ParseMaze(tc.in)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !got.Equal(&g) {
+			t.Errorf("%s: got a different maze back", tc.name)
+		}
+		if !slices.Equal(got.Entrances, g.Entrances) || !slices.Equal(got.Exits, g.Exits) {
+			t.Errorf("%s: got entrances %v and exits %v, want %v and %v", tc.name, got.Entrances, got.Exits, g.Entrances, g.Exits)
+		}
+		if !maps.EqualFunc(got.meta, g.meta, maps.Equal) {
+			t.Errorf("%s: got metadata %v, want %v", tc.name, got.meta, g.meta)
+		}
+	}
+
+	// The text formats only have the walls.
+	for _, format := range []string{"text", "mfp"} {
+		var buf bytes.Buffer
+		if err := renderers[format].Render(&g, &buf, RenderOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseMaze(buf.Bytes())
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if got.RowCount != g.RowCount || got.ColCount != g.ColCount || !slices.Equal(got.data, g.data) {
+			t.Errorf("%s: got a different maze back", format)
+		}
+	}
+}
+
+func TestParseMazeRejects(t *testing.T) {
+	for _, tc := range []struct {
+		name, in string
+	}{
+		{"empty json", `{}`},
+		{"no cols json", `{"rows": 3, "cols": 0}`},
+		{"too few cells json", `{"rows": 2, "cols": 2, "cells": "AAAA"}`},
+		{"open to the outside json", `{"rows": 2, "cols": 2, "cells": "AQAAAA=="}`},
+		{"entrance outside json", `{"rows": 2, "cols": 2, "cells": "AAAAAA==", "entrances": [4]}`},
+		{"one-way through a wall json", `{"rows": 2, "cols": 2, "cells": "AAAAAA==", "oneway": {"0": 2}}`},
+		{"bad json", `{"rows": 2,`},
+		{"zero rows proto", "\x08\x00"},
+		{"no cols proto", "\x08\x03"},
+		{"too few cells proto", "\x08\x02\x10\x02\x1a\x03\x00\x00\x00"},
+		{"truncated proto", "\x08\x02\x10\x02\x1a\x04\x00"},
+		{"no maze", "x"},
+	} {
+		if g, err := ParseMaze([]byte(tc.in)); err == nil {
+			t.Errorf("%s: got a %dx%d grid, want an error", tc.name, g.RowCount, g.ColCount)
+		}
+	}
+}
+
+func TestUnmarshalProtoRejects(t *testing.T) {
+	// ParseMaze only takes a message as one if it starts with the rows
+	// field, so check one that doesn't directly.
+	var g Grid
+	if err := g.UnmarshalProto([]byte("\x10\x02\x1a\x04\x00\x00\x00\x00")); err == nil {
+		t.Errorf("got a %dx%d grid with no rows, want an error", g.RowCount, g.ColCount)
+	}
+}
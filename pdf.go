@@ -0,0 +1,240 @@
+package maze
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// US letter, in points.
+const pdfPageWidth, pdfPageHeight = 612, 792
+
+// pdfDoc is a minimal PDF writer: pages of lines and Helvetica text, plus
+// entries for the document information dictionary.
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	info  map[string]string
+}
+
+// newPage adds a page and returns the buffer its content stream is written
+// to.  PDF user space has its origin at the bottom left.
+func (d *pdfDoc) newPage() *bytes.Buffer {
+	page := &bytes.Buffer{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// pdfString quotes s as a PDF literal string.
+func pdfString(s string) string {
+	return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s) + ")"
+}
+
+// pdfText writes s to page at (x, y) in size point Helvetica, centred on x.
+// The centring assumes an average character width, which is close enough
+// for headings.
+func pdfText(page *bytes.Buffer, x, y, size float64, s string) {
+	x -= float64(len(s)) * size * 0.25
+	fmt.Fprintf(page, "BT /F1 %.1f Tf %.2f %.2f Td %s Tj ET\n", size, x, y, pdfString(s))
+}
+
+// WriteTo writes the document to w.
+func (d *pdfDoc) WriteTo(w io.Writer) (int64, error) {
+	// Objects 1 to 4 are the catalog, the page tree, the font and the
+	// info dictionary; then each page is followed by its content stream.
+	var objects []string
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	var info strings.Builder
+	info.WriteString("<< /Producer (maze-go)")
+	for _, key := range sortedKeys(d.info) {
+		fmt.Fprintf(&info, " /%s%s %s", infoPrefix, key, pdfString(d.info[key]))
+	}
+	info.WriteString(" >>")
+	objects = append(objects, info.String())
+	for i, page := range d.pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.Bytes()))
+	}
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	fmt.Fprint(cw, "%PDF-1.4\n")
+	offsets := make([]int64, len(objects))
+	for i, obj := range objects {
+		offsets[i] = cw.n
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// pdfColor formats c as the operands of the PDF colour operators.
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// countingWriter counts the bytes written through it and remembers the
+// first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// pdfMaze draws g on page, as large as fits in the box with bottom left
+// corner (x, y), centred in it.  The outer wall is left open by the start
+// and finish, as borderGaps has it, with arrows if opts.Openings.  It's
+// drawn in opts.Style, with the paths in opts through the middle of their
+// cells: the spine underneath, then the other paths and the solution.
+func pdfMaze(page *bytes.Buffer, g *Grid, x, y, width, height float64, opts RenderOptions) {
+	style := opts.style()
+	size := width / float64(g.ColCount)
+	if s := height / float64(g.RowCount); s < size {
+		size = s
+	}
+	x += (width - size*float64(g.ColCount)) / 2
+	y += (height - size*float64(g.RowCount)) / 2
+	top := y + size*float64(g.RowCount)
+	// px and py give the position of the top left corner of cell (row, col).
+	px := func(col int) float64 { return x + size*float64(col) }
+	py := func(row int) float64 { return top - size*float64(row) }
+	line := func(x1, y1, x2, y2 float64) {
+		fmt.Fprintf(page, "%.2f %.2f m %.2f %.2f l\n", x1, y1, x2, y2)
+	}
+
+	// Fill the background and the start and finish cells.
+	fill := func(c color.RGBA, x, y, width, height float64) {
+		fmt.Fprintf(page, "%s rg %.2f %.2f %.2f %.2f re f\n", pdfColor(c), x, y, width, height)
+	}
+	fill(style.Background, px(0), py(g.RowCount), px(g.ColCount)-px(0), py(0)-py(g.RowCount))
+	start, end := g.endpoints()
+	if style.Start.A != 0 {
+		fill(style.Start, px(start%g.ColCount), py(start/g.ColCount+1), size, size)
+	}
+	if style.Finish.A != 0 {
+		fill(style.Finish, px(end%g.ColCount), py(end/g.ColCount+1), size, size)
+	}
+
+	// Style.WallWidth is in pixels, which are 3/4 of a point at 96 dpi.
+	lineWidth := float64(style.WallWidth) * 0.75
+	if lineWidth > size/8 {
+		lineWidth = size / 8
+	}
+	fmt.Fprintf(page, "%.2f w 2 J %s RG\n", lineWidth, pdfColor(style.Wall))
+	gaps := g.borderGaps()
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			cell := g.openings(row, col)
+			if row == 0 && !g.isGap(gaps, row, col, N) {
+				line(px(col), py(0), px(col+1), py(0))
+			}
+			if col == 0 && !g.isGap(gaps, row, col, W) {
+				line(px(0), py(row), px(0), py(row+1))
+			}
+			if cell&S == 0 && !g.isGap(gaps, row, col, S) {
+				line(px(col), py(row+1), px(col+1), py(row+1))
+			}
+			if cell&E == 0 && !g.isGap(gaps, row, col, E) {
+				line(px(col+1), py(row), px(col+1), py(row+1))
+			}
+		}
+	}
+	fmt.Fprintln(page, "S")
+	if opts.Openings {
+		fmt.Fprintf(page, "%s rg\n", pdfColor(style.Wall))
+		for _, gap := range gaps {
+			t := g.gapArrow(gap, size)
+			fmt.Fprintf(page, "%.2f %.2f m %.2f %.2f l %.2f %.2f l f\n",
+				x+t[0][0], top-t[0][1], x+t[1][0], top-t[1][1], x+t[2][0], top-t[2][1])
+		}
+	}
+
+	drawPath := func(path []int, c color.RGBA, width float64) {
+		if path == nil {
+			return
+		}
+		fmt.Fprintf(page, "%.2f w 1 J 1 j %s RG\n", width, pdfColor(c))
+		for _, run := range g.pathRuns(path) {
+			for i, id := range run {
+				op := "l"
+				if i == 0 {
+					op = "m"
+				}
+				row, col := id/g.ColCount, id%g.ColCount
+				fmt.Fprintf(page, "%.2f %.2f %s\n", px(col)+size/2, py(row)-size/2, op)
+			}
+		}
+		fmt.Fprintln(page, "S")
+	}
+	drawPath(g.CellIds(opts.Spine), style.Spine, size/8)
+	for i, p := range opts.Paths {
+		drawPath(g.CellIds(p.Cells), opts.PathColor(i), opts.pathWidth(i, size))
+	}
+	drawPath(g.CellIds(opts.Path), style.Solution, size/8)
+
+	if opts.Markers {
+		// A dot on the start, drawn as four Bézier curves, and a chequered
+		// flag on the finish.
+		r := markerDot * size
+		cx, cy := px(start%g.ColCount)+size/2, py(start/g.ColCount)-size/2
+		k := r * 0.5523
+		fmt.Fprintf(page, "%s rg %.2f %.2f m", pdfColor(markerGreen), cx+r, cy)
+		fmt.Fprintf(page, " %.2f %.2f %.2f %.2f %.2f %.2f c", cx+r, cy+k, cx+k, cy+r, cx, cy+r)
+		fmt.Fprintf(page, " %.2f %.2f %.2f %.2f %.2f %.2f c", cx-k, cy+r, cx-r, cy+k, cx-r, cy)
+		fmt.Fprintf(page, " %.2f %.2f %.2f %.2f %.2f %.2f c", cx-r, cy-k, cx-k, cy-r, cx, cy-r)
+		fmt.Fprintf(page, " %.2f %.2f %.2f %.2f %.2f %.2f c f\n", cx+k, cy-r, cx+r, cy-k, cx+r, cy)
+		flag := markerFlag * size
+		check := flag / markerChecks
+		x0, y0 := px(end%g.ColCount)+(size-flag)/2, py(end/g.ColCount)-(size+flag)/2
+		fill(style.Background, x0, y0, flag, flag)
+		for i := 0; i < markerChecks*markerChecks; i += 2 {
+			fill(style.Wall, x0+float64(i%markerChecks)*check, y0+float64(i/markerChecks)*check, check, check)
+		}
+	}
+}
+
+// renderPDF draws the maze on a single page PDF, with RenderOptions.Info in
+// the document information and the legend for RenderOptions.Paths under the
+// maze.
+func renderPDF(g *Grid, w io.Writer, opts RenderOptions) error {
+	doc := &pdfDoc{info: opts.Info}
+	const margin, legendLine = 36, 16
+	legend := float64(len(opts.Paths) * legendLine)
+	page := doc.newPage()
+	pdfMaze(page, g, margin, margin+legend, pdfPageWidth-2*margin, pdfPageHeight-2*margin-legend, opts)
+	for i, p := range opts.Paths {
+		y := margin + legend - float64((i+1)*legendLine)
+		fmt.Fprintf(page, "%s rg %d %.2f 10 10 re f\n", pdfColor(opts.PathColor(i)), margin, y+3)
+		fmt.Fprintf(page, "0 g BT /F1 10 Tf %d %.2f Td %s Tj ET\n", margin+16, y+4, pdfString(p.Label))
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
@@ -0,0 +1,149 @@
+package maze
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// PlaceKey is the metadata key PlaceLevel marks a cell with what it
+// suggests putting there: one of PlaceSpawn, PlaceExit, PlaceBoss,
+// PlaceTreasure and PlaceEnemy.
+const PlaceKey = "place"
+
+// The things PlaceLevel places, as PlaceKey values.
+const (
+	PlaceSpawn    = "spawn"
+	PlaceExit     = "exit"
+	PlaceBoss     = "boss"
+	PlaceTreasure = "treasure"
+	PlaceEnemy    = "enemy"
+)
+
+// placeGlyphs are the labels PlaceLevel gives its cells for the text
+// format to draw, as in a roguelike.
+var placeGlyphs = map[string]string{
+	PlaceSpawn:    "@",
+	PlaceExit:     ">",
+	PlaceBoss:     "B",
+	PlaceTreasure: "$",
+	PlaceEnemy:    "e",
+}
+
+// LevelPlacement is where PlaceLevel suggests putting things in a roguelike
+// level.
+type LevelPlacement struct {
+	// Spawn is where the player starts and Exit where they leave, as far
+	// apart as the maze allows.
+	Spawn, Exit Cell
+	// Boss guards the exit, on the cell next to it on the way from Spawn.
+	Boss Cell
+	// Treasure is in the deepest dead ends, those at the end of the
+	// longest corridors off a junction, deepest first.
+	Treasure []Cell
+	// Enemies are scattered over the cells at least a third of the way
+	// from Spawn to the furthest cell, in CellId order.
+	Enemies []Cell
+}
+
+// PlaceLevel suggests where to put things in the maze for a roguelike
+// level, using how far each cell is from the spawn and how deep each dead
+// end is, and marks the cells with PlaceKey, and with LabelKey for the
+// text format to draw, so they're saved with the maze.  Like LongestPath,
+// which places the spawn and exit, it only looks at the part of the maze
+// connected to the first cell.  It fails if there aren't enough dead ends
+// free for the treasure or cells far enough away for the enemies.
+func (g *Grid) PlaceLevel(rng *rand.Rand, treasure, enemies int) (LevelPlacement, error) {
+	path := g.longestPath()
+	if len(path) < 3 {
+		return LevelPlacement{}, fmt.Errorf("a %dx%d maze is too small for a level", g.RowCount, g.ColCount)
+	}
+	spawn, exit, boss := path[0], path[len(path)-1], path[len(path)-2]
+	taken := map[int]bool{spawn: true, exit: true, boss: true}
+	dist, _ := g.bfsFrom([]int{spawn})
+
+	depths := g.deadEndDepths()
+	var ends []int
+	for id := range depths {
+		if !taken[id] {
+			ends = append(ends, id)
+		}
+	}
+	if len(ends) < treasure {
+		return LevelPlacement{}, fmt.Errorf("only %d dead ends free for %d treasure", len(ends), treasure)
+	}
+	sort.Slice(ends, func(i, j int) bool {
+		a, b := ends[i], ends[j]
+		if depths[a] != depths[b] {
+			return depths[a] > depths[b]
+		}
+		if dist[a] != dist[b] {
+			return dist[a] > dist[b]
+		}
+		return a < b
+	})
+	ends = ends[:treasure]
+	for _, id := range ends {
+		taken[id] = true
+	}
+
+	var far []int
+	for id, d := range dist {
+		if !taken[id] && 3*d >= dist[exit] {
+			far = append(far, id)
+		}
+	}
+	if len(far) < enemies {
+		return LevelPlacement{}, fmt.Errorf("only %d cells free far enough from the spawn for %d enemies", len(far), enemies)
+	}
+	rng.Shuffle(len(far), func(i, j int) { far[i], far[j] = far[j], far[i] })
+	far = far[:enemies]
+	sort.Ints(far)
+
+	mark := func(id int, what string) {
+		g.SetMeta(g.CellOf(id), PlaceKey, what)
+		g.SetMeta(g.CellOf(id), LabelKey, placeGlyphs[what])
+	}
+	mark(spawn, PlaceSpawn)
+	mark(exit, PlaceExit)
+	mark(boss, PlaceBoss)
+	for _, id := range ends {
+		mark(id, PlaceTreasure)
+	}
+	for _, id := range far {
+		mark(id, PlaceEnemy)
+	}
+	return LevelPlacement{
+		Spawn: g.CellOf(spawn), Exit: g.CellOf(exit), Boss: g.CellOf(boss),
+		Treasure: g.PathCells(ends), Enemies: g.PathCells(far),
+	}, nil
+}
+
+// deadEndDepths returns how deep each dead end is, by CellId: how many
+// steps back along its corridor it is to the nearest junction, or to the
+// other end of the corridor if it has no junction.
+func (g *Grid) deadEndDepths() map[int]int {
+	depths := map[int]int{}
+	for c := range g.DeadEnds() {
+		id := g.CellId(c.Row, c.Col)
+		prev, depth := -1, 0
+		for cur := id; ; depth++ {
+			next := -1
+			links := g.linkedNeighbors(cur)
+			if depth > 0 && len(links) != 2 {
+				break
+			}
+			for _, n := range links {
+				if n != prev {
+					next = n
+				}
+			}
+			if next < 0 {
+				break
+			}
+			prev, cur = cur, next
+		}
+		depths[id] = depth
+	}
+	return depths
+}
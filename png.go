@@ -0,0 +1,215 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// renderPNG draws the maze as a PNG image.  Labels aren't drawn.
+func renderPNG(g *Grid, w io.Writer, opts RenderOptions) error {
+	if opts.Preview > 0 {
+		return renderPreviewPNG(g, w, opts.Preview)
+	}
+	style := opts.style()
+	size, wall := style.CellSize, style.WallWidth
+	margin := size / 2
+	img := image.NewRGBA(image.Rect(0, 0, g.ColCount*size+2*margin+wall, g.RowCount*size+2*margin+wall))
+	fill := func(r image.Rectangle, c color.RGBA) {
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	fill(img.Bounds(), style.Background)
+	if opts.Background != nil {
+		drawStretched(img, image.Rect(margin, margin, margin+g.ColCount*size+wall, margin+g.RowCount*size+wall), opts.Background)
+	}
+
+	// cell returns the rectangle covering cell (row, col), walls included.
+	cell := func(id int) image.Rectangle {
+		x, y := margin+id%g.ColCount*size, margin+id/g.ColCount*size
+		return image.Rect(x, y, x+size+wall, y+size+wall)
+	}
+	if opts.Regions != nil {
+		for id, r := range opts.Regions {
+			if r >= 0 {
+				fill(cell(id), opts.regionColor(r))
+			}
+		}
+	}
+	if opts.Visits != nil {
+		most := 0
+		for _, n := range opts.Visits {
+			most = max(most, n)
+		}
+		for id, n := range opts.Visits {
+			if n > 0 {
+				fill(cell(id), opts.visitColor(n, most))
+			}
+		}
+	}
+	start, end := g.endpoints()
+	if style.Start.A != 0 {
+		fill(cell(start), style.Start)
+	}
+	if style.Finish.A != 0 {
+		fill(cell(end), style.Finish)
+	}
+
+	// Paths run between neighbouring cells, so each step is a rectangle
+	// joining their middles.
+	drawPath := func(path []int, c color.RGBA, width int) {
+		centre := func(id int) image.Point {
+			r := cell(id)
+			return image.Pt(r.Min.X+(size+wall-width)/2, r.Min.Y+(size+wall-width)/2)
+		}
+		dot := image.Pt(width, width)
+		for _, run := range g.pathRuns(path) {
+			prev := centre(run[0])
+			for _, id := range run {
+				p := centre(id)
+				fill(image.Rectangle{prev, prev.Add(dot)}.Union(image.Rectangle{p, p.Add(dot)}), c)
+				prev = p
+			}
+		}
+	}
+	drawPath(g.CellIds(opts.Spine), style.Spine, size/4+1)
+	for i, p := range opts.Paths {
+		drawPath(g.CellIds(p.Cells), opts.PathColor(i), int(opts.pathWidth(i, float64(size)))+1)
+	}
+	drawPath(g.CellIds(opts.Path), style.Solution, size/4+1)
+
+	var gaps []borderGap
+	if opts.Openings {
+		gaps = g.borderGaps()
+	}
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			if !opts.inMask(g, row, col) {
+				continue
+			}
+			r := cell(g.CellId(row, col))
+			openings := g.openings(row, col)
+			for _, d := range []Direction{N, E, S, W} {
+				if g.isGap(gaps, row, col, d) {
+					openings |= d
+				}
+			}
+			if openings&N == 0 {
+				fill(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+wall), style.Wall)
+			}
+			if openings&S == 0 {
+				fill(image.Rect(r.Min.X, r.Max.Y-wall, r.Max.X, r.Max.Y), style.Wall)
+			}
+			if openings&W == 0 {
+				fill(image.Rect(r.Min.X, r.Min.Y, r.Min.X+wall, r.Max.Y), style.Wall)
+			}
+			if openings&E == 0 {
+				fill(image.Rect(r.Max.X-wall, r.Min.Y, r.Max.X, r.Max.Y), style.Wall)
+			}
+		}
+	}
+	// One-way passages get an arrowhead pointing the way they go.
+	for _, e := range g.oneWays() {
+		fillTriangle(img, oneWayArrow(e.row, e.col, e.d, float64(size)), margin+wall/2, margin+wall/2, style.Wall)
+	}
+	for _, gap := range gaps {
+		fillTriangle(img, g.gapTriangle(gap, float64(size)), margin+wall/2, margin+wall/2, style.Wall)
+	}
+	if opts.Markers {
+		// A dot on the start, and a chequered flag on the finish.
+		middle := func(id int) (x, y float64) {
+			r := cell(id)
+			return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+		}
+		x, y := middle(start)
+		fillCircle(img, x, y, markerDot*float64(size), markerGreen)
+		x, y = middle(end)
+		flag := markerFlag * float64(size)
+		x0, y0 := int(math.Round(x-flag/2)), int(math.Round(y-flag/2))
+		fill(image.Rect(x0, y0, x0+int(math.Round(flag)), y0+int(math.Round(flag))), style.Background)
+		for i := 0; i < markerChecks*markerChecks; i += 2 {
+			cx := x0 + int(math.Round(float64(i%markerChecks)*flag/markerChecks))
+			cy := y0 + int(math.Round(float64(i/markerChecks)*flag/markerChecks))
+			cx1 := x0 + int(math.Round(float64(i%markerChecks+1)*flag/markerChecks))
+			cy1 := y0 + int(math.Round(float64(i/markerChecks+1)*flag/markerChecks))
+			fill(image.Rect(cx, cy, cx1, cy1), style.Wall)
+		}
+	}
+	if len(opts.Info) == 0 {
+		return png.Encode(w, img)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	// The encoder can't write text chunks, so splice them in after the
+	// header chunk, which always comes first.
+	b := buf.Bytes()
+	var text []byte
+	for _, key := range sortedKeys(opts.Info) {
+		text = appendPNGChunk(text, "tEXt", []byte(infoPrefix+key+"\x00"+opts.Info[key]))
+	}
+	if _, err := w.Write(b[:pngHeaderEnd]); err != nil {
+		return err
+	}
+	if _, err := w.Write(text); err != nil {
+		return err
+	}
+	_, err := w.Write(b[pngHeaderEnd:])
+	return err
+}
+
+// drawStretched draws src over the rectangle r of dst, stretched to fit,
+// picking the nearest pixel of src for each pixel of dst.
+func drawStretched(dst draw.Image, r image.Rectangle, src image.Image) {
+	b := src.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := b.Min.Y + ((y-r.Min.Y)*b.Dy()+b.Dy()/2)/r.Dy()
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := b.Min.X + ((x-r.Min.X)*b.Dx()+b.Dx()/2)/r.Dx()
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+}
+
+// pngHeaderEnd is the offset just past a PNG file's signature and IHDR
+// chunk.
+const pngHeaderEnd = 8 + 4 + 4 + 13 + 4
+
+// appendPNGChunk appends a PNG chunk: length, type, data and CRC.
+func appendPNGChunk(buf []byte, kind string, data []byte) []byte {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	start := len(buf)
+	buf = append(append(append(buf, n[:]...), kind...), data...)
+	binary.BigEndian.PutUint32(n[:], crc32.ChecksumIEEE(buf[start+4:]))
+	return append(buf, n[:]...)
+}
+
+// readPNGText returns the tEXt chunks of the PNG file b by keyword.
+func readPNGText(b []byte) (map[string]string, error) {
+	if len(b) < 8 || string(b[:8]) != "\x89PNG\r\n\x1a\n" {
+		return nil, errors.New("not a PNG file")
+	}
+	text := map[string]string{}
+	for b = b[8:]; len(b) >= 12; {
+		n := int(binary.BigEndian.Uint32(b))
+		if n > len(b)-12 {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		if string(b[4:8]) == "tEXt" {
+			if key, value, ok := strings.Cut(string(b[8:8+n]), "\x00"); ok {
+				text[key] = value
+			}
+		}
+		b = b[12+n:]
+	}
+	return text, nil
+}
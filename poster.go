@@ -0,0 +1,105 @@
+package maze
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Poster is a US letter sheet of mazes, Across to a row, each with a
+// caption under it, for classroom handouts and activity sheets.
+type Poster struct {
+	Mazes    []Grid
+	Captions []string
+	Across   int
+	// Title, if not "", is a heading for the top of the sheet.
+	Title string
+	Style Style
+	// Info is written into the file as RenderOptions.Info is.
+	Info map[string]string
+}
+
+// posterMargin is the blank border round a poster, posterTitle how much
+// room its title takes and posterCaption how much each caption does, all
+// in points.
+const (
+	posterMargin  = 36
+	posterTitle   = 36
+	posterCaption = 20
+)
+
+// layout calls place for each of the mazes with the box it goes in on the
+// sheet, with y going down from the top of the page, leaving room at the
+// top for the title if there is one.
+func (p *Poster) layout(place func(i int, x, y, width, height float64)) {
+	top := float64(posterMargin)
+	if p.Title != "" {
+		top += posterTitle
+	}
+	n := len(p.Mazes)
+	down := (n + p.Across - 1) / p.Across
+	width := float64(pdfPageWidth-2*posterMargin) / float64(p.Across)
+	height := (float64(pdfPageHeight-posterMargin) - top) / float64(down)
+	for i := 0; i < n; i++ {
+		place(i, posterMargin+float64(i%p.Across)*width, top+float64(i/p.Across)*height, width, height)
+	}
+}
+
+// WritePDF writes the poster to w as a PDF.
+func (p *Poster) WritePDF(w io.Writer) error {
+	doc := &pdfDoc{info: p.Info}
+	page := doc.newPage()
+	p.layout(func(i int, x, y, width, height float64) {
+		// PDF has y going up, from the bottom of the page.
+		y = pdfPageHeight - y - height
+		pdfText(page, x+width/2, y+4, 10, p.Captions[i])
+		pdfMaze(page, &p.Mazes[i], x+8, y+posterCaption, width-16, height-posterCaption-8, RenderOptions{Style: &p.Style})
+	})
+	if p.Title != "" {
+		pdfText(page, pdfPageWidth/2, pdfPageHeight-posterMargin-18, 18, p.Title)
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// WriteSVG writes the poster to w as an SVG, each maze drawn by renderSVG
+// and scaled to fit its box, with Info in comments as renderSVG has it.
+func (p *Poster) WriteSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"8.5in\" height=\"11in\" viewBox=\"0 0 %d %d\">\n",
+		pdfPageWidth, pdfPageHeight)
+	for _, key := range sortedKeys(p.Info) {
+		fmt.Fprintf(bw, "<!-- %s%s=%s -->\n", infoPrefix, key, strings.ReplaceAll(p.Info[key], "--", "- -"))
+	}
+	style := p.Style
+	fmt.Fprintf(bw, "<rect width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", pdfPageWidth, pdfPageHeight, hexColor(style.Background))
+	fmt.Fprintf(bw, "<g fill=\"%s\" font-family=\"sans-serif\" text-anchor=\"middle\">\n", hexColor(style.Wall))
+	if p.Title != "" {
+		fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\" font-size=\"18\">%s</text>\n", pdfPageWidth/2, posterMargin+18, html.EscapeString(p.Title))
+	}
+	var err error
+	p.layout(func(i int, x, y, width, height float64) {
+		if err != nil {
+			return
+		}
+		g := &p.Mazes[i]
+		// renderSVG draws it with half a cell of margin all round.
+		mw := float64((g.ColCount + 1) * style.CellSize)
+		mh := float64((g.RowCount + 1) * style.CellSize)
+		scale := min((width-16)/mw, (height-posterCaption-8)/mh)
+		fmt.Fprintf(bw, "<g transform=\"translate(%.2f %.2f) scale(%.4f)\">\n",
+			x+(width-mw*scale)/2, y+8+(height-posterCaption-8-mh*scale)/2, scale)
+		if err = renderSVG(g, bw, RenderOptions{Style: &style}); err != nil {
+			return
+		}
+		fmt.Fprintf(bw, "</g>\n<text x=\"%.2f\" y=\"%.2f\" font-size=\"10\">%s</text>\n",
+			x+width/2, y+height-6, html.EscapeString(p.Captions[i]))
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(bw, "</g>\n</svg>\n")
+	return bw.Flush()
+}
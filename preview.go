@@ -0,0 +1,94 @@
+package maze
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Density splits the maze into blocks of block x block cells (smaller at the
+// bottom and right edges) and returns how open each one is: the fraction of
+// the walls between and below its cells that have been carved away.  It
+// returns the number of block rows and columns, and the densities in row
+// order.
+func (g *Grid) Density(block int) (rows, cols int, density []float64) {
+	rows, cols = (g.RowCount+block-1)/block, (g.ColCount+block-1)/block
+	open := make([]int, rows*cols)
+	walls := make([]int, rows*cols)
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			i := row/block*cols + col/block
+			cell := g.openings(row, col)
+			for _, d := range []Direction{E, S} {
+				if g.inside(row+rowOffset[d], col+colOffset[d]) {
+					walls[i]++
+					if cell&d != 0 {
+						open[i]++
+					}
+				}
+			}
+		}
+	}
+	density = make([]float64, len(open))
+	for i := range density {
+		if walls[i] > 0 {
+			density[i] = float64(open[i]) / float64(walls[i])
+		}
+	}
+	return rows, cols, density
+}
+
+// previewShades are the characters renderPreview draws, from the least open
+// blocks to the most.
+var previewShades = []rune("█▓▒░ ")
+
+// previewLevels scales each density to a level from 0 to levels-1, stretched
+// over the range of densities found so differences show up: in a perfect
+// maze every block is close to half open.
+func previewLevels(density []float64, levels int) []int {
+	lo, hi := 1.0, 0.0
+	for _, d := range density {
+		if d < lo {
+			lo = d
+		}
+		if d > hi {
+			hi = d
+		}
+	}
+	out := make([]int, len(density))
+	for i, d := range density {
+		if hi > lo {
+			out[i] = int((d - lo) / (hi - lo) * float64(levels-1))
+		}
+	}
+	return out
+}
+
+// renderPreview draws one character for each block of block x block cells,
+// shaded by how open the block is.
+func renderPreview(g *Grid, w io.Writer, block int) error {
+	rows, cols, density := g.Density(block)
+	levels := previewLevels(density, len(previewShades))
+	var buf []byte
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			buf = append(buf, string(previewShades[levels[row*cols+col]])...)
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// renderPreviewPNG draws one grey pixel for each block of block x block
+// cells, lighter the more open the block is.
+func renderPreviewPNG(g *Grid, w io.Writer, block int) error {
+	rows, cols, density := g.Density(block)
+	levels := previewLevels(density, 256)
+	img := image.NewGray(image.Rect(0, 0, cols, rows))
+	for i, level := range levels {
+		img.SetGray(i%cols, i/cols, color.Gray{uint8(level)})
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,153 @@
+package maze
+
+import (
+	"sort"
+
+	"github.com/overthink/maze-go/internal/protowire"
+)
+
+// MarshalProto encodes g as the Grid message in maze.proto, for exchanging
+// mazes with programs in other languages more compactly than JSON.
+func (g *Grid) MarshalProto() []byte {
+	b := protowire.AppendInt(nil, 1, int64(g.RowCount))
+	b = protowire.AppendInt(b, 2, int64(g.ColCount))
+	b = protowire.AppendBytes(b, 3, g.data)
+	b = protowire.AppendInts(b, 4, g.CellIds(g.Entrances))
+	b = protowire.AppendInts(b, 5, g.CellIds(g.Exits))
+	ids := make([]int, 0, len(g.meta))
+	for id := range g.meta {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		meta := protowire.AppendInt(nil, 1, int64(id))
+		for _, key := range sortedKeys(g.meta[id]) {
+			entry := protowire.AppendBytes(nil, 1, []byte(key))
+			entry = protowire.AppendBytes(entry, 2, []byte(g.meta[id][key]))
+			meta = protowire.AppendBytes(meta, 2, entry)
+		}
+		b = protowire.AppendBytes(b, 6, meta)
+	}
+	ids = ids[:0]
+	for id := range g.oneWay {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		entry := protowire.AppendInt(nil, 1, int64(id))
+		entry = protowire.AppendInt(entry, 2, int64(g.oneWay[id]))
+		b = protowire.AppendBytes(b, 7, entry)
+	}
+	return b
+}
+
+// UnmarshalProto decodes a Grid message, checking it the same way as
+// UnmarshalJSON.
+func (g *Grid) UnmarshalProto(b []byte) error {
+	var j gridJSON
+	err := protowire.Read(b, func(f protowire.Field) error {
+		var err error
+		switch f.Num {
+		case 1:
+			j.Rows = f.Int32()
+		case 2:
+			j.Cols = f.Int32()
+		case 3:
+			j.Cells = append([]uint8(nil), f.Data...)
+		case 4:
+			j.Entrances, err = f.AppendInt32s(j.Entrances)
+		case 5:
+			j.Exits, err = f.AppendInt32s(j.Exits)
+		case 6:
+			err = j.readProtoMeta(f)
+		case 7:
+			err = j.readProtoOneWay(f)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	grid, err := j.grid()
+	if err != nil {
+		return err
+	}
+	*g = grid
+	return nil
+}
+
+// readProtoMeta adds the metadata in a CellMeta message to j.
+func (j *gridJSON) readProtoMeta(f protowire.Field) error {
+	if f.Wire != protowire.Bytes {
+		return protowire.ErrMalformed
+	}
+	id := 0
+	values := map[string]string{}
+	err := protowire.Read(f.Data, func(f protowire.Field) error {
+		switch f.Num {
+		case 1:
+			id = f.Int32()
+		case 2:
+			if f.Wire != protowire.Bytes {
+				return protowire.ErrMalformed
+			}
+			var key, value string
+			err := protowire.Read(f.Data, func(f protowire.Field) error {
+				switch f.Num {
+				case 1:
+					key = string(f.Data)
+				case 2:
+					value = string(f.Data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			values[key] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if j.Meta == nil {
+		j.Meta = map[int]map[string]string{}
+	}
+	if j.Meta[id] == nil {
+		j.Meta[id] = map[string]string{}
+	}
+	for key, value := range values {
+		j.Meta[id][key] = value
+	}
+	return nil
+}
+
+// readProtoOneWay adds the one-way passages in an entry of the oneway map
+// to j.
+func (j *gridJSON) readProtoOneWay(f protowire.Field) error {
+	if f.Wire != protowire.Bytes {
+		return protowire.ErrMalformed
+	}
+	id, d := 0, 0
+	err := protowire.Read(f.Data, func(f protowire.Field) error {
+		switch f.Num {
+		case 1:
+			id = f.Int32()
+		case 2:
+			d = f.Int32()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if j.OneWay == nil {
+		j.OneWay = map[int]Direction{}
+	}
+	j.OneWay[id] |= Direction(d)
+	return nil
+}
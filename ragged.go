@@ -0,0 +1,160 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// raggedAligns are the ways --align lines up the rows of a ragged maze,
+// each returning the column a row width cells wide starts at when the
+// widest is widest.
+var raggedAligns = map[string]func(width, widest int) int{
+	"left":   func(width, widest int) int { return 0 },
+	"centre": func(width, widest int) int { return (widest - width) / 2 },
+	"right":  func(width, widest int) int { return widest - width },
+}
+
+// RaggedGrid is a maze whose rows can each be a different number of cells
+// wide, e.g. a pyramid or a staircase, keeping only the cells it has
+// rather than a rectangle's worth with a mask over them.  It's a Graph,
+// its nodes the cells reading row by row, so the MazifyGraph functions
+// carve it.  The cells below and above each other are neighbours, so rows
+// have to overlap the ones next to them.
+type RaggedGrid struct {
+	// Starts is the column each row's first cell is in, and Widths how many
+	// cells wide each row is.
+	Starts, Widths []int
+	// Observer, if not nil, is told about each wall carved, in the columns
+	// of Starts.
+	Observer *Observer
+
+	// first is the node of each row's first cell, and one past the last.
+	first []int
+	data  []uint8
+}
+
+// NewRaggedGrid returns a ragged grid with a row for each of widths, that
+// many cells wide, lined up by the named way of raggedAligns, with every
+// wall up.
+func NewRaggedGrid(widths []int, align string) (*RaggedGrid, error) {
+	start, ok := raggedAligns[align]
+	if !ok {
+		return nil, fmt.Errorf("unknown alignment %q", align)
+	}
+	if len(widths) == 0 {
+		return nil, fmt.Errorf("a ragged grid needs at least one row")
+	}
+	widest := 0
+	for _, w := range widths {
+		if w < 1 {
+			return nil, fmt.Errorf("bad row width %d: rows need at least one cell", w)
+		}
+		widest = max(widest, w)
+	}
+	r := &RaggedGrid{Widths: widths, Starts: make([]int, len(widths)), first: make([]int, len(widths)+1)}
+	for row, w := range widths {
+		r.Starts[row] = start(w, widest)
+		r.first[row+1] = r.first[row] + w
+		if row > 0 && (r.Starts[row] >= r.end(row-1) || r.Starts[row-1] >= r.end(row)) {
+			return nil, fmt.Errorf("rows %d and %d don't overlap, so can't be joined", row-1, row)
+		}
+	}
+	if err := CheckGridSize(len(widths), widest); err != nil {
+		return nil, err
+	}
+	r.data = make([]uint8, r.first[len(widths)])
+	return r, nil
+}
+
+// end returns the column one past the last cell of row.
+func (r *RaggedGrid) end(row int) int {
+	return r.Starts[row] + r.Widths[row]
+}
+
+// Cols returns how many columns the rows span between them.
+func (r *RaggedGrid) Cols() int {
+	cols := 0
+	for row := range r.Widths {
+		cols = max(cols, r.end(row))
+	}
+	return cols
+}
+
+// node returns the node of cell (row, col), or false if there isn't one.
+func (r *RaggedGrid) node(row, col int) (int, bool) {
+	if row < 0 || row >= len(r.Widths) || col < r.Starts[row] || col >= r.end(row) {
+		return 0, false
+	}
+	return r.first[row] + col - r.Starts[row], true
+}
+
+// cell returns the row and column of node.
+func (r *RaggedGrid) cell(node int) (row, col int) {
+	row = sort.SearchInts(r.first[1:], node+1)
+	return row, r.Starts[row] + node - r.first[row]
+}
+
+func (r *RaggedGrid) Nodes() int {
+	return len(r.data)
+}
+
+// Degree returns 4, for the N, E, S and W sides of a cell.
+func (r *RaggedGrid) Degree() int {
+	return len(gridSides)
+}
+
+// Neighbour returns the node on side gridSides[side] of node, or -1 at the
+// edge of the grid.
+func (r *RaggedGrid) Neighbour(node, side int) int {
+	row, col := r.cell(node)
+	n := neighbourSteps[side]
+	if next, ok := r.node(row+n.row, col+n.col); ok {
+		return next
+	}
+	return -1
+}
+
+// Connect removes the wall between the neighbouring nodes a and b.
+func (r *RaggedGrid) Connect(a, b int) {
+	row, col := r.cell(a)
+	for _, n := range neighbourSteps {
+		if next, ok := r.node(row+n.row, col+n.col); ok && next == b {
+			r.data[a] |= uint8(n.d)
+			r.data[b] |= uint8(opposite[n.d])
+			if r.Observer != nil {
+				r.Observer.carve(len(r.data), row, col, n.d)
+			}
+			return
+		}
+	}
+}
+
+// Mazify carves a maze across the grid with the named algorithm, one of
+// shapeAlgorithms.  It returns ctx.Err() if ctx is done before it
+// finishes.
+func (r *RaggedGrid) Mazify(ctx context.Context, rng *rand.Rand, algorithm string) error {
+	carve, ok := shapeAlgorithms[algorithm]
+	if !ok {
+		return fmt.Errorf("algorithm %q can't carve ragged mazes, only kruskal, prim, rec and wilson can", algorithm)
+	}
+	return carve(ctx, r, rng, 0)
+}
+
+// Grid lays the maze out in a Grid as wide as the rows span, for the
+// renderers and solvers, and returns it with the mask of the cells that are
+// the maze's, by CellId, for RenderOptions.Mask.  Its start is the first
+// cell of the top row and its finish the last of the bottom row.
+func (r *RaggedGrid) Grid() (Grid, []bool) {
+	g := newGrid(len(r.Widths), r.Cols())
+	mask := make([]bool, len(g.data))
+	for node, cell := range r.data {
+		id := g.CellId(r.cell(node))
+		g.data[id], mask[id] = cell, true
+	}
+	last := len(r.Widths) - 1
+	g.Entrances = []Cell{{0, r.Starts[0]}}
+	g.Exits = []Cell{{last, r.end(last) - 1}}
+	return g, mask
+}
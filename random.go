@@ -0,0 +1,41 @@
+package maze
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+)
+
+// splitRand returns a rand.Rand for another goroutine to use and the seed
+// it drew for it from rng, or CryptoRand itself and 0 if that's what rng is.
+func splitRand(rng *rand.Rand) (*rand.Rand, int64) {
+	if rng == CryptoRand {
+		return rng, 0
+	}
+	seed := rng.Int63()
+	return rand.New(rand.NewSource(seed)), seed
+}
+
+// CryptoRand draws from crypto/rand, for puzzles whose solution mustn't be
+// recoverable by brute forcing a math/rand seed.  Its source has no state,
+// so unlike other rand.Rands it's safe to share between goroutines (except
+// for Read).
+var CryptoRand = rand.New(cryptoSource{})
+
+// cryptoSource is a rand.Source reading from crypto/rand.  Seed does
+// nothing.
+type cryptoSource struct{}
+
+func (cryptoSource) Seed(int64) {}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
@@ -0,0 +1,92 @@
+package maze
+
+import (
+	"image/color"
+	"io"
+	"math/rand"
+)
+
+// Regions splits the maze into one region per seed cell by flooding out
+// through its passages from all the seeds at once, so each cell joins the
+// region of the seed nearest to it.  It returns the region index (into
+// seeds) of every cell by CellId; cells no seed can reach are -1.
+func (g *Grid) Regions(seeds []Cell) []int {
+	return g.regions(g.CellIds(seeds))
+}
+
+// regions is Regions with the seeds as CellIds.
+func (g *Grid) regions(seeds []int) []int {
+	region := make([]int, len(g.data))
+	for i := range region {
+		region[i] = -1
+	}
+	var queue []int
+	for i, seed := range seeds {
+		if region[seed] < 0 {
+			region[seed] = i
+			queue = append(queue, seed)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		row, col := id/g.ColCount, id%g.ColCount
+		for _, d := range []Direction{N, E, S, W} {
+			if g.openings(row, col)&d == 0 {
+				continue
+			}
+			next := g.CellId(row+rowOffset[d], col+colOffset[d])
+			if region[next] < 0 {
+				region[next] = region[id]
+				queue = append(queue, next)
+			}
+		}
+	}
+	return region
+}
+
+// RandomRegions is Regions with k distinct seed cells picked at random.
+func (g *Grid) RandomRegions(rng *rand.Rand, k int) []int {
+	if k > len(g.data) {
+		k = len(g.data)
+	}
+	return g.regions(rng.Perm(len(g.data))[:k])
+}
+
+// regionColors are the ANSI background colours FprintRegions cycles
+// through.
+var regionColors = []string{
+	"\x1b[41m", "\x1b[42m", "\x1b[43m", "\x1b[44m", "\x1b[45m", "\x1b[46m",
+	"\x1b[101m", "\x1b[102m", "\x1b[103m", "\x1b[104m", "\x1b[105m", "\x1b[106m",
+}
+
+// regionPalette is regionColors as RGB, for the image formats.
+var regionPalette = []color.RGBA{
+	{205, 49, 49, 255}, {13, 188, 121, 255}, {229, 229, 16, 255},
+	{36, 114, 200, 255}, {188, 63, 188, 255}, {17, 168, 205, 255},
+	{241, 76, 76, 255}, {35, 209, 139, 255}, {245, 245, 67, 255},
+	{59, 142, 234, 255}, {214, 112, 214, 255}, {41, 184, 219, 255},
+}
+
+// FprintRegions writes the maze to w like Fprint, with each cell's
+// background coloured by its region as returned by Regions.
+func (g *Grid) FprintRegions(w io.Writer, region []int) {
+	g.fprintRegions(w, region, regionColors)
+}
+
+// fprintRegions is FprintRegions cycling through the given ANSI background
+// colours.
+func (g *Grid) fprintRegions(w io.Writer, region []int, regionColors []string) {
+	buf := g.AppendTextTop(nil)
+	colors := make([]string, g.ColCount)
+	for row := 0; row < g.RowCount; row++ {
+		for col := range colors {
+			colors[col] = ""
+			if r := region[g.CellId(row, col)]; r >= 0 {
+				colors[col] = regionColors[r%len(regionColors)]
+			}
+		}
+		buf = g.AppendTextRow(buf, row, nil, colors)
+	}
+	w.Write(buf)
+}
@@ -0,0 +1,378 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+// RenderOptions are the settings a Renderer draws a maze with.  Renderers
+// ignore options that don't apply to them.
+type RenderOptions struct {
+	// Regions, if not nil, colours each cell by its region as returned by
+	// Regions.
+	Regions []int
+	// Style, if not nil, is how the graphical formats draw the maze.
+	Style *Style
+	// Path, if not nil, is the cells to draw as the solution.
+	Path []Cell
+	// Palette, if not nil, overrides the colours of the regions, paths,
+	// solution and spine, and the heatmap's shading.
+	Palette *Palette
+	// Arrows makes the text formats draw Path as arrows showing which way
+	// it goes, with corners where it turns, instead of dots.
+	Arrows bool
+	// Markers makes the text formats label the start S and the finish F,
+	// and the svg, png and pdf formats draw a green dot on the start and a
+	// chequered flag on the finish, for puzzles to print.
+	Markers bool
+	// Openings makes the text (drawn without blocks or regions), svg, png
+	// and pdf formats leave a gap in the outer wall by the start and the
+	// finish, where they're on the edge,
+	// and the graphical ones draw an arrow in the margin pointing in at
+	// the start and out at the finish.  The pdf format always leaves the
+	// gaps.
+	Openings bool
+	// Spine, if not nil, is a path the graphical formats draw under the
+	// solution in Style.Spine, usually the maze's LongestPath.
+	Spine []Cell
+	// Paths, if not nil, are more paths for the graphical formats to draw,
+	// each in its own colour from pathPalette, with a legend of their
+	// labels in the formats with text.  PNG has no text, so no legend.
+	Paths []LabeledPath
+	// Background, if not nil, is drawn behind the maze by the PNG format,
+	// stretched to cover the grid.
+	Background image.Image
+	// TextSize, if not nil, makes the text format draw the maze in blocks
+	// of that size instead of its compact two characters a cell.
+	TextSize *TextSize
+	// Preview, if above 0, makes the text and PNG formats draw a
+	// character or pixel for each block of Preview x Preview cells, shaded
+	// by how open it is, instead of every wall.
+	Preview int
+	// Info is embedded in the formats that have somewhere to put it, so
+	// the maze can be regenerated later, e.g. the seed and algorithm.  The
+	// info command reads it back.
+	Info map[string]string
+	// Visits, if not nil, is how many times a solver visited each cell, by
+	// CellId, as CountVisits returns it, for the PNG format to shade.
+	Visits []int
+	// Order, if not nil, is when generation first carved into each cell,
+	// by CellId, counting from 0, or -1 for cells it never carved into,
+	// as an Observer's Carve callback sees it, for the SVG format to number
+	// and shade.
+	Order []int
+	// Mask, if not nil, is which cells, by CellId, the maze is made of when
+	// it's been carved in a shape by MazifyMask.  The text, unicode, svg and
+	// png formats leave out the walls of the cells outside it, so the maze
+	// isn't drawn in a box.
+	Mask []bool
+	// Corridor is how many tiles wide the tiles format draws passages,
+	// with walls a tile thick; 0 is taken as 1.
+	Corridor int
+	// Cut, if not nil, is the material the dxf format lays the maze out
+	// to be laser cut from.
+	Cut *Cut
+	// Glyphs, if not nil, are the characters the unicode format draws walls
+	// with, instead of single box drawing lines.
+	Glyphs *Glyphs
+	// GoPackage and GoName are the package and the name of the constant
+	// the go format writes the maze as: main and Maze if they're empty.
+	GoPackage, GoName string
+	// Chat is the chat app, one of chatLimits, the emoji format fits the
+	// maze to a message of: discord if it's empty.
+	Chat string
+}
+
+// LabeledPath is a path with a label for the legend, such as the name of
+// the solver that found it.
+type LabeledPath struct {
+	Label string
+	Cells []Cell
+}
+
+// pathWidth is how thick the graphical formats draw path i of opts.Paths in
+// cells size wide.  The first is the thickest and each after it thinner, so
+// where the paths share cells they all still show.
+func (opts RenderOptions) pathWidth(i int, size float64) float64 {
+	return size / 2 * float64(len(opts.Paths)-i) / float64(len(opts.Paths))
+}
+
+// TextSize is the size of the parts of a maze drawn with blocks of text, in
+// characters.
+type TextSize struct {
+	CellWidth, CellHeight int
+	// WallWidth is how wide the walls are between cells side by side and
+	// WallHeight how high they are between cells one above the other.
+	WallWidth, WallHeight int
+}
+
+// infoPrefix marks the entries of RenderOptions.Info in rendered output.
+const infoPrefix = "maze:"
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Renderer writes a maze to w in some output format.  Renderers only read
+// the grid (the ones that mark it up work on a Clone), so several can draw
+// the same grid at once.
+type Renderer interface {
+	Render(g *Grid, w io.Writer, opts RenderOptions) error
+}
+
+// RendererFunc lets an ordinary function be used as a Renderer.
+type RendererFunc func(g *Grid, w io.Writer, opts RenderOptions) error
+
+// Render calls f, unless g is nil or has no cells: then it returns an error
+// without calling f, so renderers don't have to check.
+func (f RendererFunc) Render(g *Grid, w io.Writer, opts RenderOptions) error {
+	if g == nil || len(g.data) == 0 {
+		return errors.New("no maze to render")
+	}
+	return f(g, w, opts)
+}
+
+// renderers maps the name used with --format to its Renderer.
+var renderers = map[string]Renderer{
+	"text":      RendererFunc(renderText),
+	"unicode":   RendererFunc(renderUnicode),
+	"svg":       RendererFunc(renderSVG),
+	"png":       RendererFunc(renderPNG),
+	"pdf":       RendererFunc(renderPDF),
+	"html":      RendererFunc(renderHTML),
+	"halfblock": RendererFunc(renderHalfBlock),
+	"isometric": RendererFunc(renderIsometric),
+	"braille":   RendererFunc(renderBraille),
+	"heatmap":   RendererFunc(renderHeatmap),
+	"go":        RendererFunc(renderGo),
+	"mazelib":   RendererFunc(renderMazelib),
+	"mfp":       RendererFunc(renderMFP),
+	"midi":      RendererFunc(renderMIDI),
+	"visits":    RendererFunc(renderVisits),
+	"order":     RendererFunc(renderOrder),
+	"tiles":     RendererFunc(renderTiles),
+	"dxf":       RendererFunc(renderDXF),
+	"emoji":     RendererFunc(renderEmoji),
+}
+
+// RegisterRenderer makes r available as the format name.  It panics if the
+// name is already taken.
+func RegisterRenderer(name string, r Renderer) {
+	if _, ok := renderers[name]; ok {
+		panic(fmt.Sprintf("renderer %q already registered", name))
+	}
+	renderers[name] = r
+}
+
+// LookupRenderer returns the renderer registered as the format name.
+func LookupRenderer(name string) (Renderer, bool) {
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// RendererNames returns the registered format names, sorted.
+func RendererNames() []string {
+	var names []string
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderText draws the maze in ASCII, with Fprint or FprintRegions.
+func renderText(g *Grid, w io.Writer, opts RenderOptions) error {
+	if opts.Preview > 0 {
+		return renderPreview(g, w, opts.Preview)
+	}
+	if opts.Markers {
+		marked := g.Clone()
+		marked.markText()
+		g = &marked
+	}
+	if opts.TextSize == nil && opts.Regions == nil && !opts.Arrows {
+		text := g.appendText(nil, g.CellIds(opts.Path))
+		if opts.Mask != nil {
+			text = maskText(g, text, opts)
+		}
+		if opts.Openings {
+			text = g.textGaps(text, g.borderGaps())
+		}
+		_, err := w.Write(text)
+		return err
+	}
+	if opts.Path != nil {
+		marked := g.Clone()
+		marked.markPath(g.CellIds(opts.Path), opts.Arrows)
+		g = &marked
+	}
+	if opts.TextSize != nil {
+		return renderBlocks(g, w, *opts.TextSize)
+	}
+	if opts.Regions != nil {
+		g.fprintRegions(w, opts.Regions, opts.RegionANSI())
+	} else if opts.Openings {
+		w.Write(g.textGaps(g.appendText(nil, nil), g.borderGaps()))
+	} else {
+		g.Fprint(w)
+	}
+	return nil
+}
+
+// renderUnicode draws the maze with box drawing characters, or
+// opts.Glyphs, each cell three characters wide and labels in the middle of
+// their cells.
+func renderUnicode(g *Grid, w io.Writer, opts RenderOptions) error {
+	glyphs := glyphSets["single"]
+	if opts.Glyphs != nil {
+		glyphs = *opts.Glyphs
+	}
+	if opts.Path != nil {
+		marked := g.Clone()
+		marked.markPath(g.CellIds(opts.Path), opts.Arrows)
+		g = &marked
+	}
+	// hWall and vWall report whether there's a wall along the top and the
+	// left of cell (row, col), where row and col can be one past the end.
+	hWall := func(row, col int) bool {
+		if opts.Mask != nil && !opts.drawsWall(g, row, col, N) {
+			return false
+		}
+		return row == 0 || row == g.RowCount || g.openings(row, col)&N == 0
+	}
+	vWall := func(row, col int) bool {
+		if opts.Mask != nil && !opts.drawsWall(g, row, col, W) {
+			return false
+		}
+		return col == 0 || col == g.ColCount || g.openings(row, col)&W == 0
+	}
+
+	var buf []byte
+	for row := 0; row <= g.RowCount; row++ {
+		for col := 0; col <= g.ColCount; col++ {
+			corner := 0
+			if row > 0 && vWall(row-1, col) {
+				corner |= 1
+			}
+			if col < g.ColCount && hWall(row, col) {
+				corner |= 2
+			}
+			if row < g.RowCount && vWall(row, col) {
+				corner |= 4
+			}
+			if col > 0 && hWall(row, col-1) {
+				corner |= 8
+			}
+			buf = utf8.AppendRune(buf, glyphs.Corners[corner])
+			if col < g.ColCount {
+				if corner&2 != 0 {
+					buf = utf8.AppendRune(utf8.AppendRune(buf, glyphs.Horizontal), glyphs.Horizontal)
+				} else {
+					buf = append(buf, "  "...)
+				}
+			}
+		}
+		buf = append(buf, '\n')
+		if row == g.RowCount {
+			break
+		}
+		for col := 0; col <= g.ColCount; col++ {
+			if vWall(row, col) {
+				buf = utf8.AppendRune(buf, glyphs.Vertical)
+			} else {
+				buf = append(buf, ' ')
+			}
+			if col < g.ColCount {
+				label := ' '
+				if value, ok := g.Meta(Cell{row, col}, LabelKey); ok && value != "" {
+					label = []rune(value)[0]
+				}
+				buf = utf8.AppendRune(buf, label)
+				buf = append(buf, ' ')
+			}
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// renderBlocks draws the maze with walls made of solid blocks, at the given
+// size, and labels in the middle of their cells.
+func renderBlocks(g *Grid, w io.Writer, size TextSize) error {
+	// hWall and vWall are as in renderUnicode.
+	hWall := func(row, col int) bool {
+		return row == 0 || row == g.RowCount || g.openings(row, col)&N == 0
+	}
+	vWall := func(row, col int) bool {
+		return col == 0 || col == g.ColCount || g.openings(row, col)&W == 0
+	}
+	const block = "█"
+	repeat := func(buf []byte, s string, n int) []byte {
+		for i := 0; i < n; i++ {
+			buf = append(buf, s...)
+		}
+		return buf
+	}
+
+	var buf []byte
+	for row := 0; row <= g.RowCount; row++ {
+		for i := 0; i < size.WallHeight; i++ {
+			for col := 0; col <= g.ColCount; col++ {
+				// Corners are filled in if any wall meets them.
+				corner := (row > 0 && vWall(row-1, col)) || (row < g.RowCount && vWall(row, col)) ||
+					(col > 0 && hWall(row, col-1)) || (col < g.ColCount && hWall(row, col))
+				if corner {
+					buf = repeat(buf, block, size.WallWidth)
+				} else {
+					buf = repeat(buf, " ", size.WallWidth)
+				}
+				if col < g.ColCount {
+					if hWall(row, col) {
+						buf = repeat(buf, block, size.CellWidth)
+					} else {
+						buf = repeat(buf, " ", size.CellWidth)
+					}
+				}
+			}
+			buf = append(buf, '\n')
+		}
+		if row == g.RowCount {
+			break
+		}
+		for i := 0; i < size.CellHeight; i++ {
+			for col := 0; col <= g.ColCount; col++ {
+				if vWall(row, col) {
+					buf = repeat(buf, block, size.WallWidth)
+				} else {
+					buf = repeat(buf, " ", size.WallWidth)
+				}
+				if col == g.ColCount {
+					break
+				}
+				label, _ := g.Meta(Cell{row, col}, LabelKey)
+				if i != size.CellHeight/2 || label == "" {
+					buf = repeat(buf, " ", size.CellWidth)
+					continue
+				}
+				left := (size.CellWidth - 1) / 2
+				buf = repeat(buf, " ", left)
+				buf = utf8.AppendRune(buf, []rune(label)[0])
+				buf = repeat(buf, " ", size.CellWidth-left-1)
+			}
+			buf = append(buf, '\n')
+		}
+	}
+	_, err := w.Write(buf)
+	return err
+}
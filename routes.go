@@ -0,0 +1,195 @@
+package maze
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// routeOverlap is the most of the shorter of two routes AddRoutes lets them
+// share, as a fraction of its cells, for them to count as different.
+// routeDetour is how much more than a cell of its own it costs a new route
+// to go through a cell of one found already, and routeAttempts how many
+// tries AddRoutes has per route wanted before giving up.
+const (
+	routeOverlap  = 0.5
+	routeDetour   = 4
+	routeAttempts = 20
+)
+
+// routeOverlapFraction returns how much of the shorter of the routes a and
+// b, lists of CellIds, is cells they both go through.
+func routeOverlapFraction(a, b []int) float64 {
+	in := make(map[int]bool, len(a))
+	for _, id := range a {
+		in[id] = true
+	}
+	shared := 0
+	for _, id := range b {
+		if in[id] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(min(len(a), len(b)))
+}
+
+// cheapestRoute returns the path from start to end that minimizes the total
+// cost of the cells entered, plus wall for each wall it goes through as if
+// it were knocked down.
+func (g *Grid) cheapestRoute(start, end int, cost []float64, wall float64) []int {
+	dist := make([]float64, len(g.data))
+	parent := make([]int, len(g.data))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[start] = start
+	queue := &costQueue{{start, 0}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(costItem)
+		if item.id == end {
+			break
+		}
+		if item.cost > dist[item.id] {
+			continue // stale
+		}
+		row, col := item.id/g.ColCount, item.id%g.ColCount
+		for _, n := range neighbourSteps {
+			if !g.inside(row+n.row, col+n.col) {
+				continue
+			}
+			next := g.CellId(row+n.row, col+n.col)
+			nextCost := item.cost + cost[next]
+			if g.HasWall(Cell{row, col}, n.d) {
+				nextCost += wall
+			}
+			if parent[next] >= 0 && dist[next] <= nextCost {
+				continue
+			}
+			dist[next] = nextCost
+			parent[next] = item.id
+			heap.Push(queue, costItem{next, nextCost})
+		}
+	}
+	return walkBack(parent, end)
+}
+
+// AddRoutes knocks down walls to give the maze k routes from start to
+// finish that differ from each other in at least half their cells, none
+// more than slack longer, as a fraction, than the shortest, for racing
+// games where players should get a choice of ways.  Each new route is the
+// cheapest way to the finish keeping off the routes so far, with the walls
+// in its way as dear as they can be for it to be short enough, so as few
+// as can be are knocked down.  A new route that's a shortcut drops the
+// routes it makes too long.  It returns the routes, shortest first,
+// checked against the solver, or an error if it can't make k of them.
+func (g *Grid) AddRoutes(rng *rand.Rand, k int, slack float64) ([][]Cell, error) {
+	routes, err := g.addRoutes(rng, k, slack)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([][]Cell, len(routes))
+	for i, route := range routes {
+		cells[i] = g.PathCells(route)
+	}
+	return cells, nil
+}
+
+// addRoutes is AddRoutes with the routes as CellIds.
+func (g *Grid) addRoutes(rng *rand.Rand, k int, slack float64) ([][]int, error) {
+	start, finish := g.endpoints()
+	first := g.solveNearest([]int{start}, []int{finish})
+	if first == nil {
+		return nil, fmt.Errorf("there's no way from the start to the finish")
+	}
+	routes := [][]int{first}
+	// longest is how long a route can be, slack longer than the shortest.
+	longest := int(float64(len(first)-1) * (1 + slack))
+	cost := make([]float64, len(g.data))
+	onRoute := make([]bool, len(g.data))
+	var carved []linkOp
+	for attempt := 0; len(routes) < k; attempt++ {
+		if attempt == routeAttempts*k {
+			return nil, fmt.Errorf("only found %d different routes within %g of the shortest, not %d", len(routes), slack, k)
+		}
+		clear(onRoute)
+		for _, r := range routes {
+			for _, id := range r {
+				onRoute[id] = true
+			}
+		}
+		for id := range cost {
+			cost[id] = 1 + rng.Float64()
+			if onRoute[id] && id != start && id != finish {
+				cost[id] += routeDetour
+			}
+		}
+		// Home in on the dearest walls can be with the route still short
+		// enough and different enough.
+		fits := func(route []int) bool {
+			for _, r := range routes {
+				if routeOverlapFraction(r, route) > routeOverlap {
+					return false
+				}
+			}
+			return len(route)-1 <= longest
+		}
+		low, high := 0.0, float64(len(g.data))
+		for i := 0; i < 20; i++ {
+			wall := (low + high) / 2
+			if fits(g.cheapestRoute(start, finish, cost, wall)) {
+				low = wall
+			} else {
+				high = wall
+			}
+		}
+		route := g.cheapestRoute(start, finish, cost, low)
+		carved = carved[:0]
+		for i := 1; i < len(route); i++ {
+			row, col, d := route[i-1]/g.ColCount, route[i-1]%g.ColCount, g.direction(route[i-1], route[i])
+			if g.HasWall(Cell{row, col}, d) {
+				g.carve(row, col, d)
+				carved = append(carved, linkOp{row, col, d, false})
+			}
+		}
+
+		// Keep the routes still short enough, if the new one is different
+		// enough from them; otherwise put the walls back and try again.
+		shortest := len(g.solveNearest([]int{start}, []int{finish})) - 1
+		newLongest := int(float64(shortest) * (1 + slack))
+		var kept [][]int
+		different := true
+		for _, r := range routes {
+			if len(r)-1 <= newLongest {
+				different = different && routeOverlapFraction(r, route) <= routeOverlap
+				kept = append(kept, r)
+			}
+		}
+		if !different {
+			for _, op := range carved {
+				g.apply(op)
+			}
+			continue
+		}
+		if len(route)-1 <= newLongest {
+			kept = append(kept, route)
+		}
+		routes, longest = kept, newLongest
+		slices.SortStableFunc(routes, func(a, b []int) int { return len(a) - len(b) })
+	}
+
+	// Check the routes against the solver: each goes through open walls,
+	// and none is too long.
+	longest = int(float64(len(g.solveNearest([]int{start}, []int{finish}))-1) * (1 + slack))
+	for i, r := range routes {
+		for j := 1; j < len(r); j++ {
+			if g.HasWall(g.CellOf(r[j-1]), g.direction(r[j-1], r[j])) {
+				return nil, fmt.Errorf("route %d goes through a wall", i+1)
+			}
+		}
+		if len(r)-1 > longest {
+			return nil, fmt.Errorf("route %d is %d cells long, over %g longer than the shortest", i+1, len(r)-1, slack)
+		}
+	}
+	return routes, nil
+}
@@ -0,0 +1,221 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// shapes are the outlines --shape carves mazes in, each reporting whether
+// the point (x, y) is inside it, with the grid stretched to run from -1 to
+// 1 across and down, y growing downwards.
+var shapes = map[string]func(x, y float64) bool{
+	"circle": func(x, y float64) bool {
+		return x*x+y*y <= 1
+	},
+	"diamond": func(x, y float64) bool {
+		return math.Abs(x)+math.Abs(y) <= 1
+	},
+	"heart": func(x, y float64) bool {
+		// The heart curve (x² + y² - 1)³ = x²y³ spans about -1.14 to 1.14
+		// across and -1 to 1.24 up, so it's moved and scaled to fill the grid.
+		x, y = x*1.14, 0.12-y*1.12
+		r := x*x + y*y - 1
+		return r*r*r <= x*x*y*y*y
+	},
+	"ring": func(x, y float64) bool {
+		r := x*x + y*y
+		return r <= 1 && r >= 0.5*0.5
+	},
+}
+
+// ShapeNames returns the names of the shapes, sorted.
+func ShapeNames() []string {
+	names := make([]string, 0, len(shapes))
+	for name := range shapes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ShapeMask returns which cells, by CellId, of a rows x cols grid are inside
+// the named shape, stretched to fill the grid.  A cell is in if its middle
+// is, and only the biggest connected piece is kept, so small grids don't
+// leave cells the maze can't reach.
+func ShapeMask(name string, rows, cols int) ([]bool, error) {
+	inside, ok := shapes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown shape %q", name)
+	}
+	g := newGrid(rows, cols)
+	mask := make([]bool, rows*cols)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			x := (float64(col)+0.5)/float64(cols)*2 - 1
+			y := (float64(row)+0.5)/float64(rows)*2 - 1
+			mask[g.CellId(row, col)] = inside(x, y)
+		}
+	}
+
+	// Label the pieces and keep the biggest.
+	piece := make([]int, len(mask))
+	var sizes []int
+	var stack, adjacent []int
+	for id := range mask {
+		if !mask[id] || piece[id] != 0 {
+			continue
+		}
+		sizes = append(sizes, 0)
+		piece[id] = len(sizes)
+		stack = append(stack[:0], id)
+		for len(stack) > 0 {
+			cell := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			sizes[len(sizes)-1]++
+			adjacent = g.Adjacent(cell, adjacent[:0])
+			for _, next := range adjacent {
+				if mask[next] && piece[next] == 0 {
+					piece[next] = len(sizes)
+					stack = append(stack, next)
+				}
+			}
+		}
+	}
+	biggest := 0
+	for i, size := range sizes {
+		if size > sizes[biggest] {
+			biggest = i
+		}
+	}
+	if len(sizes) == 0 || sizes[biggest] < 2 {
+		return nil, fmt.Errorf("a %dx%d grid is too small for a %s", rows, cols, name)
+	}
+	for id := range mask {
+		mask[id] = piece[id] == biggest+1
+	}
+	return mask, nil
+}
+
+// maskGraph is the cells of a grid inside a mask as a Graph, so the
+// MazifyGraph functions carve a maze of just those.
+type maskGraph struct {
+	grid *Grid
+	// cells is the CellId of each node, and nodes the node of each CellId
+	// or -1 for cells outside the mask.
+	cells, nodes []int
+}
+
+func newMaskGraph(g *Grid, mask []bool) *maskGraph {
+	mg := &maskGraph{grid: g, nodes: make([]int, len(mask))}
+	for id, in := range mask {
+		mg.nodes[id] = -1
+		if in {
+			mg.nodes[id] = len(mg.cells)
+			mg.cells = append(mg.cells, id)
+		}
+	}
+	return mg
+}
+
+func (mg *maskGraph) Nodes() int {
+	return len(mg.cells)
+}
+
+func (mg *maskGraph) Degree() int {
+	return mg.grid.Degree()
+}
+
+func (mg *maskGraph) Neighbour(node, side int) int {
+	if id := mg.grid.Neighbour(mg.cells[node], side); id >= 0 {
+		return mg.nodes[id]
+	}
+	return -1
+}
+
+func (mg *maskGraph) Connect(a, b int) {
+	mg.grid.Connect(mg.cells[a], mg.cells[b])
+}
+
+// shapeAlgorithms are the algorithms that can carve a maze in a shape, the
+// ones with a MazifyGraph function.
+var shapeAlgorithms = map[string]func(ctx context.Context, gr Graph, rng *rand.Rand, start int) error{
+	"kruskal": func(ctx context.Context, gr Graph, rng *rand.Rand, start int) error {
+		return MazifyGraphKruskal(ctx, gr, rng)
+	},
+	"rec":    MazifyGraphRec,
+	"prim":   MazifyGraphPrim,
+	"wilson": MazifyGraphWilson,
+}
+
+// MazifyMask carves a maze with the named algorithm in just the cells of
+// mask, by CellId, such as ShapeMask returns, leaving the rest walled in.  The
+// first and last cells of the mask, reading row by row, become the start
+// and finish.  The mask must be connected.  It returns ctx.Err() if ctx is
+// done before it finishes.
+func (g *Grid) MazifyMask(ctx context.Context, rng *rand.Rand, mask []bool, algorithm string) error {
+	carve, ok := shapeAlgorithms[algorithm]
+	if !ok {
+		return fmt.Errorf("algorithm %q can't carve shapes, only kruskal, prim, rec and wilson can", algorithm)
+	}
+	mg := newMaskGraph(g, mask)
+	if mg.Nodes() == 0 {
+		return fmt.Errorf("the mask is empty")
+	}
+	g.Entrances, g.Exits = []Cell{g.CellOf(mg.cells[0])}, []Cell{g.CellOf(mg.cells[len(mg.cells)-1])}
+	return carve(ctx, mg, rng, 0)
+}
+
+// inMask reports whether cell (row, col) of g is part of the maze as opts
+// draws it: inside the grid and, if there's a Mask, inside that.
+func (opts RenderOptions) inMask(g *Grid, row, col int) bool {
+	return g.inside(row, col) && (opts.Mask == nil || opts.Mask[g.CellId(row, col)])
+}
+
+// drawsWall reports whether the wall on side d of cell (row, col) is drawn:
+// whether the cell on either side of it is part of the maze.
+func (opts RenderOptions) drawsWall(g *Grid, row, col int, d Direction) bool {
+	return opts.inMask(g, row, col) || opts.inMask(g, row+rowOffset[d], col+colOffset[d])
+}
+
+// maskText blanks out the walls between cells outside opts.Mask in text,
+// the maze as appendText draws it.
+func maskText(g *Grid, text []byte, opts RenderOptions) []byte {
+	lines := strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+	var out []byte
+	for i, line := range lines {
+		chars := []rune(line)
+		// The top line is the north walls of row 0, and the rest each the
+		// west wall, then the south and east walls of each cell of a row.
+		row := i - 1
+		for j := range chars {
+			col := (j - 1) / 2
+			var drawn bool
+			switch {
+			case row < 0 && j%2 == 1:
+				drawn = opts.inMask(g, 0, col)
+			case row < 0:
+				drawn = opts.inMask(g, 0, col) && opts.inMask(g, 0, col+1)
+			case j == 0:
+				drawn = opts.inMask(g, row, 0)
+			case j%2 == 1:
+				drawn = opts.drawsWall(g, row, col, S)
+			default:
+				drawn = opts.drawsWall(g, row, col, E)
+				if !drawn && opts.inMask(g, row+1, col) && opts.inMask(g, row+1, col+1) {
+					// Join up the north walls of the cells below.
+					chars[j], drawn = '_', true
+				}
+			}
+			if !drawn {
+				chars[j] = ' '
+			}
+		}
+		out = append(out, strings.TrimRight(string(chars), " ")...)
+		out = append(out, '\n')
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package maze
+
+import "io"
+
+// Snapshot is a copy of a grid as it was at one moment, which nothing can
+// change, so any number of goroutines can render and solve it while the
+// grid it came from goes on being edited: a server drawing the maze a
+// player is braiding, say.  Take it while holding whatever lock the grid's
+// writers hold; after that it needs no locking at all.
+type Snapshot struct {
+	g Grid
+}
+
+// Snapshot returns a Snapshot of the grid's walls, entrances, exits and
+// metadata.  It costs a Clone.
+func (g *Grid) Snapshot() Snapshot {
+	return Snapshot{g.Clone()}
+}
+
+// Size returns the snapshot's row and column counts.
+func (s Snapshot) Size() (rows, cols int) {
+	return s.g.RowCount, s.g.ColCount
+}
+
+// Render renders the snapshot with r.
+func (s Snapshot) Render(r Renderer, w io.Writer, opts RenderOptions) error {
+	return r.Render(&s.g, w, opts)
+}
+
+// Solve finds a path through the snapshot with solver, as FindPath does.
+func (s Snapshot) Solve(solver Solver, start, finish Cell) ([]Cell, error) {
+	return FindPath(solver, &s.g, start, finish)
+}
+
+// Grid returns a copy of the snapshot to edit.
+func (s Snapshot) Grid() Grid {
+	return s.g.Clone()
+}
@@ -0,0 +1,310 @@
+package maze
+
+import (
+	"context"
+	"sync"
+)
+
+// Distances returns the number of steps from c to every cell in the maze,
+// indexed by CellId, found with a breadth first search.  Cells that can't be
+// reached are -1.  It returns nil if c isn't in the grid.
+func (g *Grid) Distances(c Cell) []int {
+	if !g.Contains(c) {
+		return nil
+	}
+	dist, _ := g.bfs(c.Row, c.Col)
+	return dist
+}
+
+// Solve returns the shortest path from start to finish, both ends included,
+// or nil if there is no path.
+func (g *Grid) Solve(start, finish Cell) []Cell {
+	path, _ := g.SolveContext(context.Background(), start, finish)
+	return path
+}
+
+// SolveContext is Solve but gives up and returns ctx.Err() if ctx is done
+// before it finishes, checking as often as the generators do.
+func (g *Grid) SolveContext(ctx context.Context, start, finish Cell) ([]Cell, error) {
+	path, err := g.solve(ctx, start, finish)
+	return g.PathCells(path), err
+}
+
+// solvePath is Solve with the path as CellIds, the way the renderers draw
+// it.
+func (g *Grid) solvePath(start, finish Cell) []int {
+	path, _ := g.solve(context.Background(), start, finish)
+	return path
+}
+
+// solve is SolveContext, returning the path as CellIds.
+func (g *Grid) solve(ctx context.Context, start, finish Cell) ([]int, error) {
+	s := searchPool.Get().(*search)
+	defer searchPool.Put(s)
+	end := g.CellIdOf(finish)
+	if err := g.searchContext(ctx, s, []int{g.CellIdOf(start)}, end); err != nil {
+		return nil, err
+	}
+	if s.parent[end] < 0 {
+		return nil, nil
+	}
+	return walkBack(s.parent, end), nil
+}
+
+// walkBack follows parent links from end back to the start of a search,
+// which is its own parent, and returns the path from start to end.
+func walkBack(parent []int, end int) []int {
+	var path []int
+	for id := end; ; id = parent[id] {
+		path = append(path, id)
+		if parent[id] == id {
+			break
+		}
+	}
+	// We walked it backwards.
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// SolveNearest returns the shortest path from any of the starts to whichever
+// of the goals is closest to one, or nil if no goal can be reached.
+func (g *Grid) SolveNearest(starts, goals []Cell) []Cell {
+	return g.PathCells(g.solveNearest(g.CellIds(starts), g.CellIds(goals)))
+}
+
+// solveNearest is SolveNearest with the cells and the path as CellIds.
+func (g *Grid) solveNearest(starts, goals []int) []int {
+	s := searchPool.Get().(*search)
+	defer searchPool.Put(s)
+	g.search(s, starts, -1)
+	best := -1
+	for _, goal := range goals {
+		if s.dist[goal] >= 0 && (best < 0 || s.dist[goal] < s.dist[best]) {
+			best = goal
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return walkBack(s.parent, best)
+}
+
+// SolveExits returns the shortest path from any of the grid's Entrances to
+// the nearest of its Exits, or nil if there isn't one.
+func (g *Grid) SolveExits() []Cell {
+	return g.SolveNearest(g.Entrances, g.Exits)
+}
+
+// endpoints returns the CellIds of the grid's first entrance and exit, or
+// the top left and bottom right corners if it doesn't have them.
+func (g *Grid) endpoints() (start, end int) {
+	start, end = 0, len(g.data)-1
+	if len(g.Entrances) > 0 {
+		start = g.CellIdOf(g.Entrances[0])
+	}
+	if len(g.Exits) > 0 {
+		end = g.CellIdOf(g.Exits[0])
+	}
+	return start, end
+}
+
+// bfs searches the maze from (row, col), returning the distance to each cell
+// and the CellId of the cell each one was reached from (the start is its own
+// parent), indexed by CellId.  Unreached cells are -1 in both.
+func (g *Grid) bfs(row, col int) (dist, parent []int) {
+	return g.bfsFrom([]int{g.CellId(row, col)})
+}
+
+// bfsFrom is bfs from several starting cells, given as CellIds, at once.
+// Each cell's distance is to the nearest start.
+func (g *Grid) bfsFrom(starts []int) (dist, parent []int) {
+	var s search
+	g.search(&s, starts, -1)
+	return s.dist, s.parent
+}
+
+// search is the memory a breadth first search works in.  The solvers that
+// don't hand it to their callers keep it in searchPool between searches,
+// so solving maze after maze doesn't keep allocating it.
+type search struct {
+	dist, parent, queue []int
+}
+
+var searchPool = sync.Pool{New: func() interface{} { return &search{} }}
+
+// search does what bfsFrom does in s, reusing its memory if there's room.
+// It stops once it reaches the cell stop, if that's not -1, leaving the
+// cells it hasn't got to yet -1.
+func (g *Grid) search(s *search, starts []int, stop int) {
+	g.searchContext(context.Background(), s, starts, stop)
+}
+
+// searchContext is search but gives up and returns ctx.Err() if ctx is
+// done before it finishes, leaving s part filled in.
+func (g *Grid) searchContext(ctx context.Context, s *search, starts []int, stop int) error {
+	n := len(g.data)
+	if cap(s.dist) < n {
+		s.dist, s.parent, s.queue = make([]int, n), make([]int, n), make([]int, 0, n)
+	}
+	dist, parent := s.dist[:n], s.parent[:n]
+	s.dist, s.parent = dist, parent
+	for i := range dist {
+		dist[i] = -1
+		parent[i] = -1
+	}
+	// Each cell is queued at most once, so the queue never outgrows n.
+	queue := s.queue[:0]
+	for _, start := range starts {
+		if dist[start] < 0 {
+			dist[start] = 0
+			parent[start] = start
+			queue = append(queue, start)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		if i%checkEvery == checkEvery-1 {
+			if err := ctx.Err(); err != nil {
+				s.queue = queue
+				return err
+			}
+		}
+		id := queue[i]
+		if g.Observer != nil {
+			g.Observer.visit(g, id)
+		}
+		if id == stop {
+			break
+		}
+		r, c := id/g.ColCount, id%g.ColCount
+		for _, d := range [...]Direction{N, E, S, W} {
+			if g.walkable(r, c)&d == 0 {
+				continue
+			}
+			next := g.CellId(r+rowOffset[d], c+colOffset[d])
+			if dist[next] < 0 {
+				dist[next] = dist[id] + 1
+				parent[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	s.queue = queue
+	return nil
+}
+
+// SolveTremaux finds a path from start to finish with Trémaux's algorithm,
+// the way someone walking the maze with a piece of chalk would: never take a
+// passage twice in the same direction, and back out of dead ends and
+// passages leading somewhere already visited.  It
+// returns nil if there isn't a path.  The path need not be the shortest in a
+// maze with loops.
+func (g *Grid) SolveTremaux(start, finish Cell) []Cell {
+	path, _ := g.solveTremaux(context.Background(), start, finish)
+	return g.PathCells(path)
+}
+
+// solveTremaux is SolveTremaux, returning the path as CellIds and giving up
+// with ctx.Err() if ctx is done before it finishes.
+func (g *Grid) solveTremaux(ctx context.Context, start, finish Cell) ([]int, error) {
+	end := g.CellIdOf(finish)
+	visited := make([]bool, len(g.data))
+	visited[g.CellIdOf(start)] = true
+	// The passages on the stack have been walked once, the ones popped off
+	// it twice.
+	stack := []int{g.CellIdOf(start)}
+	for i := 1; len(stack) > 0; i++ {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		id := stack[len(stack)-1]
+		if g.Observer != nil {
+			g.Observer.visit(g, id)
+		}
+		if id == end {
+			return stack, nil
+		}
+		row, col := id/g.ColCount, id%g.ColCount
+		next := -1
+		for _, d := range []Direction{N, E, S, W} {
+			if g.walkable(row, col)&d == 0 {
+				continue
+			}
+			if n := g.CellId(row+rowOffset[d], col+colOffset[d]); !visited[n] {
+				next = n
+				break
+			}
+		}
+		if next < 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		visited[next] = true
+		stack = append(stack, next)
+	}
+	return nil, nil
+}
+
+// SolveWallFollower finds a path from start to finish by keeping a hand on
+// the right-hand wall, dropping any detours it
+// walked out and back along from the returned path.  It returns nil if
+// following the wall leads back where it started without reaching the end,
+// which can happen when the maze has loops.
+func (g *Grid) SolveWallFollower(start, finish Cell) []Cell {
+	path, _ := g.solveWallFollower(context.Background(), start, finish)
+	return g.PathCells(path)
+}
+
+// solveWallFollower is SolveWallFollower, returning the path as CellIds
+// and giving up with ctx.Err() if ctx is done before it finishes.
+func (g *Grid) solveWallFollower(ctx context.Context, start, finish Cell) ([]int, error) {
+	end := g.CellIdOf(finish)
+	// Directions clockwise, so right of clockwise[i] is clockwise[i+1].
+	clockwise := []Direction{N, E, S, W}
+	row, col, heading := start.Row, start.Col, 0
+	seen := make([]bool, 4*len(g.data))
+	path := []int{g.CellId(row, col)}
+	at := map[int]int{path[0]: 0} // index in path of each cell on it
+	for i := 1; path[len(path)-1] != end; i++ {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		id := g.CellId(row, col)
+		if g.Observer != nil {
+			g.Observer.visit(g, id)
+		}
+		if seen[4*id+heading] {
+			return nil, nil
+		}
+		seen[4*id+heading] = true
+		// Try right, straight on, left, then back.
+		var turn int
+		for _, turn = range []int{1, 0, 3, 2} {
+			if g.walkable(row, col)&clockwise[(heading+turn)%4] != 0 {
+				break
+			}
+		}
+		d := clockwise[(heading+turn)%4]
+		if g.walkable(row, col)&d == 0 {
+			return nil, nil // walled in
+		}
+		heading = (heading + turn) % 4
+		row, col = row+rowOffset[d], col+colOffset[d]
+		next := g.CellId(row, col)
+		if i, ok := at[next]; ok {
+			for _, id := range path[i+1:] {
+				delete(at, id)
+			}
+			path = path[:i+1]
+		} else {
+			at[next] = len(path)
+			path = append(path, next)
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,196 @@
+package maze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Solver finds a path through a maze from start to finish, returning it
+// with both ends included, or nil if it can't find one.  The built in
+// solvers only walk one-way passages the way they go.
+type Solver interface {
+	Solve(g *Grid, start, finish Cell) []Cell
+}
+
+// SolverFunc lets an ordinary function be used as a Solver.
+type SolverFunc func(g *Grid, start, finish Cell) []Cell
+
+func (f SolverFunc) Solve(g *Grid, start, finish Cell) []Cell {
+	return f(g, start, finish)
+}
+
+// ContextSolver is a Solver that can be cancelled: SolveContext gives up
+// and returns ctx.Err() if ctx is done before it finishes.  The built in
+// solvers are ContextSolvers; FindPathContext uses SolveContext when a
+// Solver has it.
+type ContextSolver interface {
+	Solver
+	SolveContext(ctx context.Context, g *Grid, start, finish Cell) ([]Cell, error)
+}
+
+// contextSolverFunc is a ContextSolver, the form the built in solvers take,
+// finding the path as CellIds.
+type contextSolverFunc func(ctx context.Context, g *Grid, start, finish Cell) ([]int, error)
+
+func (f contextSolverFunc) Solve(g *Grid, start, finish Cell) []Cell {
+	path, _ := f.SolveContext(context.Background(), g, start, finish)
+	return path
+}
+
+func (f contextSolverFunc) SolveContext(ctx context.Context, g *Grid, start, finish Cell) ([]Cell, error) {
+	path, err := f(ctx, g, start, finish)
+	return g.PathCells(path), err
+}
+
+// solvers maps the name used with --solver to its Solver.
+var solvers = map[string]Solver{
+	"bfs": contextSolverFunc(func(ctx context.Context, g *Grid, start, finish Cell) ([]int, error) {
+		return g.solve(ctx, start, finish)
+	}),
+	"astar": contextSolverFunc(func(ctx context.Context, g *Grid, start, finish Cell) ([]int, error) {
+		path, _, err := g.solveAStar(ctx, start, finish, func(row, col int) float64 { return 1 }, 1)
+		return path, err
+	}),
+	"tremaux": contextSolverFunc(func(ctx context.Context, g *Grid, start, finish Cell) ([]int, error) {
+		return g.solveTremaux(ctx, start, finish)
+	}),
+	"wallfollower": contextSolverFunc(func(ctx context.Context, g *Grid, start, finish Cell) ([]int, error) {
+		return g.solveWallFollower(ctx, start, finish)
+	}),
+	// Slide solves the maze on ice, where each move slides on to a wall.
+	"slide": contextSolverFunc(func(ctx context.Context, g *Grid, start, finish Cell) ([]int, error) {
+		return g.solveSliding(ctx, start, finish)
+	}),
+}
+
+// RegisterSolver makes s available as the solver name.  It panics if the
+// name is already taken.
+func RegisterSolver(name string, s Solver) {
+	if _, ok := solvers[name]; ok {
+		panic(fmt.Sprintf("solver %q already registered", name))
+	}
+	solvers[name] = s
+}
+
+// LookupSolver returns the solver registered as name.
+func LookupSolver(name string) (Solver, bool) {
+	s, ok := solvers[name]
+	return s, ok
+}
+
+// ErrNoPath is FindPath's error when there's no way from the start to the
+// finish.
+var ErrNoPath = errors.New("no path from the start to the finish")
+
+// FindPath finds a path with s like s.Solve, but checks first that there's
+// a grid and both cells are in it, instead of letting s index out of range,
+// and returns ErrNoPath if s finds no path, instead of nil.
+func FindPath(s Solver, g *Grid, start, finish Cell) ([]Cell, error) {
+	return FindPathContext(context.Background(), s, g, start, finish)
+}
+
+// FindPathContext is FindPath but gives up and returns ctx.Err() if ctx is
+// done before s finishes.  Only a ContextSolver can be stopped partway; any
+// other Solver runs to the end, and ctx is checked before and after.
+func FindPathContext(ctx context.Context, s Solver, g *Grid, start, finish Cell) ([]Cell, error) {
+	if g == nil || len(g.data) == 0 {
+		return nil, errors.New("no maze to solve")
+	}
+	if !g.Contains(start) {
+		return nil, fmt.Errorf("start %v is outside the grid", start)
+	}
+	if !g.Contains(finish) {
+		return nil, fmt.Errorf("finish %v is outside the grid", finish)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var path []Cell
+	if cs, ok := s.(ContextSolver); ok {
+		var err error
+		if path, err = cs.SolveContext(ctx, g, start, finish); err != nil {
+			return nil, err
+		}
+	} else {
+		path = s.Solve(g, start, finish)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if path == nil {
+		return nil, ErrNoPath
+	}
+	return path, nil
+}
+
+// SolverNames returns the registered solver names, sorted.
+func SolverNames() []string {
+	var names []string
+	for name := range solvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// markPath labels the cells on path that don't have any metadata with a dot,
+// so the text renderers show it, or with arrows giving the way along it if
+// arrows is set.
+func (g *Grid) markPath(path []int, arrows bool) {
+	for i, id := range path {
+		if c := g.CellOf(id); len(g.MetaKeys(c)) == 0 {
+			label := "."
+			if arrows {
+				label = string(g.pathArrow(path, i))
+			}
+			g.SetMeta(c, LabelKey, label)
+		}
+	}
+}
+
+// pathArrows are the arrows pathArrow draws along straight stretches of a
+// path, by direction of travel, and pathCorners the corners where it turns,
+// by the two sides of the cell it goes through: 1 N, 2 E, 4 S, 8 W.
+var (
+	pathArrows  = map[Direction]rune{N: '↑', E: '→', S: '↓', W: '←'}
+	pathCorners = map[Direction]rune{N | E: '╰', N | W: '╯', S | E: '╭', S | W: '╮'}
+)
+
+// pathArrow returns the character for cell i of path: an arrow the way the
+// path goes on a straight stretch or a corner where it turns.  The first
+// cell points the way out of it and the last the way in.
+func (g *Grid) pathArrow(path []int, i int) rune {
+	// in and out are the ways the path goes into and out of the cell, if
+	// the cells before and after are next to it; a path cut down to a
+	// Viewport can jump.
+	var in, out Direction
+	if i > 0 && g.adjacent(path[i-1], path[i]) {
+		in = g.direction(path[i-1], path[i])
+	}
+	if i < len(path)-1 && g.adjacent(path[i], path[i+1]) {
+		out = g.direction(path[i], path[i+1])
+	}
+	switch {
+	case in == 0 && out == 0:
+		return '.'
+	case in == 0 || in == out:
+		return pathArrows[out]
+	case out == 0 || in == opposite[out]:
+		return pathArrows[in]
+	}
+	return pathCorners[opposite[in]|out]
+}
+
+// adjacent reports whether cells a and b, given as CellIds, are next to
+// each other.
+func (g *Grid) adjacent(a, b int) bool {
+	switch b - a {
+	case -g.ColCount, g.ColCount:
+		return true
+	case -1, 1:
+		return a/g.ColCount == b/g.ColCount
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package maze
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestFindPathContext(t *testing.T) {
+	g := newGrid(100, 100)
+	g.MazifyKruskal(rand.New(rand.NewSource(1)))
+	start, finish := g.Endpoints()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, name := range SolverNames() {
+		t.Run(name, func(t *testing.T) {
+			s := solvers[name]
+			want := s.Solve(&g, start, finish)
+			got, err := FindPathContext(context.Background(), s, &g, start, finish)
+			if want == nil {
+				if !errors.Is(err, ErrNoPath) {
+					t.Fatalf("got %v, %v, want ErrNoPath", got, err)
+				}
+			} else if err != nil || !slices.Equal(got, want) {
+				t.Fatalf("got %v, %v, want the %d cell path Solve finds", got, err, len(want))
+			}
+			if _, err := FindPathContext(cancelled, s, &g, start, finish); !errors.Is(err, context.Canceled) {
+				t.Fatalf("cancelled: got %v, want context.Canceled", err)
+			}
+			cs, ok := s.(ContextSolver)
+			if !ok {
+				t.Fatal("not a ContextSolver")
+			}
+			visits := 0
+			g.Observer = &Observer{Visit: func(Cell) { visits++ }}
+			s.Solve(&g, start, finish)
+			g.Observer = nil
+			if visits < checkEvery {
+				// It's done before its first check.
+				return
+			}
+			// Past the check done before solving starts, the solver's own
+			// checks have to stop it.
+			if _, err := cs.SolveContext(cancelled, &g, start, finish); !errors.Is(err, context.Canceled) {
+				t.Fatalf("SolveContext cancelled: got %v, want context.Canceled", err)
+			}
+		})
+	}
+}
+
+func TestOutsideCells(t *testing.T) {
+	g := newGrid(3, 4)
+	g.MazifyKruskal(rand.New(rand.NewSource(1)))
+	for _, c := range []Cell{{-1, 0}, {0, -1}, {3, 0}, {0, 4}, {2, 4}} {
+		for _, d := range []Direction{N, E, S, W} {
+			if !g.HasWall(c, d) {
+				t.Errorf("%v has no wall on its %v side", c, d)
+			}
+		}
+		if dist := g.Distances(c); dist != nil {
+			t.Errorf("Distances(%v) = %v, want nil", c, dist)
+		}
+	}
+}
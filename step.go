@@ -0,0 +1,437 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Stepper runs a generation algorithm one carved wall at a time, so callers
+// can animate it, single-step it in a debugger, or interleave it with other
+// work.  The Mazify methods are just loops over a Stepper.
+type Stepper interface {
+	// Step carves the next wall and reports whether it did; it returns false
+	// once the maze is finished.
+	Step() bool
+	// Last returns the wall carved by the most recent successful Step: the
+	// wall between c and its neighbour in direction d.
+	Last() (c Cell, d Direction)
+}
+
+// checkEvery is how many steps the generators take between checks for
+// cancellation in the Context variants.
+const checkEvery = 1 << 12
+
+// contextStepper is a Stepper that checks ctx itself as it steps, for one
+// whose Step can do a lot of work without carving anything.
+type contextStepper interface {
+	Stepper
+	stepContext(ctx context.Context) (bool, error)
+}
+
+// runSteps steps s until it's done or ctx is.
+func runSteps(ctx context.Context, s Stepper) error {
+	if cs, ok := s.(contextStepper); ok {
+		for {
+			more, err := cs.stepContext(ctx)
+			if err != nil || !more {
+				return err
+			}
+		}
+	}
+	for i := 1; s.Step(); i++ {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// For Kruskal impl
+type edge struct {
+	row int
+	col int
+	d   Direction // other end of edge is in this direction
+}
+
+// recFrame is a node on the backtracker's stack along with how many of its
+// sides it has tried.
+type recFrame struct {
+	node int
+	next int // index into the frame's sides of the next side to try
+}
+
+// RecStepper is the recursive backtracker as a Stepper, with the recursion
+// replaced by an explicit stack.  It carves any Graph.
+type RecStepper struct {
+	gr      Graph
+	rng     *rand.Rand
+	bias    float64
+	degree  int
+	visited []bool
+	stack   []recFrame
+	// sides holds each frame's sides in the order it tries them, degree
+	// apiece.
+	sides []uint8
+	// eachStep picks one of the top node's unvisited neighbours at random
+	// at every step instead of shuffling its sides when it's pushed, the
+	// order MazifyGraphRec has always carved in.
+	eachStep           bool
+	unvisited          []int
+	lastNode, lastSide int
+	// log, if not nil, gets a debug record of each backtrack, which
+	// backtracks and depth count for the summary at the end.
+	log        *slog.Logger
+	backtracks int
+	depth      int
+	started    time.Time
+}
+
+// NewRecStepper returns a Stepper that carves g with recursive backtracking
+// starting from start.
+func NewRecStepper(g *Grid, rng *rand.Rand, start Cell) *RecStepper {
+	return NewBiasedRecStepper(g, rng, start, NoBias)
+}
+
+// NewBiasedRecStepper is NewRecStepper with a preference for carving in the
+// direction given by bias.
+func NewBiasedRecStepper(g *Grid, rng *rand.Rand, start Cell, bias float64) *RecStepper {
+	return newRecStepper(g, rng, g.CellIdOf(start), bias, false)
+}
+
+// newRecStepper returns a Stepper that carves gr with recursive
+// backtracking from the node start.  bias only makes sense for a graph
+// whose sides are gridSides, and is ignored if eachStep is set.
+func newRecStepper(gr Graph, rng *rand.Rand, start int, bias float64, eachStep bool) *RecStepper {
+	s := &RecStepper{
+		gr:       gr,
+		rng:      rng,
+		bias:     bias,
+		degree:   gr.Degree(),
+		visited:  make([]bool, gr.Nodes()),
+		eachStep: eachStep,
+		log:      graphLog(gr),
+		started:  time.Now(),
+	}
+	s.push(start)
+	return s
+}
+
+func (s *RecStepper) push(node int) {
+	s.visited[node] = true
+	if s.eachStep {
+		s.stack = append(s.stack, recFrame{node: node})
+		s.depth = max(s.depth, len(s.stack))
+		return
+	}
+	base := len(s.stack) * s.degree
+	s.sides = append(s.sides[:base], make([]uint8, s.degree)...)
+	sides := s.sides[base:]
+	for i := range sides {
+		sides[i] = uint8(i)
+	}
+	if s.bias == NoBias {
+		s.rng.Shuffle(len(sides), func(i, j int) { sides[i], sides[j] = sides[j], sides[i] })
+	} else {
+		dirs := gridSides
+		shuffleBiased(s.rng, dirs[:], s.bias)
+		for i, d := range dirs {
+			sides[i] = uint8(slices.Index(gridSides[:], d))
+		}
+	}
+	s.stack = append(s.stack, recFrame{node: node})
+	s.depth = max(s.depth, len(s.stack))
+}
+
+func (s *RecStepper) Step() bool {
+	for len(s.stack) > 0 {
+		top := len(s.stack) - 1
+		f := &s.stack[top]
+		if s.eachStep {
+			s.unvisited = s.unvisited[:0]
+			for side := 0; side < s.degree; side++ {
+				if next := s.gr.Neighbour(f.node, side); next >= 0 && !s.visited[next] {
+					s.unvisited = append(s.unvisited, side)
+				}
+			}
+			if len(s.unvisited) > 0 {
+				side := s.unvisited[s.rng.Intn(len(s.unvisited))]
+				next := s.gr.Neighbour(f.node, side)
+				s.gr.Connect(f.node, next)
+				s.lastNode, s.lastSide = f.node, side
+				s.push(next)
+				return true
+			}
+			f.next = s.degree
+		}
+		if f.next == s.degree {
+			s.stack = s.stack[:top]
+			if s.log != nil {
+				s.backtracks++
+				s.log.Debug("rec: backtrack", "node", f.node, "depth", len(s.stack))
+				if len(s.stack) == 0 {
+					s.log.Debug("rec: done", "backtracks", s.backtracks, "max-depth", s.depth, "took", time.Since(s.started))
+				}
+			}
+			continue
+		}
+		side := int(s.sides[top*s.degree+f.next])
+		f.next++
+		// Carve through the wall on that side if there's a node there
+		// and we haven't already been to it.
+		if next := s.gr.Neighbour(f.node, side); next >= 0 && !s.visited[next] {
+			s.gr.Connect(f.node, next)
+			s.lastNode, s.lastSide = f.node, side
+			s.push(next)
+			return true
+		}
+	}
+	return false
+}
+
+// Last returns the wall last carved, if the graph is laid out on a Grid.
+func (s *RecStepper) Last() (c Cell, d Direction) {
+	return lastWall(s.gr, s.lastNode, s.lastSide)
+}
+
+// KruskalStepper is Kruskal's algorithm as a Stepper.  It carves any Graph.
+type KruskalStepper struct {
+	gr Graph
+	// region is gr if it's a gridRegion, which Step carves directly rather
+	// than through the Graph methods: it's by far the commonest graph, and
+	// the calls cost a third of the time on a big one.
+	region *gridRegion
+	buf    *kruskalBuffers
+	edges  []uint32 // kruskalEdges
+	next   int      // index into edges of the next edge to consider
+	sets   *DisjointSet
+	// shift is how far kruskalEdges shift their node over to make room for
+	// its side.
+	shift              uint
+	lastNode, lastSide int
+	// log, if not nil, gets a debug record of each edge considered, and
+	// merges counts the ones that joined two sets, for the summary at the
+	// end.
+	log     *slog.Logger
+	merges  int
+	started time.Time
+}
+
+// kruskalBuffers are the edge list and DSU of a KruskalStepper, which are
+// most of the memory it takes to make a maze.  They go back in kruskalPool
+// once the maze is done, so generating maze after maze doesn't keep
+// allocating them.
+type kruskalBuffers struct {
+	edges []uint32
+	sets  DisjointSet
+}
+
+// kruskalEdge packs the wall on the given side of node into a uint32,
+// shifting node over to make room for the side, which holds any of the
+// maxGridCells cells' walls.  That's a sixth the size of an edge, which adds
+// up to gigabytes on a 10000x10000 grid.
+func kruskalEdge(node, side int, shift uint) uint32 {
+	return uint32(node)<<shift | uint32(side)
+}
+
+var kruskalPool = sync.Pool{New: func() interface{} { return &kruskalBuffers{} }}
+
+// NewKruskalStepper returns a Stepper that carves g with Kruskal's algorithm.
+func NewKruskalStepper(g *Grid, rng *rand.Rand) *KruskalStepper {
+	// It can only fail if the context is done.
+	s, _ := newKruskalStepper(context.Background(), &gridRegion{g, 0, 0, g.RowCount, g.ColCount}, rng, g.GeneratorVersion == 1)
+	return s
+}
+
+// newKruskalStepper returns a Stepper that runs Kruskal's algorithm on gr.
+// legacy lists every wall from both sides, as GeneratorVersion 1 did for a
+// Grid.  Listing and shuffling the edges of a big graph takes a while, so
+// it returns ctx.Err() if ctx is done before it's through them.
+func newKruskalStepper(ctx context.Context, gr Graph, rng *rand.Rand, legacy bool) (*KruskalStepper, error) {
+	// 1. Generate all the possible edges in the graph.
+	//   - our representation of an edge will be (node, side)
+	//     e.g. (3, 0) on a Grid means an edge between cell 3 and the cell
+	//     North of it
+	// 2. Shuffle the set of edges.
+	// 3. Execute Kruskal's algorithm on the set of shuffled edges.
+	//    - use a disjoint set union data structure
+	//    - each edge starts in a disjoint subset all by itself
+	//    - for each edge (u, v), if u and v are not in the same disjoint
+	//      subset
+	//      - update the graph allowing a path between u and v
+	//      - union the representative sets for u and v
+	//
+	// Each call to Step does the work of step 3 up to and including the
+	// next edge that gets carved.
+
+	// Every wall between two nodes is listed once, from the lower numbered
+	// node, or in legacy from both.  On a Grid that's the east and south
+	// walls of each cell in turn.
+	nodes, degree := gr.Nodes(), gr.Degree()
+	shift := uint(bits.Len(uint(degree - 1)))
+	if uint64(nodes)<<shift > 1<<32 {
+		return nil, fmt.Errorf("%d nodes of %d sides are too many to list the walls of", nodes, degree)
+	}
+	count := nodes * degree
+	if !legacy {
+		count /= 2
+	}
+	buf := kruskalPool.Get().(*kruskalBuffers)
+	if cap(buf.edges) < count {
+		buf.edges = make([]uint32, 0, count)
+	}
+	edges := buf.edges[:0]
+	for node := 0; node < nodes; node++ {
+		if node%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				kruskalPool.Put(buf)
+				return nil, err
+			}
+		}
+		for side := 0; side < degree; side++ {
+			if next := gr.Neighbour(node, side); next > node || (legacy && next >= 0) {
+				edges = append(edges, kruskalEdge(node, side, shift))
+			}
+		}
+	}
+
+	started := time.Now()
+	if err := shuffleContext(ctx, rng, len(edges), func(i, j int) {
+		edges[i], edges[j] = edges[j], edges[i]
+	}); err != nil {
+		buf.edges = edges
+		kruskalPool.Put(buf)
+		return nil, err
+	}
+	log := graphLog(gr)
+	if log != nil {
+		log.Debug("kruskal: edges listed and shuffled", "nodes", nodes, "edges", len(edges), "took", time.Since(started))
+	}
+
+	buf.sets.resize(nodes)
+	region, _ := gr.(*gridRegion)
+	return &KruskalStepper{
+		gr:      gr,
+		region:  region,
+		buf:     buf,
+		edges:   edges,
+		sets:    &buf.sets,
+		shift:   shift,
+		log:     log,
+		started: time.Now(),
+	}, nil
+}
+
+// shuffleContext is rng.Shuffle, making the very same swaps so a seed
+// shuffles the same way, but checking every checkEvery swaps whether ctx is
+// done and giving up with ctx.Err() if it is.
+func shuffleContext(ctx context.Context, rng *rand.Rand, n int, swap func(i, j int)) error {
+	if n < 0 {
+		panic("invalid argument to shuffleContext")
+	}
+	for i := n - 1; i > 0; i-- {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if i > 1<<31-1-1 {
+			swap(i, int(rng.Int63n(int64(i+1))))
+		} else {
+			swap(i, int(int31n(rng, int32(i+1))))
+		}
+	}
+	return nil
+}
+
+// int31n is the unexported int31n of math/rand that Shuffle uses, which
+// isn't the same as Int31n: a random number in [0, n) by Lemire's multiply
+// and shift.
+func int31n(rng *rand.Rand, n int32) int32 {
+	v := rng.Uint32()
+	prod := uint64(v) * uint64(n)
+	low := uint32(prod)
+	if low < uint32(n) {
+		thresh := uint32(-n) % uint32(n)
+		for low < thresh {
+			v = rng.Uint32()
+			prod = uint64(v) * uint64(n)
+			low = uint32(prod)
+		}
+	}
+	return int32(prod >> 32)
+}
+
+func (s *KruskalStepper) Step() bool {
+	more, _ := s.stepContext(context.Background())
+	return more
+}
+
+// stepContext is Step, but it gives up and returns ctx.Err() if ctx is done,
+// checking every checkEvery edges it considers whether it carves them or
+// not: late on nearly every edge joins nodes already joined, so one Step can
+// go through millions.
+func (s *KruskalStepper) stepContext(ctx context.Context) (bool, error) {
+	for s.next < len(s.edges) {
+		if s.next%checkEvery == checkEvery-1 {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+		}
+		node, side := s.unpack(s.edges[s.next])
+		s.next++
+		var next int
+		if r := s.region; r != nil {
+			next = gridNeighbour(node, side, r.rows, r.cols)
+		} else {
+			next = s.gr.Neighbour(node, side)
+		}
+		merged := s.sets.Union(node, next)
+		if s.log != nil {
+			s.log.Debug("kruskal: edge", "from", node, "to", next, "merged", merged)
+		}
+		if merged {
+			if r := s.region; r != nil {
+				r.g.carve(r.rowStart+node/r.cols, r.colStart+node%r.cols, gridSides[side])
+			} else {
+				s.gr.Connect(node, next)
+			}
+			s.lastNode, s.lastSide = node, side
+			s.merges++
+			return true, nil
+		}
+	}
+	if s.log != nil && s.buf != nil {
+		s.log.Debug("kruskal: done", "edges", len(s.edges), "merges", s.merges, "took", time.Since(s.started))
+	}
+	if s.buf != nil {
+		s.buf.edges = s.edges
+		kruskalPool.Put(s.buf)
+		s.buf, s.edges, s.sets = nil, nil, nil
+	}
+	return false, nil
+}
+
+// unpack returns the node and side of e, one of s's kruskalEdges.
+func (s *KruskalStepper) unpack(e uint32) (node, side int) {
+	return int(e >> s.shift), int(e & (1<<s.shift - 1))
+}
+
+// edge unpacks e, one of s's kruskalEdges, into the wall it is on the Grid
+// s carves.
+func (s *KruskalStepper) edge(e uint32) edge {
+	node, side := s.unpack(e)
+	return s.gr.(gridWalls).wall(node, side)
+}
+
+// Last returns the wall last carved, if the graph is laid out on a Grid.
+func (s *KruskalStepper) Last() (c Cell, d Direction) {
+	return lastWall(s.gr, s.lastNode, s.lastSide)
+}
@@ -0,0 +1,76 @@
+package maze
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestShuffleContext(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, checkEvery, 3*checkEvery + 7} {
+		want, got := make([]int, n), make([]int, n)
+		for i := range want {
+			want[i], got[i] = i, i
+		}
+		rand.New(rand.NewSource(int64(n))).Shuffle(n, func(i, j int) { want[i], want[j] = want[j], want[i] })
+		if err := shuffleContext(context.Background(), rand.New(rand.NewSource(int64(n))), n, func(i, j int) { got[i], got[j] = got[j], got[i] }); err != nil {
+			t.Fatalf("n %d: %v", n, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("n %d: shuffled %v, rng.Shuffle gives %v", n, got, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := shuffleContext(ctx, rand.New(rand.NewSource(1)), 2*checkEvery, func(i, j int) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestMazifyKruskalContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for version := 1; version <= LatestGeneratorVersion; version++ {
+		g := newGrid(300, 300)
+		g.GeneratorVersion = version
+		g.MazifyKruskal(rand.New(rand.NewSource(1)))
+		links := 0
+		for range g.Links() {
+			links++
+		}
+		if dist, _ := g.bfsFrom([]int{0}); links != 300*300-1 || slices.Contains(dist, -1) {
+			t.Errorf("version %d: not a perfect maze", version)
+		}
+
+		g = newGrid(300, 300)
+		g.GeneratorVersion = version
+		if err := g.MazifyKruskalContext(ctx, rand.New(rand.NewSource(1))); !errors.Is(err, context.Canceled) {
+			t.Errorf("version %d cancelled: got %v, want %v", version, err, context.Canceled)
+		}
+		for range g.Links() {
+			t.Fatalf("version %d cancelled: carved before listing the edges", version)
+		}
+
+		// Once every cell is joined the rest of the edges are all rejected,
+		// and those count towards the checks too.
+		g = newGrid(300, 300)
+		g.GeneratorVersion = version
+		s := NewKruskalStepper(&g, rand.New(rand.NewSource(1)))
+		for s.merges < 300*300-1 {
+			s.Step()
+		}
+		next, left := s.next, len(s.edges)-s.next
+		more, err := s.stepContext(ctx)
+		switch {
+		case more:
+			t.Errorf("version %d: carved after every cell was joined", version)
+		case err == nil && left >= checkEvery:
+			t.Errorf("version %d: went through the last %d edges without checking ctx", version, left)
+		case err != nil && (!errors.Is(err, context.Canceled) || s.next-next > checkEvery):
+			t.Errorf("version %d: got %v after %d edges, want %v within %d", version, err, s.next-next, context.Canceled, checkEvery)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package maze
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Placement positions a maze inside a composite built by Stitch: its top
+// left cell lands at At.
+type Placement struct {
+	Grid *Grid
+	At   Cell
+}
+
+// Stitch returns a rowCount x colCount maze made by copying in each
+// placement's maze and then carving passages between neighbouring
+// placements, Kruskal style, until everything placed is connected.  Exactly
+// one passage joins any two regions, so stitching perfect mazes gives a
+// perfect maze.  Cells no placement covers are left walled in.  It returns
+// an error if a placement has no maze, doesn't fit or overlaps another.
+func Stitch(rng *rand.Rand, rowCount, colCount int, parts []Placement) (Grid, error) {
+	out, err := NewGrid(rowCount, colCount)
+	if err != nil {
+		return Grid{}, err
+	}
+	owner := make([]int, len(out.data)) // index into parts + 1, 0 when empty
+	for i, p := range parts {
+		if p.Grid == nil {
+			return Grid{}, fmt.Errorf("placement %d has no maze", i)
+		}
+		if p.At.Row < 0 || p.At.Col < 0 || p.At.Row+p.Grid.RowCount > rowCount || p.At.Col+p.Grid.ColCount > colCount {
+			return Grid{}, fmt.Errorf("placement %d (%dx%d at %v) doesn't fit in %dx%d",
+				i, p.Grid.RowCount, p.Grid.ColCount, p.At, rowCount, colCount)
+		}
+		for row := 0; row < p.Grid.RowCount; row++ {
+			for col := 0; col < p.Grid.ColCount; col++ {
+				id := out.CellId(p.At.Row+row, p.At.Col+col)
+				if owner[id] != 0 {
+					return Grid{}, fmt.Errorf("placements %d and %d overlap at %d,%d",
+						owner[id]-1, i, p.At.Row+row, p.At.Col+col)
+				}
+				owner[id] = i + 1
+				out.data[id] = p.Grid.data[p.Grid.CellId(row, col)]
+			}
+		}
+	}
+
+	// Start the DSU from the passages the parts already have, then join
+	// across the seams.
+	sets := NewDisjointSet(len(out.data))
+	var seams []edge
+	for row := 0; row < rowCount; row++ {
+		for col := 0; col < colCount; col++ {
+			for _, d := range []Direction{E, S} {
+				otherRow, otherCol := row+rowOffset[d], col+colOffset[d]
+				if otherRow >= rowCount || otherCol >= colCount {
+					continue
+				}
+				a, b := out.CellId(row, col), out.CellId(otherRow, otherCol)
+				switch {
+				case out.openings(row, col)&d != 0:
+					sets.Union(a, b)
+				case owner[a] != 0 && owner[b] != 0 && owner[a] != owner[b]:
+					seams = append(seams, edge{row, col, d})
+				}
+			}
+		}
+	}
+	rng.Shuffle(len(seams), func(i, j int) {
+		seams[i], seams[j] = seams[j], seams[i]
+	})
+	for _, e := range seams {
+		if sets.Union(out.CellId(e.row, e.col), out.CellId(e.row+rowOffset[e.d], e.col+colOffset[e.d])) {
+			out.carve(e.row, e.col, e.d)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package maze
+
+import "math/rand"
+
+// Straighten rewires bends into straight corridors, for a calmer maze
+// that's easier to read: each cell where a corridor turns a corner gets,
+// with probability p, one side of the corner walled up and the side
+// opposite the other opened instead, making a straight.  A rewiring is
+// only kept if it reconnects everything the new wall cut off, leaves fewer
+// bends than before, and keeps the solution within slack, a fraction, of
+// its original length either way, so a perfect maze stays perfect and
+// about as long.  It's recorded as a single History step.
+func (g *Grid) Straighten(rng *rand.Rand, p, slack float64) {
+	start, end := g.endpoints()
+	solution := len(g.solveNearest([]int{start}, []int{end}))
+	shortest, longest := float64(solution)*(1-slack), float64(solution)*(1+slack)
+	var bends []int
+	for id := range g.data {
+		if _, _, ok := g.bend(id); ok {
+			bends = append(bends, id)
+		}
+	}
+	rng.Shuffle(len(bends), func(i, j int) { bends[i], bends[j] = bends[j], bends[i] })
+	s := searchPool.Get().(*search)
+	defer searchPool.Put(s)
+	g.Batch(func() {
+		for _, id := range bends {
+			// An earlier rewiring may have already straightened this one.
+			a, b, ok := g.bend(id)
+			if !ok || rng.Float64() >= p {
+				continue
+			}
+			if rng.Intn(2) == 0 {
+				a, b = b, a
+			}
+			row, col := id/g.ColCount, id%g.ColCount
+			// Keep the passage on side a and swap the one on side b for the
+			// one opposite a.
+			for _, keep := range []Direction{a, b} {
+				wall, open := a^b^keep, opposite[keep]
+				if !g.inside(row+rowOffset[open], col+colOffset[open]) {
+					continue
+				}
+				walled := g.CellId(row+rowOffset[wall], col+colOffset[wall])
+				opened := g.CellId(row+rowOffset[open], col+colOffset[open])
+				before := g.bendCount(id, walled, opened)
+				g.apply(linkOp{row, col, wall, false})
+				g.apply(linkOp{row, col, open, true})
+				better := g.bendCount(id, walled, opened) < before
+				if better {
+					g.search(s, []int{id}, walled)
+					better = s.dist[walled] >= 0
+				}
+				if better && solution > 0 {
+					n := float64(len(g.solveNearest([]int{start}, []int{end})))
+					better = n >= shortest && n <= longest
+				}
+				g.apply(linkOp{row, col, open, false})
+				g.apply(linkOp{row, col, wall, true})
+				if better {
+					g.Unlink(Cell{row, col}, wall)
+					g.Link(Cell{row, col}, open)
+					break
+				}
+			}
+		}
+	})
+}
+
+// bend returns the two sides cell id opens on if it's where a corridor
+// turns a corner: it has exactly two passages, to cells of the grid, at
+// right angles.
+func (g *Grid) bend(id int) (a, b Direction, ok bool) {
+	row, col := id/g.ColCount, id%g.ColCount
+	var sides []Direction
+	for _, step := range neighbourSteps {
+		if g.openings(row, col)&step.d == 0 {
+			continue
+		}
+		if !g.inside(row+step.row, col+step.col) {
+			return 0, 0, false
+		}
+		sides = append(sides, step.d)
+	}
+	if len(sides) != 2 || opposite[sides[0]] == sides[1] {
+		return 0, 0, false
+	}
+	return sides[0], sides[1], true
+}
+
+// bendCount returns how many of the cells ids are bends.
+func (g *Grid) bendCount(ids ...int) int {
+	n := 0
+	for _, id := range ids {
+		if _, _, ok := g.bend(id); ok {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,110 @@
+package maze
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// Style is how the graphical renderers (SVG, PNG and PDF) draw a maze.
+type Style struct {
+	Background, Wall, Solution color.RGBA
+	// Spine is the colour of RenderOptions.Spine.
+	Spine color.RGBA
+	// Start and Finish fill the start and finish cells, unless they're
+	// fully transparent.
+	Start, Finish color.RGBA
+	// WallWidth is in pixels, in every format; PDF draws it at 96 dpi.
+	WallWidth int
+	// CellSize is the width of a cell in pixels.  PDF ignores it and
+	// fills the page.
+	CellSize int
+}
+
+// pathPalette are the colours of RenderOptions.Paths, in order, whatever
+// the theme, unless there's a Palette.
+var pathPalette = []color.RGBA{
+	{228, 26, 28, 255}, {55, 126, 184, 255}, {77, 175, 74, 255},
+	{152, 78, 163, 255}, {255, 127, 0, 255}, {166, 86, 40, 255},
+}
+
+// themes are the Style presets available with --theme.
+var themes = map[string]Style{
+	"classic": {
+		Background: color.RGBA{255, 255, 255, 255},
+		Wall:       color.RGBA{0, 0, 0, 255},
+		Solution:   color.RGBA{217, 26, 26, 255},
+		Spine:      color.RGBA{60, 110, 220, 255},
+		Start:      color.RGBA{140, 214, 140, 255},
+		Finish:     color.RGBA{240, 150, 150, 255},
+		WallWidth:  2,
+		CellSize:   16,
+	},
+	"dark": {
+		Background: color.RGBA{30, 30, 30, 255},
+		Wall:       color.RGBA{224, 224, 224, 255},
+		Solution:   color.RGBA{255, 176, 0, 255},
+		Spine:      color.RGBA{0, 180, 220, 255},
+		Start:      color.RGBA{40, 110, 60, 255},
+		Finish:     color.RGBA{130, 40, 40, 255},
+		WallWidth:  2,
+		CellSize:   16,
+	},
+	"blueprint": {
+		Background: color.RGBA{31, 78, 140, 255},
+		Wall:       color.RGBA{255, 255, 255, 255},
+		Solution:   color.RGBA{255, 221, 51, 255},
+		Spine:      color.RGBA{255, 120, 200, 255},
+		WallWidth:  1,
+		CellSize:   12,
+	},
+	// print has heavy walls and no coloured markers, for black and white
+	// printers.
+	"print": {
+		Background: color.RGBA{255, 255, 255, 255},
+		Wall:       color.RGBA{0, 0, 0, 255},
+		Solution:   color.RGBA{128, 128, 128, 255},
+		Spine:      color.RGBA{200, 200, 200, 255},
+		WallWidth:  3,
+		CellSize:   20,
+	},
+}
+
+// LookupTheme returns the theme named name.
+func LookupTheme(name string) (Style, bool) {
+	style, ok := themes[name]
+	return style, ok
+}
+
+// ThemeNames returns the theme names, sorted.
+func ThemeNames() []string {
+	var names []string
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// style returns the Style to render with: opts.Style, or the classic theme
+// if that's nil.
+func (opts RenderOptions) style() Style {
+	style := themes["classic"]
+	if opts.Style != nil {
+		style = *opts.Style
+	}
+	if p := opts.Palette; p != nil {
+		if p.Solution.A != 0 {
+			style.Solution = p.Solution
+		}
+		if p.Spine.A != 0 {
+			style.Spine = p.Spine
+		}
+	}
+	return style
+}
+
+// hexColor formats c as an HTML colour, #rrggbb.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
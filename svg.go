@@ -0,0 +1,184 @@
+package maze
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// renderSVG draws the maze as an SVG image, with each wall a line.
+func renderSVG(g *Grid, w io.Writer, opts RenderOptions) error {
+	style := opts.style()
+	size := style.CellSize
+	// Leave half a cell of margin so the border isn't clipped.
+	margin := size / 2
+	// The legend for opts.Paths goes under the maze, a line for each.
+	const legendLine = 16
+	width, height := g.ColCount*size+2*margin, g.RowCount*size+2*margin+len(opts.Paths)*legendLine
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height)
+	for _, key := range sortedKeys(opts.Info) {
+		fmt.Fprintf(bw, "<!-- %s%s=%s -->\n", infoPrefix, key, strings.ReplaceAll(opts.Info[key], "--", "- -"))
+	}
+	fmt.Fprintf(bw, "<rect width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", width, height, hexColor(style.Background))
+	fillCell := func(id int, fill string) {
+		fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n",
+			margin+id%g.ColCount*size, margin+id/g.ColCount*size, size, size, fill)
+	}
+	if opts.Regions != nil {
+		for id, r := range opts.Regions {
+			if r >= 0 {
+				fillCell(id, hexColor(opts.regionColor(r)))
+			}
+		}
+	}
+	if opts.Order != nil {
+		last := slices.Max(opts.Order)
+		for id, n := range opts.Order {
+			if n >= 0 {
+				fillCell(id, hexColor(opts.visitColor(n+1, last+1)))
+			}
+		}
+	}
+	start, end := g.endpoints()
+	if style.Start.A != 0 {
+		fillCell(start, hexColor(style.Start))
+	}
+	if style.Finish.A != 0 {
+		fillCell(end, hexColor(style.Finish))
+	}
+
+	drawPath := func(path []int, c color.RGBA, width int) {
+		for _, run := range g.pathRuns(path) {
+			points := make([]string, len(run))
+			for i, id := range run {
+				points[i] = fmt.Sprintf("%d,%d", margin+id%g.ColCount*size+size/2, margin+id/g.ColCount*size+size/2)
+			}
+			fmt.Fprintf(bw, "<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%d\" stroke-linejoin=\"round\"/>\n",
+				strings.Join(points, " "), hexColor(c), width)
+		}
+	}
+	drawPath(g.CellIds(opts.Spine), style.Spine, size/4+1)
+	for i, p := range opts.Paths {
+		drawPath(g.CellIds(p.Cells), opts.PathColor(i), int(opts.pathWidth(i, float64(size)))+1)
+	}
+	drawPath(g.CellIds(opts.Path), style.Solution, size/4+1)
+
+	fmt.Fprintf(bw, "<g stroke=\"%s\" stroke-width=\"%d\" stroke-linecap=\"square\">\n", hexColor(style.Wall), style.WallWidth)
+	line := func(x1, y1, x2, y2 int) {
+		fmt.Fprintf(bw, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\"/>\n",
+			margin+x1*size, margin+y1*size, margin+x2*size, margin+y2*size)
+	}
+	var gaps []borderGap
+	if opts.Openings {
+		gaps = g.borderGaps()
+	}
+	if opts.Mask == nil && gaps == nil {
+		line(0, 0, g.ColCount, 0)
+		line(0, 0, 0, g.RowCount)
+	}
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			cell := g.openings(row, col)
+			if opts.Mask != nil {
+				if !opts.drawsWall(g, row, col, S) {
+					cell |= S
+				}
+				if !opts.drawsWall(g, row, col, E) {
+					cell |= E
+				}
+			}
+			if opts.Mask != nil || gaps != nil {
+				// The top and left borders, where they're wanted.
+				if row == 0 && opts.inMask(g, row, col) && !g.isGap(gaps, row, col, N) {
+					line(col, 0, col+1, 0)
+				}
+				if col == 0 && opts.inMask(g, row, col) && !g.isGap(gaps, row, col, W) {
+					line(0, row, 0, row+1)
+				}
+			}
+			if cell&S == 0 && !g.isGap(gaps, row, col, S) {
+				line(col, row+1, col+1, row+1)
+			}
+			if cell&E == 0 && !g.isGap(gaps, row, col, E) {
+				line(col+1, row, col+1, row+1)
+			}
+		}
+	}
+	fmt.Fprintf(bw, "</g>\n")
+	// One-way passages get an arrowhead pointing the way they go.
+	for _, e := range g.oneWays() {
+		t := oneWayArrow(e.row, e.col, e.d, float64(size))
+		fmt.Fprintf(bw, "<polygon points=\"%d,%d %d,%d %d,%d\" fill=\"%s\"/>\n",
+			margin+t[0].X, margin+t[0].Y, margin+t[1].X, margin+t[1].Y, margin+t[2].X, margin+t[2].Y, hexColor(style.Wall))
+	}
+	for _, gap := range gaps {
+		t := g.gapArrow(gap, float64(size))
+		fmt.Fprintf(bw, "<polygon points=\"%.1f,%.1f %.1f,%.1f %.1f,%.1f\" fill=\"%s\"/>\n",
+			float64(margin)+t[0][0], float64(margin)+t[0][1], float64(margin)+t[1][0], float64(margin)+t[1][1],
+			float64(margin)+t[2][0], float64(margin)+t[2][1], hexColor(style.Wall))
+	}
+	if opts.Markers {
+		// A dot on the start, and on the finish a flag of markerChecks
+		// squares each way, alternately the walls' colour and the
+		// background's.
+		fmt.Fprintf(bw, "<circle cx=\"%d\" cy=\"%d\" r=\"%.1f\" fill=\"%s\"/>\n",
+			margin+start%g.ColCount*size+size/2, margin+start/g.ColCount*size+size/2, markerDot*float64(size), hexColor(markerGreen))
+		flag := markerFlag * float64(size)
+		check := flag / markerChecks
+		x0 := float64(margin+end%g.ColCount*size) + (float64(size)-flag)/2
+		y0 := float64(margin+end/g.ColCount*size) + (float64(size)-flag)/2
+		fmt.Fprintf(bw, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\" stroke=\"%s\"/>\n",
+			x0, y0, flag, flag, hexColor(style.Background), hexColor(style.Wall))
+		for i := 0; i < markerChecks*markerChecks; i += 2 {
+			fmt.Fprintf(bw, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\"/>\n",
+				x0+float64(i%markerChecks)*check, y0+float64(i/markerChecks)*check, check, check, hexColor(style.Wall))
+		}
+	}
+
+	if g.meta != nil {
+		fmt.Fprintf(bw, "<g fill=\"%s\" font-family=\"sans-serif\" font-size=\"%d\" text-anchor=\"middle\" dominant-baseline=\"central\">\n",
+			hexColor(style.Wall), size*2/3)
+		for row := 0; row < g.RowCount; row++ {
+			for col := 0; col < g.ColCount; col++ {
+				if label, ok := g.Meta(Cell{row, col}, LabelKey); ok && label != "" {
+					fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\">%s</text>\n",
+						margin+col*size+size/2, margin+row*size+size/2, html.EscapeString(label))
+				}
+			}
+		}
+		fmt.Fprintf(bw, "</g>\n")
+	}
+	if opts.Order != nil {
+		// Shrink the numbers to fit the longest across a cell.
+		fontSize := min(size*2/3, size*8/5/len(strconv.Itoa(len(opts.Order))))
+		fmt.Fprintf(bw, "<g fill=\"%s\" font-family=\"sans-serif\" font-size=\"%d\" text-anchor=\"middle\" dominant-baseline=\"central\">\n",
+			hexColor(style.Wall), fontSize)
+		for id, n := range opts.Order {
+			if n >= 0 {
+				fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\">%d</text>\n",
+					margin+id%g.ColCount*size+size/2, margin+id/g.ColCount*size+size/2, n)
+			}
+		}
+		fmt.Fprintf(bw, "</g>\n")
+	}
+	if opts.Paths != nil {
+		top := g.RowCount*size + 2*margin
+		fmt.Fprintf(bw, "<g fill=\"%s\" font-family=\"sans-serif\" font-size=\"12\" dominant-baseline=\"central\">\n", hexColor(style.Wall))
+		for i, p := range opts.Paths {
+			y := top + i*legendLine
+			fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"12\" height=\"12\" fill=\"%s\"/>\n", margin, y+2, hexColor(opts.PathColor(i)))
+			fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\">%s</text>\n", margin+18, y+legendLine/2, html.EscapeString(p.Label))
+		}
+		fmt.Fprintf(bw, "</g>\n")
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,169 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// Symmetry is a way of mapping a grid onto itself that MazifySymmetric keeps
+// the maze looking the same under.
+type Symmetry int
+
+const (
+	// MirrorSymmetry reflects the maze left to right.
+	MirrorSymmetry Symmetry = iota
+	// Rotate180Symmetry turns the maze half way around its centre.
+	Rotate180Symmetry
+	// Rotate90Symmetry turns the maze a quarter of the way around its
+	// centre.  It only makes sense for square grids.
+	Rotate90Symmetry
+)
+
+var symmetryNames = map[string]Symmetry{
+	"mirror":    MirrorSymmetry,
+	"rotate180": Rotate180Symmetry,
+	"rotate90":  Rotate90Symmetry,
+}
+
+// LookupSymmetry returns the symmetry named name: mirror, rotate180 or
+// rotate90.
+func LookupSymmetry(name string) (Symmetry, bool) {
+	sym, ok := symmetryNames[name]
+	return sym, ok
+}
+
+// rotate90 maps each direction to the one a quarter turn clockwise from it.
+var rotate90 = map[Direction]Direction{N: E, E: S, S: W, W: N}
+
+// mirror maps each direction to its left-right reflection.
+var mirror = map[Direction]Direction{N: N, E: W, S: S, W: E}
+
+// apply returns where the wall between (row, col) and its neighbour in
+// direction d ends up under the symmetry.
+func (sym Symmetry) apply(g *Grid, e edge) edge {
+	switch sym {
+	case MirrorSymmetry:
+		return edge{e.row, g.ColCount - 1 - e.col, mirror[e.d]}
+	case Rotate180Symmetry:
+		return edge{g.RowCount - 1 - e.row, g.ColCount - 1 - e.col, opposite[e.d]}
+	default:
+		return edge{e.col, g.RowCount - 1 - e.row, rotate90[e.d]}
+	}
+}
+
+// canonical returns the same wall as e, described from whichever of its two
+// cells has it on the east or south side.
+func canonical(e edge) edge {
+	if e.d == N || e.d == W {
+		return edge{e.row + rowOffset[e.d], e.col + colOffset[e.d], opposite[e.d]}
+	}
+	return e
+}
+
+// MazifySymmetric turns the grid into a maze that looks the same under sym.
+//
+// It runs Kruskal's algorithm over groups of walls that map onto each other,
+// knocking down a whole group at a time, and then finishes with ordinary
+// Kruskal to join up anything the symmetric pass couldn't without making a
+// loop.  That fix-up breaks the symmetry in a few places at most, and often
+// not at all.
+func (g *Grid) MazifySymmetric(rng *rand.Rand, sym Symmetry) error {
+	return g.MazifySymmetricContext(context.Background(), rng, sym)
+}
+
+// MazifySymmetricContext is MazifySymmetric but gives up, leaving the maze
+// partly carved, and returns ctx.Err() if ctx is done before it finishes.
+func (g *Grid) MazifySymmetricContext(ctx context.Context, rng *rand.Rand, sym Symmetry) error {
+	if sym == Rotate90Symmetry && g.RowCount != g.ColCount {
+		return fmt.Errorf("rotate90 symmetry needs a square grid, not %dx%d", g.RowCount, g.ColCount)
+	}
+
+	// Group the walls into orbits under sym.
+	seen := make([]bool, 2*len(g.data))
+	key := func(e edge) int {
+		if e.d == S {
+			return 2*g.CellId(e.row, e.col) + 1
+		}
+		return 2 * g.CellId(e.row, e.col)
+	}
+	var orbits [][]edge
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			if g.CellId(row, col)%checkEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			for _, d := range []Direction{E, S} {
+				e := edge{row, col, d}
+				if row+rowOffset[d] >= g.RowCount || col+colOffset[d] >= g.ColCount || seen[key(e)] {
+					continue
+				}
+				var orbit []edge
+				for !seen[key(e)] {
+					seen[key(e)] = true
+					orbit = append(orbit, e)
+					e = canonical(sym.apply(g, e))
+				}
+				orbits = append(orbits, orbit)
+			}
+		}
+	}
+	if err := shuffleContext(ctx, rng, len(orbits), func(i, j int) {
+		orbits[i], orbits[j] = orbits[j], orbits[i]
+	}); err != nil {
+		return err
+	}
+
+	sets := NewDisjointSet(len(g.data))
+	for i, orbit := range orbits {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		// Only knock down the group if doing all of it makes no loops.
+		// Orbits have at most 4 walls, so a throwaway map is fine.
+		merged := map[int]int{}
+		root := func(id int) int {
+			id = sets.Find(id)
+			for next, ok := merged[id]; ok; next, ok = merged[id] {
+				id = next
+			}
+			return id
+		}
+		ok := true
+		for _, e := range orbit {
+			a := root(g.CellId(e.row, e.col))
+			b := root(g.CellId(e.row+rowOffset[e.d], e.col+colOffset[e.d]))
+			if a == b {
+				ok = false
+				break
+			}
+			merged[b] = a
+		}
+		if !ok {
+			continue
+		}
+		for _, e := range orbit {
+			g.carve(e.row, e.col, e.d)
+			sets.Union(g.CellId(e.row, e.col), g.CellId(e.row+rowOffset[e.d], e.col+colOffset[e.d]))
+		}
+	}
+
+	// Connectivity fix-up.
+	for i, orbit := range orbits {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		for _, e := range orbit {
+			if sets.Union(g.CellId(e.row, e.col), g.CellId(e.row+rowOffset[e.d], e.col+colOffset[e.d])) {
+				g.carve(e.row, e.col, e.d)
+			}
+		}
+	}
+	return nil
+}
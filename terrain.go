@@ -0,0 +1,106 @@
+package maze
+
+import (
+	"container/heap"
+	"context"
+	"math"
+)
+
+// TerrainKey is the metadata key TerrainCost looks up, e.g. "mud" or "lava".
+const TerrainKey = "terrain"
+
+// CellCost gives the cost of moving into cell (row, col).  Costs must not be
+// negative.
+type CellCost func(row, col int) float64
+
+// TerrainCost returns a CellCost that charges costs[t] to enter a cell whose
+// TerrainKey metadata is t, and 1 for cells without terrain or with a terrain
+// missing from costs.
+func TerrainCost(g *Grid, costs map[string]float64) CellCost {
+	return func(row, col int) float64 {
+		if terrain, ok := g.Meta(Cell{row, col}, TerrainKey); ok {
+			if cost, ok := costs[terrain]; ok {
+				return cost
+			}
+		}
+		return 1
+	}
+}
+
+// SolveCheapest returns the cheapest path, rather than the shortest, from
+// start to finish, using Dijkstra's
+// algorithm.  It also returns the path's total cost, which doesn't include
+// the start cell.  The path is nil if there isn't one.
+func (g *Grid) SolveCheapest(start, finish Cell, cost CellCost) ([]Cell, float64) {
+	return g.SolveAStar(start, finish, cost, 0)
+}
+
+// SolveAStar is SolveCheapest using A* search, which can skip much of the
+// maze when minCost, the smallest cost any cell can have, is above zero.
+// With minCost 0 it is just Dijkstra's algorithm.
+func (g *Grid) SolveAStar(from, finish Cell, cost CellCost, minCost float64) ([]Cell, float64) {
+	path, total, _ := g.solveAStar(context.Background(), from, finish, cost, minCost)
+	return g.PathCells(path), total
+}
+
+// solveAStar is SolveAStar, returning the path as CellIds and giving up
+// with ctx.Err() if ctx is done before it finishes.
+func (g *Grid) solveAStar(ctx context.Context, from, finish Cell, cost CellCost, minCost float64) ([]int, float64, error) {
+	start, end := g.CellIdOf(from), g.CellIdOf(finish)
+	// Manhattan distance is a lower bound on the number of cells still to
+	// enter.
+	estimate := func(row, col int) float64 {
+		return minCost * float64(abs(row-finish.Row)+abs(col-finish.Col))
+	}
+
+	dist := make([]float64, len(g.data))
+	parent := make([]int, len(g.data))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		parent[i] = -1
+	}
+	dist[start] = 0
+	parent[start] = start
+	queue := &costQueue{{start, estimate(from.Row, from.Col)}}
+	for i := 1; queue.Len() > 0; i++ {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, math.Inf(1), err
+			}
+		}
+		item := heap.Pop(queue).(costItem)
+		row, col := item.id/g.ColCount, item.id%g.ColCount
+		if item.cost > dist[item.id]+estimate(row, col) {
+			continue // stale
+		}
+		if g.Observer != nil {
+			g.Observer.visit(g, item.id)
+		}
+		if item.id == end {
+			break
+		}
+		for _, d := range []Direction{N, E, S, W} {
+			if g.walkable(row, col)&d == 0 {
+				continue
+			}
+			nextRow, nextCol := row+rowOffset[d], col+colOffset[d]
+			next := g.CellId(nextRow, nextCol)
+			if nextDist := dist[item.id] + cost(nextRow, nextCol); nextDist < dist[next] {
+				dist[next] = nextDist
+				parent[next] = item.id
+				heap.Push(queue, costItem{next, nextDist + estimate(nextRow, nextCol)})
+			}
+		}
+	}
+	if parent[end] < 0 {
+		return nil, math.Inf(1), nil
+	}
+	return walkBack(parent, end), dist[end], nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
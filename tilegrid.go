@@ -0,0 +1,45 @@
+package maze
+
+// TileGrid is a Grid with a value of type T for each cell, for games that
+// keep their own data about each tile (terrain, items, fog of war) alongside
+// the maze instead of in a parallel slice.  The Grid is embedded, so the
+// generators, solvers and renderers all work on a TileGrid unchanged:
+//
+//	level, err := NewTileGrid[Tile](20, 30)
+//	if err != nil {
+//		return err
+//	}
+//	level.MazifyKruskal(rng)
+//	level.At(Cell{3, 4}).Torch = true
+type TileGrid[T any] struct {
+	Grid
+	// Tiles holds the cells' values row by row, indexed by CellId.
+	Tiles []T
+}
+
+// NewTileGrid returns a rowCount x colCount TileGrid with every wall up and
+// every tile the zero T, or NewGrid's error for a bad size.
+func NewTileGrid[T any](rowCount, colCount int) (TileGrid[T], error) {
+	g, err := NewGrid(rowCount, colCount)
+	if err != nil {
+		return TileGrid[T]{}, err
+	}
+	return TileGrid[T]{Grid: g, Tiles: make([]T, rowCount*colCount)}, nil
+}
+
+// At returns a pointer to the tile of cell c, to read or change it.
+func (t *TileGrid[T]) At(c Cell) *T {
+	return &t.Tiles[t.CellIdOf(c)]
+}
+
+// Set sets the tile of cell c to v.
+func (t *TileGrid[T]) Set(c Cell, v T) {
+	t.Tiles[t.CellIdOf(c)] = v
+}
+
+// Clone returns a deep copy of the grid, as Grid.Clone does, and a copy of
+// its tiles.  Tiles are copied as values, so any pointers in them are
+// shared.
+func (t *TileGrid[T]) Clone() TileGrid[T] {
+	return TileGrid[T]{Grid: t.Grid.Clone(), Tiles: append([]T(nil), t.Tiles...)}
+}
@@ -0,0 +1,204 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// seamEnd is one side of a seam of a surface: the wall on the d side of a
+// cell, by CellId, at the edge of the net.
+type seamEnd struct {
+	id int
+	d  Direction
+}
+
+// Surface is a topology a maze can be carved on, laid out flat as a net:
+// a Rows x Cols grid, or the cells of it in mask if that's not nil, each
+// next to its neighbours in the grid, plus seams, the edges of the net
+// that are joined to others when it's folded up.
+type Surface struct {
+	Rows, Cols int
+	mask       []bool
+	seams      map[seamEnd]seamEnd
+}
+
+// Mask returns the cells of the net that are on the surface, by CellId, or
+// nil if they all are.
+func (s *Surface) Mask() []bool {
+	return s.mask
+}
+
+// join makes the walls a and b of the net one seam, each leading to the
+// other's cell.
+func (s *Surface) join(a, b seamEnd) {
+	s.seams[a], s.seams[b] = b, a
+}
+
+// surfaces are the topologies --topology takes, each making the surface
+// for a maze of the size given, or an error if it can't be that size.
+var surfaces = map[string]func(rows, cols int) (*Surface, error){
+	// torus joins each edge to the opposite one.
+	"torus": func(rows, cols int) (*Surface, error) {
+		s := &Surface{Rows: rows, Cols: cols, seams: map[seamEnd]seamEnd{}}
+		for row := 0; row < rows; row++ {
+			s.join(seamEnd{row*cols + cols - 1, E}, seamEnd{row * cols, W})
+		}
+		for col := 0; col < cols; col++ {
+			s.join(seamEnd{(rows-1)*cols + col, S}, seamEnd{col, N})
+		}
+		return s, nil
+	},
+	// mobius joins the left and right edges with a half twist, so the
+	// top row leads round into the bottom one.
+	"mobius": func(rows, cols int) (*Surface, error) {
+		s := &Surface{Rows: rows, Cols: cols, seams: map[seamEnd]seamEnd{}}
+		for row := 0; row < rows; row++ {
+			s.join(seamEnd{row*cols + cols - 1, E}, seamEnd{(rows - 1 - row) * cols, W})
+		}
+		return s, nil
+	},
+	// cube folds six rows x rows faces up into a cube from a cross-shaped
+	// net: the top face over the front, then the left, front, right and
+	// back faces in a band, then the bottom face under the front.
+	"cube": func(rows, cols int) (*Surface, error) {
+		if rows != cols {
+			return nil, fmt.Errorf("a cube's faces are square, so it can't be %dx%d", rows, cols)
+		}
+		n := rows
+		s := &Surface{Rows: 3 * n, Cols: 4 * n, mask: make([]bool, 12*n*n), seams: map[seamEnd]seamEnd{}}
+		// The faces, by the row and column of the block of the net they're in.
+		for _, face := range [][2]int{{0, 1}, {1, 0}, {1, 1}, {1, 2}, {1, 3}, {2, 1}} {
+			for row := face[0] * n; row < (face[0]+1)*n; row++ {
+				for col := face[1] * n; col < (face[1]+1)*n; col++ {
+					s.mask[row*s.Cols+col] = true
+				}
+			}
+		}
+		at := func(row, col int, d Direction) seamEnd {
+			return seamEnd{row*s.Cols + col, d}
+		}
+		for i := 0; i < n; i++ {
+			// The back face's right edge and the left face's left.
+			s.join(at(n+i, 4*n-1, E), at(n+i, 0, W))
+			// The top face's edges and the tops of the faces round it.
+			s.join(at(i, n, W), at(n, i, N))
+			s.join(at(i, 2*n-1, E), at(n, 3*n-1-i, N))
+			s.join(at(0, n+i, N), at(n, 4*n-1-i, N))
+			// The bottom face's edges and the bottoms of the faces round it.
+			s.join(at(2*n+i, n, W), at(2*n-1, n-1-i, S))
+			s.join(at(2*n+i, 2*n-1, E), at(2*n-1, 2*n+i, S))
+			s.join(at(3*n-1, n+i, S), at(2*n-1, 4*n-1-i, S))
+		}
+		return s, nil
+	},
+}
+
+// NewSurface returns the surface of the named topology for a rows x cols
+// maze, whose net may be bigger, or an error if it can't be that size.
+func NewSurface(topology string, rows, cols int) (*Surface, error) {
+	build, ok := surfaces[topology]
+	if !ok {
+		return nil, fmt.Errorf("unknown topology %q", topology)
+	}
+	return build(rows, cols)
+}
+
+// TopologyNames returns the names of the surfaces, sorted.
+func TopologyNames() []string {
+	names := make([]string, 0, len(surfaces))
+	for name := range surfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// surfaceGraph is the cells of a grid laid out as a surface's net as a
+// Graph, so the MazifyGraph functions carve a maze across its seams.
+type surfaceGraph struct {
+	*maskGraph
+	seams map[seamEnd]seamEnd
+	// passages are the seams carved through, each by one of its ends.
+	passages []seamEnd
+}
+
+// Degree returns 8: a cell's four sides in the grid, then the same four
+// again for the seams they may lead through.
+func (sg *surfaceGraph) Degree() int {
+	return 2 * len(gridSides)
+}
+
+func (sg *surfaceGraph) Neighbour(node, side int) int {
+	if side < len(gridSides) {
+		return sg.maskGraph.Neighbour(node, side)
+	}
+	if end, ok := sg.seams[seamEnd{sg.cells[node], gridSides[side-len(gridSides)]}]; ok {
+		return sg.nodes[end.id]
+	}
+	return -1
+}
+
+func (sg *surfaceGraph) Connect(a, b int) {
+	g, from, to := sg.grid, sg.cells[a], sg.cells[b]
+	row, col := from/g.ColCount, from%g.ColCount
+	for _, d := range []Direction{N, E, S, W} {
+		if r, c := row+rowOffset[d], col+colOffset[d]; g.inside(r, c) && g.CellId(r, c) == to {
+			g.carve(row, col, d)
+			return
+		}
+		if end, ok := sg.seams[seamEnd{from, d}]; ok && end.id == to {
+			sg.passages = append(sg.passages, seamEnd{from, d})
+			return
+		}
+	}
+}
+
+// seamLabel returns the label of the ith seam carved through: a to z, then
+// aa, ab and so on.
+func seamLabel(i int) string {
+	var label []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = append([]byte{byte('a' + (i-1)%26)}, label...)
+	}
+	return string(label)
+}
+
+// MazifySurface carves a maze with the named algorithm, one of
+// shapeAlgorithms, on s, into g laid out as s's net, which g must be the
+// size of.  The walls of the net stay up where the maze goes through a
+// seam; instead the cells either side of it are labelled alike, a, b, c
+// and so on, with LabelKey, separated by commas in a cell with more than
+// one.  The first and last cells of the net, reading row by row, become
+// the start and finish.  It returns ctx.Err() if ctx is done before it
+// finishes.
+func (g *Grid) MazifySurface(ctx context.Context, rng *rand.Rand, s *Surface, algorithm string) error {
+	carve, ok := shapeAlgorithms[algorithm]
+	if !ok {
+		return fmt.Errorf("algorithm %q can't carve on a surface, only kruskal, prim, rec and wilson can", algorithm)
+	}
+	mask := s.mask
+	if mask == nil {
+		mask = make([]bool, len(g.data))
+		for id := range mask {
+			mask[id] = true
+		}
+	}
+	sg := &surfaceGraph{maskGraph: newMaskGraph(g, mask), seams: s.seams}
+	g.Entrances, g.Exits = []Cell{g.CellOf(sg.cells[0])}, []Cell{g.CellOf(sg.cells[len(sg.cells)-1])}
+	if err := carve(ctx, sg, rng, 0); err != nil {
+		return err
+	}
+	for i, p := range sg.passages {
+		for _, id := range []int{p.id, s.seams[p].id} {
+			row, col := id/g.ColCount, id%g.ColCount
+			label := seamLabel(i)
+			if old, ok := g.Meta(Cell{row, col}, LabelKey); ok {
+				label = old + "," + label
+			}
+			g.SetMeta(Cell{row, col}, LabelKey, label)
+		}
+	}
+	return nil
+}
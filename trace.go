@@ -0,0 +1,87 @@
+package maze
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TraceStep is a line of a generation trace, as --trace writes it: one wall
+// the generator removed.
+type TraceStep struct {
+	// Step counts the walls removed so far, from 0, across every attempt.
+	Step int `json:"step"`
+	// Attempt counts how many times the maze was cleared to start over
+	// before this step, for generators retrying to meet a constraint.
+	Attempt int `json:"attempt,omitempty"`
+	// Phase is the last phase the algorithm sent to Observer.Event, e.g.
+	// "stitch", or "carve" before it's sent any.
+	Phase string `json:"phase"`
+	// The wall removed is on the Dir side of cell (Row, Col).
+	Row int    `json:"row"`
+	Col int    `json:"col"`
+	Dir string `json:"dir"`
+}
+
+// ReplayTrace carves into g the walls a trace, as --trace writes it, says
+// were removed, in order, clearing g each time the trace starts another
+// attempt, so g ends up as the maze that was traced, and g's Observer sees
+// it carved again.  g should be the size of the traced maze, with every
+// wall up.
+func ReplayTrace(g *Grid, r io.Reader) error {
+	steps, err := ReadTrace(r)
+	if err != nil {
+		return err
+	}
+	return ReplaySteps(g, steps)
+}
+
+// ReadTrace reads the steps of a trace, as --trace writes it.
+func ReadTrace(r io.Reader) ([]TraceStep, error) {
+	scanner := bufio.NewScanner(r)
+	var steps []TraceStep
+	for line := 1; scanner.Scan(); line++ {
+		var step TraceStep
+		if err := json.Unmarshal(scanner.Bytes(), &step); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+		if _, ok := traceDirections[step.Dir]; !ok {
+			return nil, fmt.Errorf("trace line %d: bad direction %q", line, step.Dir)
+		}
+		steps = append(steps, step)
+	}
+	return steps, scanner.Err()
+}
+
+// traceDirections are the directions by the names traces give them.
+var traceDirections = map[string]Direction{"N": N, "E": E, "S": S, "W": W}
+
+// TraceSize returns the size of the smallest grid that holds every wall
+// steps removes, the size of the traced maze unless its last rows or
+// columns were never carved into.
+func TraceSize(steps []TraceStep) (rows, cols int) {
+	for _, step := range steps {
+		d := traceDirections[step.Dir]
+		rows = max(rows, step.Row+1, step.Row+rowOffset[d]+1)
+		cols = max(cols, step.Col+1, step.Col+colOffset[d]+1)
+	}
+	return rows, cols
+}
+
+// ReplaySteps is ReplayTrace with the trace already read.
+func ReplaySteps(g *Grid, steps []TraceStep) error {
+	attempt := 0
+	for _, step := range steps {
+		d := traceDirections[step.Dir]
+		if err := g.checkNeighbour(step.Row, step.Col, d); err != nil {
+			return fmt.Errorf("trace step %d: %w", step.Step, err)
+		}
+		if step.Attempt != attempt {
+			attempt = step.Attempt
+			g.Clear()
+		}
+		g.carve(step.Row, step.Col, d)
+	}
+	return nil
+}
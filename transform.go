@@ -0,0 +1,123 @@
+package maze
+
+import "fmt"
+
+// flipVertical maps each direction to its top-bottom reflection.
+var flipVertical = map[Direction]Direction{N: S, E: E, S: N, W: W}
+
+// remap returns cell's openings with every direction d replaced by m[d].
+func remap(cell uint8, m map[Direction]Direction) uint8 {
+	var out Direction
+	for _, d := range []Direction{N, E, S, W} {
+		if Direction(cell)&d != 0 {
+			out |= m[d]
+		}
+	}
+	return uint8(out)
+}
+
+// Rotate90 returns a copy of the maze turned a quarter turn clockwise, so the
+// result has the original's column count as its row count.
+func (g *Grid) Rotate90() Grid {
+	out := newGrid(g.ColCount, g.RowCount)
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			out.data[out.CellId(col, g.RowCount-1-row)] = remap(g.data[g.CellId(row, col)], rotate90)
+		}
+	}
+	g.moveOneWays(&out, rotate90, func(row, col int) (int, int) { return col, g.RowCount - 1 - row })
+	return out
+}
+
+// MirrorH returns a copy of the maze flipped left to right.
+func (g *Grid) MirrorH() Grid {
+	out := newGrid(g.RowCount, g.ColCount)
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			out.data[out.CellId(row, g.ColCount-1-col)] = remap(g.data[g.CellId(row, col)], mirror)
+		}
+	}
+	g.moveOneWays(&out, mirror, func(row, col int) (int, int) { return row, g.ColCount - 1 - col })
+	return out
+}
+
+// MirrorV returns a copy of the maze flipped top to bottom.
+func (g *Grid) MirrorV() Grid {
+	out := newGrid(g.RowCount, g.ColCount)
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			out.data[out.CellId(g.RowCount-1-row, col)] = remap(g.data[g.CellId(row, col)], flipVertical)
+		}
+	}
+	g.moveOneWays(&out, flipVertical, func(row, col int) (int, int) { return g.RowCount - 1 - row, col })
+	return out
+}
+
+// moveOneWays gives out g's one-way passages, each cell's moved to where
+// to puts it and its directions turned by m.
+func (g *Grid) moveOneWays(out *Grid, m map[Direction]Direction, to func(row, col int) (int, int)) {
+	for id, d := range g.oneWay {
+		if out.oneWay == nil {
+			out.oneWay = map[int]Direction{}
+		}
+		row, col := to(id/g.ColCount, id%g.ColCount)
+		out.oneWay[out.CellId(row, col)] = Direction(remap(uint8(d), m))
+	}
+}
+
+// Subgrid returns a copy of the cells from start, its top left corner, up to
+// but not including end's row and column, with their one-way passages and
+// metadata.  Passages that led out of that region are walled off, so the
+// result may not be fully connected even if g was.  Like NewGrid, it returns
+// an error if the region is empty, or if it isn't inside g.
+func (g *Grid) Subgrid(start, end Cell) (Grid, error) {
+	if start.Row < 0 || start.Col < 0 || end.Row > g.RowCount || end.Col > g.ColCount || start.Row >= end.Row || start.Col >= end.Col {
+		return Grid{}, fmt.Errorf("bad subgrid rows %d to %d and cols %d to %d of a %dx%d grid",
+			start.Row, end.Row, start.Col, end.Col, g.RowCount, g.ColCount)
+	}
+	out := newGrid(end.Row-start.Row, end.Col-start.Col)
+	for row := 0; row < out.RowCount; row++ {
+		for col := 0; col < out.ColCount; col++ {
+			cell := g.openings(start.Row+row, start.Col+col)
+			if row == 0 {
+				cell &^= N
+			}
+			if row == out.RowCount-1 {
+				cell &^= S
+			}
+			if col == 0 {
+				cell &^= W
+			}
+			if col == out.ColCount-1 {
+				cell &^= E
+			}
+			out.data[out.CellId(row, col)] = uint8(cell)
+		}
+	}
+	for id, blocked := range g.oneWay {
+		if sub := g.subgridId(&out, start, id); sub >= 0 {
+			if out.oneWay == nil {
+				out.oneWay = map[int]Direction{}
+			}
+			out.oneWay[sub] = blocked
+		}
+	}
+	for id := range g.meta {
+		if sub := g.subgridId(&out, start, id); sub >= 0 {
+			for key, value := range g.meta[id] {
+				out.SetMeta(out.CellOf(sub), key, value)
+			}
+		}
+	}
+	return out, nil
+}
+
+// subgridId maps CellId id of g to the CellId of the same cell in sub, the
+// Subgrid of g starting at start, or -1 if it isn't in sub.
+func (g *Grid) subgridId(sub *Grid, start Cell, id int) int {
+	row, col := id/g.ColCount-start.Row, id%g.ColCount-start.Col
+	if row < 0 || row >= sub.RowCount || col < 0 || col >= sub.ColCount {
+		return -1
+	}
+	return sub.CellId(row, col)
+}
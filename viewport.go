@@ -0,0 +1,98 @@
+package maze
+
+// Viewport returns the part of the maze from start up to end, as Subgrid
+// does, with opts changed to match: regions, the paths and markers are
+// cropped to it too.  Labels, one-way passages and entrances and exits
+// inside it are kept.  Any renderer can draw the result.  It returns
+// Subgrid's error if the viewport is empty or outside the maze.
+func (g *Grid) Viewport(opts RenderOptions, start, end Cell) (Grid, RenderOptions, error) {
+	out, err := g.Subgrid(start, end)
+	if err != nil {
+		return Grid{}, opts, err
+	}
+	// inside maps a CellId of g to one of out, or -1.
+	inside := func(id int) int { return g.subgridId(&out, start, id) }
+
+	// Markers for a start or finish outside the viewport mustn't land on
+	// its corners, where endpoints would put them.
+	entrance, exit := g.endpoints()
+	style := opts.style()
+	if sub := inside(entrance); sub >= 0 {
+		out.Entrances = []Cell{out.CellOf(sub)}
+	} else {
+		style.Start.A = 0
+	}
+	if sub := inside(exit); sub >= 0 {
+		out.Exits = []Cell{out.CellOf(sub)}
+	} else {
+		style.Finish.A = 0
+	}
+	opts.Style = &style
+
+	if opts.Regions != nil {
+		regions := make([]int, len(out.data))
+		for id, r := range opts.Regions {
+			if sub := inside(id); sub >= 0 {
+				regions[sub] = r
+			}
+		}
+		opts.Regions = regions
+	}
+	if opts.Order != nil {
+		order := make([]int, len(out.data))
+		for id, n := range opts.Order {
+			if sub := inside(id); sub >= 0 {
+				order[sub] = n
+			}
+		}
+		opts.Order = order
+	}
+	if opts.Mask != nil {
+		mask := make([]bool, len(out.data))
+		for id, in := range opts.Mask {
+			if sub := inside(id); sub >= 0 {
+				mask[sub] = in
+			}
+		}
+		opts.Mask = mask
+	}
+	crop := func(path []Cell) []Cell {
+		if path == nil {
+			return nil
+		}
+		var cropped []Cell
+		for _, c := range path {
+			if sub := inside(g.CellIdOf(c)); sub >= 0 {
+				cropped = append(cropped, out.CellOf(sub))
+			}
+		}
+		return cropped
+	}
+	opts.Path, opts.Spine = crop(opts.Path), crop(opts.Spine)
+	if opts.Paths != nil {
+		paths := make([]LabeledPath, len(opts.Paths))
+		for i, p := range opts.Paths {
+			paths[i] = LabeledPath{p.Label, crop(p.Cells)}
+		}
+		opts.Paths = paths
+	}
+	return out, opts, nil
+}
+
+// pathRuns splits path into the runs of neighbouring cells it's made of,
+// for drawing one that's been cropped and may leave and come back.
+func (g *Grid) pathRuns(path []int) [][]int {
+	var runs [][]int
+	start := 0
+	for i := 1; i < len(path); i++ {
+		rows, cols := abs(path[i]/g.ColCount-path[i-1]/g.ColCount), abs(path[i]%g.ColCount-path[i-1]%g.ColCount)
+		if rows+cols != 1 {
+			runs = append(runs, path[start:i])
+			start = i
+		}
+	}
+	if start < len(path) {
+		runs = append(runs, path[start:])
+	}
+	return runs
+}
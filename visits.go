@@ -0,0 +1,57 @@
+package maze
+
+import (
+	"errors"
+	"image/color"
+	"io"
+)
+
+// visitHeat is the visits format's gradient, from the cells visited once to
+// the ones visited most, unless there's a Palette with a Heat gradient.
+var visitHeat = []color.RGBA{{255, 237, 160, 255}, {254, 178, 76, 255}, {240, 59, 32, 255}, {128, 0, 38, 255}}
+
+// CountVisits solves g with s from start to finish like FindPath, and also
+// returns how many times s visited each cell, by CellId, as it searched:
+// the cells a solver keeps coming back to are the maze's traps.
+func CountVisits(s Solver, g *Grid, start, finish Cell) (path []Cell, visits []int, err error) {
+	if g == nil || len(g.data) == 0 {
+		return nil, nil, errors.New("no maze to solve")
+	}
+	c := g.Clone()
+	visits = make([]int, len(c.data))
+	c.Observer = &Observer{Visit: func(v Cell) { visits[c.CellIdOf(v)]++ }}
+	path, err = FindPath(s, &c, start, finish)
+	return path, visits, err
+}
+
+// renderVisits draws the maze as a PNG with each cell shaded by how many
+// times a solver visited it: white for none, then along visitHeat (or the
+// Palette's Heat) up to the most visited.  It shades opts.Visits, or counts
+// bfs's visits from the start to the finish if that's nil.
+func renderVisits(g *Grid, w io.Writer, opts RenderOptions) error {
+	if opts.Visits == nil {
+		start, finish := g.Endpoints()
+		var err error
+		if _, opts.Visits, err = CountVisits(solvers["bfs"], g, start, finish); err != nil {
+			return err
+		}
+	}
+	// The start and finish would hide their shading.
+	style := opts.style()
+	style.Start, style.Finish = color.RGBA{}, color.RGBA{}
+	opts.Style = &style
+	return renderPNG(g, w, opts)
+}
+
+// visitColor returns the colour of a cell visited n times, when no cell
+// was visited more than most times.
+func (opts RenderOptions) visitColor(n, most int) color.RGBA {
+	p := &Palette{Heat: visitHeat}
+	if opts.Palette != nil && len(opts.Palette.Heat) > 0 {
+		p = opts.Palette
+	}
+	if most <= 1 {
+		return p.heat(0)
+	}
+	return p.heat(float64(n-1) / float64(most-1))
+}
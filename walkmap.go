@@ -0,0 +1,114 @@
+package maze
+
+import "io"
+
+// WalkMap is a maze expanded into square tiles, each floor or wall, the way
+// tile based games lay out their levels: passages Corridor tiles wide, so
+// bigger characters fit through and players can pass side by side, with
+// the walls between them, and the corners where walls meet, a tile thick.
+type WalkMap struct {
+	Corridor      int
+	Width, Height int
+	// Floor is whether each tile can be walked on, row by row.
+	Floor []bool
+}
+
+// WalkMap returns the maze as tiles with passages corridor tiles wide, or
+// one if corridor is less than that.
+func (g *Grid) WalkMap(corridor int) *WalkMap {
+	corridor = max(corridor, 1)
+	m := &WalkMap{
+		Corridor: corridor,
+		Width:    g.ColCount*(corridor+1) + 1,
+		Height:   g.RowCount*(corridor+1) + 1,
+	}
+	m.Floor = make([]bool, m.Width*m.Height)
+	for row := 0; row < g.RowCount; row++ {
+		for col := 0; col < g.ColCount; col++ {
+			x0, y0 := m.CellTile(Cell{row, col})
+			open := g.openings(row, col)
+			// The cell's floor, then the wall to its east and the one to its
+			// south, knocked through the whole width if they're open.
+			for y := y0; y < y0+corridor; y++ {
+				for x := x0; x < x0+corridor; x++ {
+					m.Floor[y*m.Width+x] = true
+				}
+			}
+			for i := 0; i < corridor; i++ {
+				if col < g.ColCount-1 && open&E != 0 {
+					m.Floor[(y0+i)*m.Width+x0+corridor] = true
+				}
+				if row < g.RowCount-1 && open&S != 0 {
+					m.Floor[(y0+corridor)*m.Width+x0+i] = true
+				}
+			}
+		}
+	}
+	return m
+}
+
+// CellTile returns the tile at the top left of cell c's floor.
+func (m *WalkMap) CellTile(c Cell) (x, y int) {
+	return 1 + c.Col*(m.Corridor+1), 1 + c.Row*(m.Corridor+1)
+}
+
+// Walkable reports whether tile (x, y) is floor.  Tiles off the map aren't.
+func (m *WalkMap) Walkable(x, y int) bool {
+	return x >= 0 && x < m.Width && y >= 0 && y < m.Height && m.Floor[y*m.Width+x]
+}
+
+// renderTiles draws the maze as a WalkMap, opts.Corridor tiles wide: a line
+// of text for each row of tiles, # for wall and . for floor, with S and F
+// in the middle of the start and finish cells and the solution, if there
+// is one, as o down the middle of its passages.
+func renderTiles(g *Grid, w io.Writer, opts RenderOptions) error {
+	m := g.WalkMap(opts.Corridor)
+	lines := make([][]byte, m.Height)
+	for y := range lines {
+		lines[y] = make([]byte, m.Width, m.Width+1)
+		for x := range lines[y] {
+			lines[y][x] = '#'
+			if m.Floor[y*m.Width+x] {
+				lines[y][x] = '.'
+			}
+		}
+	}
+	m.pathTiles(g, g.CellIds(opts.Path), func(x, y int) { lines[y][x] = 'o' })
+	start, end := g.endpoints()
+	for _, mark := range []struct {
+		id   int
+		char byte
+	}{{start, 'S'}, {end, 'F'}} {
+		x, y := m.middle(g, mark.id)
+		lines[y][x] = mark.char
+	}
+	var buf []byte
+	for _, line := range lines {
+		buf = append(append(buf, line...), '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// middle returns the tile in the middle of the floor of g's cell id.
+func (m *WalkMap) middle(g *Grid, id int) (x, y int) {
+	x, y = m.CellTile(g.CellOf(id))
+	return x + (m.Corridor-1)/2, y + (m.Corridor-1)/2
+}
+
+// pathTiles calls mark with each tile path, CellIds of g, goes over, down
+// the middle of its passages.
+func (m *WalkMap) pathTiles(g *Grid, path []int, mark func(x, y int)) {
+	for i, id := range path {
+		x, y := m.middle(g, id)
+		mark(x, y)
+		if i > 0 {
+			// Fill in the tiles back to the cell before.
+			d := g.direction(id, path[i-1])
+			for j := 0; j < m.Corridor; j++ {
+				x, y = x+colOffset[d], y+rowOffset[d]
+				mark(x, y)
+			}
+		}
+	}
+}
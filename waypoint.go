@@ -0,0 +1,165 @@
+package maze
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// MazifyThrough turns the grid into a maze whose solution from the first cell
+// in stops to the last visits all of the others in order.  There must be at
+// least two, and each cell may only be given once: a route that came back to
+// a stop would have to cross itself.
+//
+// It first carves a random route through the stops, never reusing a cell, and
+// then grows the rest of the maze off that route with Kruskal's algorithm.
+// Since the result is a spanning tree the route is the only way from start to
+// finish.
+func (g *Grid) MazifyThrough(rng *rand.Rand, stops []Cell) error {
+	return g.MazifyThroughContext(context.Background(), rng, stops)
+}
+
+// MazifyThroughContext is MazifyThrough but gives up, leaving the maze partly
+// carved, and returns ctx.Err() if ctx is done before it finishes.
+func (g *Grid) MazifyThroughContext(ctx context.Context, rng *rand.Rand, stops []Cell) error {
+	if len(stops) < 2 {
+		return fmt.Errorf("%d stops given, need a start and a finish at least", len(stops))
+	}
+	seen := make(map[Cell]bool, len(stops))
+	for _, stop := range stops {
+		if !g.Contains(stop) {
+			return fmt.Errorf("stop %v is outside the %dx%d grid", stop, g.RowCount, g.ColCount)
+		}
+		if seen[stop] {
+			return fmt.Errorf("stop %v is given more than once", stop)
+		}
+		seen[stop] = true
+	}
+	ids := g.CellIds(stops)
+	var route []int
+	for attempt := 0; route == nil; attempt++ {
+		if attempt == maxAttempts {
+			return fmt.Errorf("no route through all %d stops", len(stops))
+		}
+		route = g.randomRoute(rng, ids)
+	}
+
+	s, err := newKruskalStepper(ctx, g, rng, g.GeneratorVersion == 1)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(route); i++ {
+		from := g.CellOf(route[i-1])
+		g.carve(from.Row, from.Col, g.direction(route[i-1], route[i]))
+		s.sets.Union(route[i-1], route[i])
+	}
+	return runSteps(ctx, s)
+}
+
+// direction returns the direction from cell from to its neighbour to, both
+// given as CellIds, or 0 if they aren't neighbours.
+func (g *Grid) direction(from, to int) Direction {
+	if !g.adjacent(from, to) {
+		return 0
+	}
+	switch to - from {
+	case -g.ColCount:
+		return N
+	case g.ColCount:
+		return S
+	case 1:
+		return E
+	}
+	return W
+}
+
+// randomRoute returns a path of CellIds through stops, in order, that
+// doesn't visit any cell twice, or nil if it painted itself into a corner.
+// Each leg is the cheapest path to the next stop with random cell costs, so
+// routes wander instead of running in straight lines.
+func (g *Grid) randomRoute(rng *rand.Rand, stops []int) []int {
+	cost := make([]float64, len(g.data))
+	for i := range cost {
+		cost[i] = rng.Float64()
+	}
+	used := make([]bool, len(g.data))
+	for _, stop := range stops {
+		used[stop] = true
+	}
+
+	route := []int{stops[0]}
+	for i := 1; i < len(stops); i++ {
+		// The leg's own end is the only stop it may step on.
+		used[stops[i]] = false
+		leg := g.cheapestPath(stops[i-1], stops[i], cost, used)
+		if leg == nil {
+			return nil
+		}
+		for _, id := range leg {
+			used[id] = true
+		}
+		route = append(route, leg[1:]...)
+	}
+	return route
+}
+
+// cheapestPath returns the path from start to end, ignoring walls, that
+// minimizes the total cost of the cells entered and avoids cells marked
+// blocked.  It returns nil if there isn't one.
+func (g *Grid) cheapestPath(start, end int, cost []float64, blocked []bool) []int {
+	dist := make([]float64, len(g.data))
+	parent := make([]int, len(g.data))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[start] = start
+	queue := &costQueue{{start, 0}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(costItem)
+		if item.id == end {
+			break
+		}
+		if item.cost > dist[item.id] {
+			continue // stale
+		}
+		row, col := item.id/g.ColCount, item.id%g.ColCount
+		for _, d := range []Direction{N, E, S, W} {
+			nextRow, nextCol := row+rowOffset[d], col+colOffset[d]
+			if nextRow < 0 || nextRow >= g.RowCount || nextCol < 0 || nextCol >= g.ColCount {
+				continue
+			}
+			next := g.CellId(nextRow, nextCol)
+			nextCost := item.cost + cost[next]
+			if blocked[next] || (parent[next] >= 0 && dist[next] <= nextCost) {
+				continue
+			}
+			dist[next] = nextCost
+			parent[next] = item.id
+			heap.Push(queue, costItem{next, nextCost})
+		}
+	}
+	if parent[end] < 0 {
+		return nil
+	}
+	return walkBack(parent, end)
+}
+
+// costQueue is a min-heap of cells by cost, for Dijkstra's algorithm.
+type costQueue []costItem
+
+type costItem struct {
+	id   int
+	cost float64
+}
+
+func (q costQueue) Len() int            { return len(q) }
+func (q costQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q costQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *costQueue) Push(x interface{}) { *q = append(*q, x.(costItem)) }
+func (q *costQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
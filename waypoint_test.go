@@ -0,0 +1,54 @@
+package maze
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestMazifyThrough(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		rows, cols := 4+rng.Intn(6), 4+rng.Intn(6)
+		g := newGrid(rows, cols)
+		var stops []Cell
+		for _, id := range rng.Perm(rows * cols)[:2+rng.Intn(3)] {
+			stops = append(stops, g.CellOf(id))
+		}
+		if err := g.MazifyThrough(rng, stops); err != nil {
+			t.Errorf("seed %d: %dx%d through %v: %v", seed, rows, cols, stops, err)
+			continue
+		}
+		links := 0
+		for range g.Links() {
+			links++
+		}
+		if dist, _ := g.bfsFrom([]int{0}); links != rows*cols-1 || slices.Contains(dist, -1) {
+			t.Errorf("seed %d: %dx%d through %v isn't a spanning tree", seed, rows, cols, stops)
+		}
+		// The solution has to pass through the stops in the order given.
+		path := g.Solve(stops[0], stops[len(stops)-1])
+		at := 0
+		for _, stop := range stops {
+			i := slices.Index(path[at:], stop)
+			if i < 0 {
+				t.Errorf("seed %d: the solution %v doesn't pass through %v in order", seed, path, stops)
+				break
+			}
+			at += i
+		}
+	}
+
+	g := newGrid(5, 5)
+	if err := g.MazifyThrough(rand.New(rand.NewSource(3)), []Cell{{0, 0}, {1, 1}, {3, 3}, {1, 1}, {4, 4}}); err == nil {
+		t.Error("a stop given twice: got no error")
+	}
+	for range g.Links() {
+		t.Fatal("a stop given twice: carved anyway")
+	}
+	for _, stops := range [][]Cell{nil, {{2, 2}}} {
+		if err := g.MazifyThrough(rand.New(rand.NewSource(3)), stops); err == nil {
+			t.Errorf("%d stops: got no error", len(stops))
+		}
+	}
+}
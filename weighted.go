@@ -0,0 +1,87 @@
+package maze
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math/rand"
+	"sort"
+)
+
+// EdgeWeight gives the cost of the passage between (row, col) and its
+// neighbour in direction d.  Weighted Kruskal carves cheap passages first.
+type EdgeWeight func(row, col int, d Direction) float64
+
+// MazifyWeightedKruskal turns the grid into a maze using Kruskal's algorithm,
+// considering edges cheapest first instead of in random order.  Edges with
+// equal weight are taken in random order, so a weight function that only
+// returns a few distinct values (e.g. 1 for vertical edges, 0 otherwise)
+// still produces a random maze.
+func (g *Grid) MazifyWeightedKruskal(rng *rand.Rand, weight EdgeWeight) {
+	g.MazifyWeightedKruskalContext(context.Background(), rng, weight)
+}
+
+// MazifyWeightedKruskalContext is MazifyWeightedKruskal but gives up,
+// leaving the maze partly carved, and returns ctx.Err() if ctx is done
+// before it finishes.
+func (g *Grid) MazifyWeightedKruskalContext(ctx context.Context, rng *rand.Rand, weight EdgeWeight) error {
+	s, err := newWeightedKruskalStepper(ctx, g, rng, weight)
+	if err != nil {
+		return err
+	}
+	return runSteps(ctx, s)
+}
+
+// NewWeightedKruskalStepper returns a Stepper that carves g with weighted
+// Kruskal, as in MazifyWeightedKruskal.
+func NewWeightedKruskalStepper(g *Grid, rng *rand.Rand, weight EdgeWeight) *KruskalStepper {
+	// It can only fail if the context is done.
+	s, _ := newWeightedKruskalStepper(context.Background(), g, rng, weight)
+	return s
+}
+
+// newWeightedKruskalStepper is NewWeightedKruskalStepper, giving up with
+// ctx.Err() if ctx is done while it lists and shuffles the edges.
+func newWeightedKruskalStepper(ctx context.Context, g *Grid, rng *rand.Rand, weight EdgeWeight) (*KruskalStepper, error) {
+	s, err := newKruskalStepper(ctx, g, rng, g.GeneratorVersion == 1)
+	if err != nil {
+		return nil, err
+	}
+	byWeight := weightedEdges{s.edges, make([]float64, len(s.edges))}
+	for i, e := range s.edges {
+		edge := s.edge(e)
+		byWeight.weights[i] = weight(edge.row, edge.col, edge.d)
+	}
+	// The edges are already shuffled, so a stable sort breaks ties randomly.
+	sort.Stable(byWeight)
+	return s, nil
+}
+
+// weightedEdges sorts edges by their weights.
+type weightedEdges struct {
+	edges   []uint32 // kruskalEdges
+	weights []float64
+}
+
+func (w weightedEdges) Len() int           { return len(w.edges) }
+func (w weightedEdges) Less(i, j int) bool { return w.weights[i] < w.weights[j] }
+func (w weightedEdges) Swap(i, j int) {
+	w.edges[i], w.edges[j] = w.edges[j], w.edges[i]
+	w.weights[i], w.weights[j] = w.weights[j], w.weights[i]
+}
+
+// ImageWeight returns an EdgeWeight for g that makes passages cheap through
+// the dark parts of img and expensive through the light parts, with img
+// stretched to cover the whole grid.  Weights run from 0 (black) to 1
+// (white).
+func ImageWeight(g *Grid, img image.Image) EdgeWeight {
+	bounds := img.Bounds()
+	brightness := func(row, col int) float64 {
+		x := bounds.Min.X + (col*bounds.Dx()+bounds.Dx()/2)/g.ColCount
+		y := bounds.Min.Y + (row*bounds.Dy()+bounds.Dy()/2)/g.RowCount
+		return float64(color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y) / 0xffff
+	}
+	return func(row, col int, d Direction) float64 {
+		return (brightness(row, col) + brightness(row+rowOffset[d], col+colOffset[d])) / 2
+	}
+}